@@ -0,0 +1,211 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// CachingDiscoverer is a Discoverer that remembers the answers of the
+// operating system for a fixed time to live.
+//
+// Concurrent calls for the same answer share a single OS query: if N
+// goroutines ask for the default gateway while no cached value exists,
+// the route table is read once and all N receive the result.
+//
+// Errors are shared with the callers that were waiting for them but are
+// not cached, so the next call after a failure queries the OS again.
+//
+// On operating systems that can report routing changes (Linux, the BSDs,
+// Darwin and Windows) the cache is invalidated automatically whenever the
+// routing table or the interface addresses change.
+type CachingDiscoverer struct {
+	backend Discoverer
+	ttl     time.Duration
+
+	// now is replaced in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+	watcher io.Closer
+}
+
+type cacheKey int
+
+const (
+	cacheGateways cacheKey = iota
+	cacheInterface
+	cacheGatewaysIPv6
+	cacheInterfaceIPv6
+	cacheSnapshot
+)
+
+type cacheEntry struct {
+	// done is closed once value and err are set.
+	done    chan struct{}
+	expires time.Time
+	value   any
+	err     error
+}
+
+// NewCachingDiscoverer returns a Discoverer that caches the answers of the
+// operating system for ttl. A ttl of zero or less disables caching but
+// still deduplicates concurrent lookups.
+//
+// Call Close to stop watching for routing changes once the
+// CachingDiscoverer is no longer needed.
+func NewCachingDiscoverer(ttl time.Duration) *CachingDiscoverer {
+	c := newCachingDiscoverer(osDiscoverer{}, ttl)
+	if w, err := watchRouteChanges(c.Invalidate); err == nil {
+		c.watcher = w
+	}
+	return c
+}
+
+func newCachingDiscoverer(backend Discoverer, ttl time.Duration) *CachingDiscoverer {
+	return &CachingDiscoverer{
+		backend: backend,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[cacheKey]*cacheEntry),
+	}
+}
+
+// Invalidate discards all cached answers. Lookups that are already in
+// flight complete normally, but their results are not cached.
+func (c *CachingDiscoverer) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]*cacheEntry)
+}
+
+// Close stops watching for routing changes. The CachingDiscoverer remains
+// usable and falls back to expiring answers after the time to live.
+func (c *CachingDiscoverer) Close() error {
+	c.mu.Lock()
+	w := c.watcher
+	c.watcher = nil
+	c.mu.Unlock()
+
+	if w == nil {
+		return nil
+	}
+	return w.Close()
+}
+
+// DiscoverGateways returns the cached result of DiscoverGateways.
+func (c *CachingDiscoverer) DiscoverGateways() ([]net.IP, error) {
+	return c.lookup(cacheGateways, c.backend.DiscoverGateways)
+}
+
+// DiscoverInterface returns the cached result of DiscoverInterface.
+func (c *CachingDiscoverer) DiscoverInterface() (net.IP, error) {
+	return c.lookupOne(cacheInterface, c.backend.DiscoverInterface)
+}
+
+// DiscoverGatewaysIPv6 returns the cached result of DiscoverGatewaysIPv6.
+func (c *CachingDiscoverer) DiscoverGatewaysIPv6() ([]net.IP, error) {
+	return c.lookup(cacheGatewaysIPv6, c.backend.DiscoverGatewaysIPv6)
+}
+
+// DiscoverInterfaceIPv6 returns the cached result of DiscoverInterfaceIPv6.
+func (c *CachingDiscoverer) DiscoverInterfaceIPv6() (net.IP, error) {
+	return c.lookupOne(cacheInterfaceIPv6, c.backend.DiscoverInterfaceIPv6)
+}
+
+// Snapshot returns the cached result of Snapshot. All answers of a single
+// Snapshot are consistent with each other, but they are not necessarily
+// consistent with the other cached answers.
+func (c *CachingDiscoverer) Snapshot() (Snapshot, error) {
+	v, err := c.share(cacheSnapshot, func() (any, error) {
+		return c.backend.Snapshot()
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return cloneSnapshot(v.(Snapshot)), nil
+}
+
+func (c *CachingDiscoverer) lookupOne(key cacheKey, fn func() (net.IP, error)) (net.IP, error) {
+	ips, err := c.lookup(key, func() ([]net.IP, error) {
+		ip, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return []net.IP{ip}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
+
+func (c *CachingDiscoverer) lookup(key cacheKey, fn func() ([]net.IP, error)) ([]net.IP, error) {
+	v, err := c.share(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneIPs(v.([]net.IP)), nil
+}
+
+// share returns the cached answer for key, calling fn if there is none.
+func (c *CachingDiscoverer) share(key cacheKey, fn func() (any, error)) (value any, err error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if c.now().Before(e.expires) {
+				c.mu.Unlock()
+				return e.value, nil
+			}
+		default:
+			// Another goroutine is querying the OS; share its answer.
+			c.mu.Unlock()
+			<-e.done
+			return e.value, e.err
+		}
+	}
+
+	e := &cacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	// The waiters are released even if fn panics.
+	returned := false
+	defer func() {
+		c.mu.Lock()
+		e.value, e.err = value, err
+		if !returned {
+			e.err = fmt.Errorf("gateway lookup panicked")
+		}
+		if e.err == nil {
+			e.expires = c.now().Add(c.ttl)
+		} else if c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		close(e.done)
+		c.mu.Unlock()
+	}()
+
+	value, err = fn()
+	returned = true
+	return value, err
+}
+
+// cloneIPs returns a deep copy of ips so that callers can't modify the
+// cached value.
+func cloneIPs(ips []net.IP) []net.IP {
+	if ips == nil {
+		return nil
+	}
+	result := make([]net.IP, len(ips))
+	for i, ip := range ips {
+		result[i] = append(net.IP(nil), ip...)
+	}
+	return result
+}
@@ -0,0 +1,209 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// countingDiscoverer is a Discoverer that counts OS queries and can be
+// made to block until released.
+type countingDiscoverer struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+	panics  bool
+}
+
+func (d *countingDiscoverer) DiscoverGateways() ([]net.IP, error) {
+	d.calls.Add(1)
+	if d.release != nil {
+		<-d.release
+	}
+	if d.panics {
+		panic("route table vanished")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return []net.IP{net.ParseIP("192.168.1.1")}, nil
+}
+
+func (d *countingDiscoverer) DiscoverInterface() (net.IP, error) {
+	d.calls.Add(1)
+	return net.ParseIP("192.168.1.100"), nil
+}
+
+func (d *countingDiscoverer) DiscoverGatewaysIPv6() ([]net.IP, error) {
+	d.calls.Add(1)
+	return []net.IP{net.ParseIP("fe80::1")}, nil
+}
+
+func (d *countingDiscoverer) DiscoverInterfaceIPv6() (net.IP, error) {
+	d.calls.Add(1)
+	return net.ParseIP("2001:db8::100"), nil
+}
+
+func (d *countingDiscoverer) Snapshot() (Snapshot, error) {
+	d.calls.Add(1)
+	return Snapshot{Routes: []Route{{
+		Destination: netip.MustParsePrefix("0.0.0.0/0"),
+		Gateway:     netip.MustParseAddr("192.168.1.1"),
+	}}}, nil
+}
+
+func TestCachingDiscovererTTL(t *testing.T) {
+	backend := &countingDiscoverer{}
+	c := newCachingDiscoverer(backend, time.Minute)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ips, err := c.DiscoverGateways()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ips[0].String() != "192.168.1.1" {
+			t.Errorf("Unexpected gateway address %v", ips[0])
+		}
+	}
+	if n := backend.calls.Load(); n != 1 {
+		t.Errorf("Expected 1 OS query, got %d", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := c.DiscoverGateways(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := backend.calls.Load(); n != 2 {
+		t.Errorf("Expected expired entry to be refreshed, got %d OS queries", n)
+	}
+
+	// Each answer is cached separately.
+	if _, err := c.DiscoverInterface(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.DiscoverGatewaysIPv6(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.DiscoverInterfaceIPv6(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.DiscoverInterfaceIPv6(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := backend.calls.Load(); n != 5 {
+		t.Errorf("Expected 5 OS queries, got %d", n)
+	}
+
+	s, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Routes[0].Gateway = netip.Addr{}
+	if s, _ := c.Snapshot(); !s.Routes[0].Gateway.IsValid() {
+		t.Error("Modifying a snapshot changed the cached one")
+	}
+	if n := backend.calls.Load(); n != 6 {
+		t.Errorf("Expected 6 OS queries, got %d", n)
+	}
+}
+
+func TestCachingDiscovererInvalidate(t *testing.T) {
+	backend := &countingDiscoverer{}
+	c := newCachingDiscoverer(backend, time.Hour)
+
+	c.DiscoverGateways()
+	c.Invalidate()
+	c.DiscoverGateways()
+
+	if n := backend.calls.Load(); n != 2 {
+		t.Errorf("Expected 2 OS queries, got %d", n)
+	}
+}
+
+func TestCachingDiscovererDeduplicates(t *testing.T) {
+	const n = 10
+	results := make([][]net.IP, n)
+	var calls int32
+	synctest.Test(t, func(t *testing.T) {
+		backend := &countingDiscoverer{release: make(chan struct{})}
+		c := newCachingDiscoverer(backend, 0)
+
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = c.DiscoverGateways()
+			}()
+		}
+		// Let the lookup finish once all other callers wait for it.
+		synctest.Wait()
+		close(backend.release)
+		wg.Wait()
+		calls = backend.calls.Load()
+	})
+
+	if calls != 1 {
+		t.Errorf("Expected 1 OS query for %d concurrent callers, got %d", n, calls)
+	}
+	for i, ips := range results {
+		if len(ips) != 1 || ips[0].String() != "192.168.1.1" {
+			t.Errorf("Unexpected result for caller #%d: %v", i, ips)
+		}
+	}
+
+	// Callers get their own copy of the result.
+	results[0][0][15] = 2
+	if results[1][0].String() != "192.168.1.1" {
+		t.Error("Callers share the same result slice")
+	}
+}
+
+func TestCachingDiscovererReleasesWaitersOnPanic(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := &countingDiscoverer{release: make(chan struct{}), panics: true}
+		c := newCachingDiscoverer(backend, time.Hour)
+
+		go func() {
+			defer func() { recover() }()
+			c.DiscoverGateways()
+		}()
+		synctest.Wait()
+
+		errs := make(chan error)
+		go func() {
+			_, err := c.DiscoverGateways()
+			errs <- err
+		}()
+		synctest.Wait()
+		close(backend.release)
+
+		if err := <-errs; err == nil {
+			t.Error("Expected an error for a lookup that panicked")
+		}
+		if n := backend.calls.Load(); n != 1 {
+			t.Errorf("Expected 1 OS query, got %d", n)
+		}
+	})
+}
+
+func TestCachingDiscovererDoesNotCacheErrors(t *testing.T) {
+	backend := &countingDiscoverer{err: &ErrNoGateway{}}
+	c := newCachingDiscoverer(backend, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.DiscoverGateways(); !errors.Is(err, &ErrNoGateway{}) {
+			t.Errorf("Expected ErrNoGateway, got %v", err)
+		}
+	}
+	if n := backend.calls.Load(); n != 2 {
+		t.Errorf("Expected 2 OS queries, got %d", n)
+	}
+}
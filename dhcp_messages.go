@@ -0,0 +1,206 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// DHCP options used by DHCPINFORM, see RFC 2132.
+const (
+	dhcpOptionDomainName   = 15
+	dhcpOptionMessageType  = 53
+	dhcpOptionServerID     = 54
+	dhcpOptionParamRequest = 55
+)
+
+// DHCP message types and BOOTP fields, see RFC 2131.
+const (
+	dhcpMessageAck       = 5
+	dhcpMessageInform    = 8
+	bootRequest          = 1
+	bootReply            = 2
+	bootHardwareEthernet = 1
+	bootXidOffset        = 4
+	bootCiaddrOffset     = 12
+	bootChaddrOffset     = 28
+	bootChaddrLen        = 16
+	bootOptionsOffset    = 236
+
+	// bootMinLen is the minimum length of BOOTP messages, which some
+	// relays and servers insist on (RFC 1542 2.1).
+	bootMinLen = 300
+)
+
+// DHCPv6 messages and options, see RFC 8415 and RFC 3646.
+const (
+	dhcpv6MessageReply              = 7
+	dhcpv6MessageInformationRequest = 11
+	dhcpv6OptionClientID            = 1
+	dhcpv6OptionServerID            = 2
+	dhcpv6OptionRequest             = 6
+	dhcpv6OptionElapsedTime         = 8
+	dhcpv6OptionDNSServers          = 23
+	dhcpv6OptionDomainList          = 24
+	dhcpv6DUIDLinkLayer             = 3
+	dhcpv6HeaderLen                 = 4
+)
+
+// DHCPInfo is the configuration a DHCP or DHCPv6 server sent in answer to
+// a probe.
+type DHCPInfo struct {
+	// Interface is the name of the interface the probe was sent on.
+	Interface string
+
+	// Server is the address of the server that answered: its server
+	// identifier for DHCP, and the address it sent from for DHCPv6.
+	Server netip.Addr
+
+	// Routers are the gateways, in order of preference. DHCPv6 has no
+	// router option; IPv6 routers announce themselves, see
+	// DiscoverRouters.
+	Routers []netip.Addr
+
+	// DNS are the DNS servers.
+	DNS []netip.Addr
+
+	// Domain is the domain name of the network, or the first domain of
+	// the DHCPv6 domain search list.
+	Domain string
+}
+
+// marshalDHCPInform encodes a DHCPINFORM of a host with the configured
+// address client, asking for the routers, the DNS servers and the domain.
+func marshalDHCPInform(xid uint32, client netip.Addr, hardwareAddr net.HardwareAddr) []byte {
+	b := make([]byte, bootOptionsOffset, bootMinLen)
+	b[0] = bootRequest
+	b[1] = bootHardwareEthernet
+	b[2] = byte(min(len(hardwareAddr), bootChaddrLen))
+	binary.BigEndian.PutUint32(b[bootXidOffset:], xid)
+	ciaddr := client.As4()
+	copy(b[bootCiaddrOffset:], ciaddr[:])
+	copy(b[bootChaddrOffset:bootChaddrOffset+bootChaddrLen], hardwareAddr)
+	b = append(b, dhcpMagicCookie...)
+	b = append(b, dhcpOptionMessageType, 1, dhcpMessageInform)
+	b = append(b, dhcpOptionParamRequest, 4, dhcpOptionSubnetMask, dhcpOptionRouter, dhcpOptionDNS, dhcpOptionDomainName)
+	b = append(b, dhcpOptionEnd)
+	// Pad with zeros after the end option.
+	return append(b, make([]byte, max(bootMinLen-len(b), 0))...)
+}
+
+// parseDHCPAck decodes the DHCPACK answering the DHCPINFORM with xid,
+// received from src. It reports false for other messages.
+func parseDHCPAck(b []byte, xid uint32, src netip.Addr) (DHCPInfo, bool) {
+	if len(b) < bootOptionsOffset+len(dhcpMagicCookie) || b[0] != bootReply ||
+		binary.BigEndian.Uint32(b[bootXidOffset:]) != xid ||
+		!bytes.Equal(b[bootOptionsOffset:bootOptionsOffset+len(dhcpMagicCookie)], dhcpMagicCookie) {
+		return DHCPInfo{}, false
+	}
+
+	info := DHCPInfo{Server: src}
+	var messageType byte
+	forEachDHCPOption(b[bootOptionsOffset+len(dhcpMagicCookie):], func(code byte, value []byte) {
+		switch code {
+		case dhcpOptionMessageType:
+			if len(value) == 1 {
+				messageType = value[0]
+			}
+		case dhcpOptionServerID:
+			if len(value) == 4 {
+				info.Server = netip.AddrFrom4([4]byte(value))
+			}
+		case dhcpOptionRouter:
+			info.Routers = dhcpAddrs(value)
+		case dhcpOptionDNS:
+			info.DNS = dhcpAddrs(value)
+		case dhcpOptionDomainName:
+			info.Domain = strings.TrimRight(string(value), "\x00")
+		}
+	})
+	return info, messageType == dhcpMessageAck
+}
+
+// marshalDHCPv6InformationRequest encodes an Information-Request asking
+// for the DNS servers and the domain search list. The client identifies
+// itself by its hardware address, if it has one.
+func marshalDHCPv6InformationRequest(xid uint32, hardwareAddr net.HardwareAddr) []byte {
+	b := make([]byte, dhcpv6HeaderLen, 64)
+	binary.BigEndian.PutUint32(b, xid&0xffffff)
+	b[0] = dhcpv6MessageInformationRequest
+	if len(hardwareAddr) > 0 {
+		duid := binary.BigEndian.AppendUint16(nil, dhcpv6DUIDLinkLayer)
+		duid = binary.BigEndian.AppendUint16(duid, bootHardwareEthernet)
+		b = appendDHCPv6Option(b, dhcpv6OptionClientID, append(duid, hardwareAddr...))
+	}
+	b = appendDHCPv6Option(b, dhcpv6OptionRequest, []byte{0, dhcpv6OptionDNSServers, 0, dhcpv6OptionDomainList})
+	return appendDHCPv6Option(b, dhcpv6OptionElapsedTime, []byte{0, 0})
+}
+
+func appendDHCPv6Option(b []byte, code uint16, value []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, code)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(value)))
+	return append(b, value...)
+}
+
+// parseDHCPv6Reply decodes the Reply answering the Information-Request
+// with xid, received from src. It reports false for other messages.
+func parseDHCPv6Reply(b []byte, xid uint32, src netip.Addr) (DHCPInfo, bool) {
+	if len(b) < dhcpv6HeaderLen || b[0] != dhcpv6MessageReply ||
+		binary.BigEndian.Uint32(b)&0xffffff != xid&0xffffff {
+		return DHCPInfo{}, false
+	}
+
+	info := DHCPInfo{Server: src}
+	hasServerID := false
+	for options := b[dhcpv6HeaderLen:]; len(options) >= 4; {
+		code := binary.BigEndian.Uint16(options)
+		length := int(binary.BigEndian.Uint16(options[2:]))
+		if len(options) < 4+length {
+			return DHCPInfo{}, false
+		}
+		value := options[4 : 4+length]
+		options = options[4+length:]
+
+		switch code {
+		case dhcpv6OptionServerID:
+			hasServerID = true
+		case dhcpv6OptionDNSServers:
+			for ; len(value) >= 16; value = value[16:] {
+				info.DNS = append(info.DNS, netip.AddrFrom16([16]byte(value[:16])))
+			}
+		case dhcpv6OptionDomainList:
+			if domains := parseDomainNames(value); len(domains) > 0 {
+				info.Domain = domains[0]
+			}
+		}
+	}
+	// Every Reply identifies its server (RFC 8415 16.10).
+	return info, hasServerID
+}
+
+// parseDomainNames decodes a list of uncompressed domain names in DNS wire
+// format, as DHCPv6 sends them. Decoding stops at a malformed name.
+func parseDomainNames(b []byte) []string {
+	var result []string
+	var labels []string
+	for len(b) > 0 {
+		length := int(b[0])
+		if length == 0 {
+			if len(labels) > 0 {
+				result = append(result, strings.Join(labels, "."))
+			}
+			labels = nil
+			b = b[1:]
+			continue
+		}
+		// Lengths of 64 and more mark compression, which DHCPv6 forbids.
+		if length >= 64 || len(b) < 1+length {
+			break
+		}
+		labels = append(labels, string(b[1:1+length]))
+		b = b[1+length:]
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalDHCPInform(t *testing.T) {
+	hardwareAddr := net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}
+	b := marshalDHCPInform(0x01020304, netip.MustParseAddr("192.168.8.100"), hardwareAddr)
+
+	if len(b) != bootMinLen {
+		t.Errorf("Expected %d octets, got %d", bootMinLen, len(b))
+	}
+	if b[0] != bootRequest || b[2] != 6 {
+		t.Errorf("Unexpected header %x", b[:4])
+	}
+	if xid := binary.BigEndian.Uint32(b[bootXidOffset:]); xid != 0x01020304 {
+		t.Errorf("Expected xid 01020304, got %08x", xid)
+	}
+	if ciaddr := netip.AddrFrom4([4]byte(b[bootCiaddrOffset:])); ciaddr != netip.MustParseAddr("192.168.8.100") {
+		t.Errorf("Unexpected ciaddr %v", ciaddr)
+	}
+	if chaddr := net.HardwareAddr(b[bootChaddrOffset : bootChaddrOffset+6]); chaddr.String() != hardwareAddr.String() {
+		t.Errorf("Unexpected chaddr %v", chaddr)
+	}
+
+	options := make(map[byte][]byte)
+	forEachDHCPOption(b[bootOptionsOffset+len(dhcpMagicCookie):], func(code byte, value []byte) {
+		options[code] = value
+	})
+	want := map[byte][]byte{
+		dhcpOptionMessageType:  {dhcpMessageInform},
+		dhcpOptionParamRequest: {dhcpOptionSubnetMask, dhcpOptionRouter, dhcpOptionDNS, dhcpOptionDomainName},
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("Expected options %v, got %v", want, options)
+	}
+}
+
+func TestParseDHCPAck(t *testing.T) {
+	// dhcpcd keeps the acknowledgement as received.
+	ack := dhcpcdLeaseBytes(t)
+	src := netip.MustParseAddr("192.168.8.254")
+
+	info, ok := parseDHCPAck(ack, 0x3c1d8a55, src)
+	want := DHCPInfo{
+		Server:  netip.MustParseAddr("192.168.8.1"),
+		Routers: []netip.Addr{netip.MustParseAddr("192.168.8.1")},
+		DNS:     []netip.Addr{netip.MustParseAddr("192.168.8.1"), netip.MustParseAddr("9.9.9.9")},
+	}
+	if !ok || !reflect.DeepEqual(info, want) {
+		t.Errorf("Expected %+v, got %+v, %v", want, info, ok)
+	}
+
+	if _, ok := parseDHCPAck(ack, 0x3c1d8a56, src); ok {
+		t.Errorf("Expected an answer to another transaction to be skipped")
+	}
+	if _, ok := parseDHCPAck(ack[:bootOptionsOffset], 0x3c1d8a55, src); ok {
+		t.Errorf("Expected a truncated message to be skipped")
+	}
+	inform := marshalDHCPInform(0x3c1d8a55, src, nil)
+	if _, ok := parseDHCPAck(inform, 0x3c1d8a55, src); ok {
+		t.Errorf("Expected a request to be skipped")
+	}
+}
+
+func TestMarshalDHCPv6InformationRequest(t *testing.T) {
+	b := marshalDHCPv6InformationRequest(0xff123456, net.HardwareAddr{0x02, 0, 0, 0, 0, 1})
+	want := []byte{
+		11, 0x12, 0x34, 0x56,
+		0, 1, 0, 10, 0, 3, 0, 1, 0x02, 0, 0, 0, 0, 1,
+		0, 6, 0, 4, 0, 23, 0, 24,
+		0, 8, 0, 2, 0, 0,
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("Expected %x, got %x", want, b)
+	}
+}
+
+func TestParseDHCPv6Reply(t *testing.T) {
+	reply := []byte{7, 0x12, 0x34, 0x56}
+	reply = appendDHCPv6Option(reply, dhcpv6OptionServerID, []byte{0, 3, 0, 1, 0x02, 0, 0, 0, 0, 0xfe})
+	dns := netip.MustParseAddr("2001:db8::53").As16()
+	reply = appendDHCPv6Option(reply, dhcpv6OptionDNSServers, dns[:])
+	reply = appendDHCPv6Option(reply, dhcpv6OptionDomainList, []byte("\x03lan\x00\x07example\x03com\x00"))
+	src := netip.MustParseAddr("fe80::fe%eth0")
+
+	info, ok := parseDHCPv6Reply(reply, 0x123456, src)
+	want := DHCPInfo{
+		Server: src,
+		DNS:    []netip.Addr{netip.MustParseAddr("2001:db8::53")},
+		Domain: "lan",
+	}
+	if !ok || !reflect.DeepEqual(info, want) {
+		t.Errorf("Expected %+v, got %+v, %v", want, info, ok)
+	}
+
+	if _, ok := parseDHCPv6Reply(reply, 0x123457, src); ok {
+		t.Errorf("Expected an answer to another transaction to be skipped")
+	}
+	if _, ok := parseDHCPv6Reply(reply[:len(reply)-1], 0x123456, src); ok {
+		t.Errorf("Expected a truncated message to be skipped")
+	}
+	if _, ok := parseDHCPv6Reply([]byte{7, 0x12, 0x34, 0x56}, 0x123456, src); ok {
+		t.Errorf("Expected a reply without server identifier to be skipped")
+	}
+}
+
+func TestParseDomainNames(t *testing.T) {
+	testcases := map[string][]string{
+		"\x03lan\x00":                       {"lan"},
+		"\x07example\x03com\x00\x03lan\x00": {"example.com", "lan"},
+		"\x07example\x03com\x00\xc0\x0c":    {"example.com"},
+		"\x07example\x03co":                 nil,
+		"":                                  nil,
+	}
+	for input, want := range testcases {
+		if got := parseDomainNames([]byte(input)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: Expected %v, got %v", input, want, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// DHCP ports, see RFC 2131 and RFC 8415.
+const (
+	dhcpServerPort   = 67
+	dhcpClientPort   = 68
+	dhcpv6ServerPort = 547
+	dhcpv6ClientPort = 546
+)
+
+// allDHCPv6Servers is the link-local multicast group of DHCPv6 servers and
+// relay agents.
+var allDHCPv6Servers = netip.MustParseAddr("ff02::1:2")
+
+// ProbeDHCP asks the DHCP servers of the network of the interface
+// DiscoverInterface selects for its configuration: a DHCPINFORM for IPv4,
+// a DHCPv6 Information-Request for IPv6. It suits hosts with static
+// addresses that need to learn the router of their network.
+//
+// Binding the client port needs privileges on most operating systems and
+// fails while a DHCP client runs. ErrNoGateway is returned if no server
+// answers within timeout.
+func ProbeDHCP(ipv6 bool, timeout time.Duration) (DHCPInfo, error) {
+	var ip net.IP
+	var err error
+	if ipv6 {
+		ip, err = DiscoverInterfaceIPv6()
+	} else {
+		ip, err = DiscoverInterface()
+	}
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+	iface, err := interfaceWithIP(ip)
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+	return ProbeDHCPInterface(iface.Name, ipv6, timeout)
+}
+
+// ProbeDHCPInterface is ProbeDHCP on the named interface, for hosts whose
+// interface can't be told from the routing table, such as those without a
+// default route.
+func ProbeDHCPInterface(ifaceName string, ipv6 bool, timeout time.Duration) (DHCPInfo, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+
+	var info DHCPInfo
+	if ipv6 {
+		info, err = probeDHCPv6Interface(iface, addrs, timeout)
+	} else {
+		info, err = probeDHCPv4Interface(iface, addrs, timeout)
+	}
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+	info.Interface = iface.Name
+	return info, nil
+}
+
+func probeDHCPv4Interface(iface *net.Interface, addrs []net.Addr, timeout time.Duration) (DHCPInfo, error) {
+	var subnet netip.Prefix
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			client, _ := ipToAddr(ipnet.IP)
+			bits, _ := ipnet.Mask.Size()
+			subnet = netip.PrefixFrom(client, bits)
+			break
+		}
+	}
+	if !subnet.IsValid() {
+		return DHCPInfo{}, fmt.Errorf("no IPv4 address found for interface %v", iface.Name)
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: addrToIP(subnet.Addr()), Port: dhcpClientPort})
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+	defer conn.Close()
+	// The broadcast address of the subnet takes the probe out of the
+	// interface; the limited broadcast would take the default route.
+	server := netip.AddrPortFrom(subnetBroadcast(subnet), dhcpServerPort)
+	return probeDHCPv4(conn, server, subnet.Addr(), iface.HardwareAddr, timeout)
+}
+
+func probeDHCPv6Interface(iface *net.Interface, addrs []net.Addr, timeout time.Duration) (DHCPInfo, error) {
+	var client net.IP
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() == nil && ipnet.IP.IsLinkLocalUnicast() {
+			client = ipnet.IP
+			break
+		}
+	}
+	if client == nil {
+		return DHCPInfo{}, fmt.Errorf("no link-local IPv6 address found for interface %v", iface.Name)
+	}
+
+	conn, err := net.ListenUDP("udp6", &net.UDPAddr{IP: client, Port: dhcpv6ClientPort, Zone: iface.Name})
+	if err != nil {
+		return DHCPInfo{}, err
+	}
+	defer conn.Close()
+	server := netip.AddrPortFrom(allDHCPv6Servers.WithZone(iface.Name), dhcpv6ServerPort)
+	return probeDHCPv6(conn, server, iface.HardwareAddr, timeout)
+}
+
+// probeDHCPv4 sends a DHCPINFORM for client to server through conn and
+// waits for the answer.
+func probeDHCPv4(conn *net.UDPConn, server netip.AddrPort, client netip.Addr, hardwareAddr net.HardwareAddr, timeout time.Duration) (DHCPInfo, error) {
+	xid := rand.Uint32()
+	return probeDHCPServer(conn, server, marshalDHCPInform(xid, client, hardwareAddr), timeout,
+		func(b []byte, src netip.Addr) (DHCPInfo, bool) { return parseDHCPAck(b, xid, src) })
+}
+
+// probeDHCPv6 sends an Information-Request to server through conn and
+// waits for the answer.
+func probeDHCPv6(conn *net.UDPConn, server netip.AddrPort, hardwareAddr net.HardwareAddr, timeout time.Duration) (DHCPInfo, error) {
+	xid := rand.Uint32() & 0xffffff
+	return probeDHCPServer(conn, server, marshalDHCPv6InformationRequest(xid, hardwareAddr), timeout,
+		func(b []byte, src netip.Addr) (DHCPInfo, bool) { return parseDHCPv6Reply(b, xid, src) })
+}
+
+// probeDHCPServer sends msg to server and returns the first answer parse
+// accepts. Other messages, such as answers to other clients, are skipped.
+func probeDHCPServer(conn *net.UDPConn, server netip.AddrPort, msg []byte, timeout time.Duration,
+	parse func(b []byte, src netip.Addr) (DHCPInfo, bool)) (DHCPInfo, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return DHCPInfo{}, err
+	}
+	if _, err := conn.WriteToUDPAddrPort(msg, server); err != nil {
+		return DHCPInfo{}, err
+	}
+
+	b := make([]byte, 1500)
+	for {
+		n, src, err := conn.ReadFromUDPAddrPort(b)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return DHCPInfo{}, &ErrNoGateway{}
+		}
+		if err != nil {
+			return DHCPInfo{}, err
+		}
+		if info, ok := parse(b[:n], src.Addr().Unmap()); ok {
+			return info, nil
+		}
+	}
+}
+
+// subnetBroadcast returns the broadcast address of the IPv4 subnet p, or
+// the limited broadcast address for subnets without one.
+func subnetBroadcast(p netip.Prefix) netip.Addr {
+	if p.Bits() >= 31 {
+		return netip.AddrFrom4([4]byte{255, 255, 255, 255})
+	}
+	a := p.Addr().As4()
+	host := ^uint32(0) >> p.Bits()
+	for i := range a {
+		a[i] |= byte(host >> (24 - 8*i))
+	}
+	return netip.AddrFrom4(a)
+}
+
+// interfaceWithIP returns the interface that has ip.
+func interfaceWithIP(ip net.IP) (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface found with address %v", ip)
+}
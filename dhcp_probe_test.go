@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// listenLoopback opens a UDP socket on the loopback address of network,
+// "udp4" or "udp6", and skips the test without one.
+func listenLoopback(t *testing.T, network string) *net.UDPConn {
+	ip := net.IPv4(127, 0, 0, 1)
+	if network == "udp6" {
+		ip = net.IPv6loopback
+	}
+	conn, err := net.ListenUDP(network, &net.UDPAddr{IP: ip})
+	if err != nil {
+		t.Skipf("No loopback for %s: %v", network, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// udpStandIn is a server on the loopback interface that answers each
+// request with the messages reply builds from it. The DHCP, DNS, NAT and
+// router stand-ins of the tests are such reply functions.
+func udpStandIn(t *testing.T, network string, reply func(req []byte) [][]byte) netip.AddrPort {
+	conn := listenLoopback(t, network)
+	go func() {
+		b := make([]byte, 1500)
+		for {
+			n, client, err := conn.ReadFromUDPAddrPort(b)
+			if err != nil {
+				return
+			}
+			for _, msg := range reply(b[:n]) {
+				conn.WriteToUDPAddrPort(msg, client)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).AddrPort()
+}
+
+// dhcpAck builds the DHCPACK a server sends in answer to req.
+func dhcpAck(req []byte, xid uint32, options ...byte) []byte {
+	ack := make([]byte, bootOptionsOffset)
+	copy(ack, req[:bootOptionsOffset])
+	ack[0] = bootReply
+	binary.BigEndian.PutUint32(ack[bootXidOffset:], xid)
+	ack = append(ack, dhcpMagicCookie...)
+	ack = append(ack, dhcpOptionMessageType, 1, dhcpMessageAck)
+	ack = append(ack, options...)
+	return append(ack, dhcpOptionEnd)
+}
+
+func TestProbeDHCPv4(t *testing.T) {
+	client := netip.MustParseAddr("192.168.1.20")
+	ciaddrs := make(chan netip.Addr, 1)
+	server := udpStandIn(t, "udp4", func(req []byte) [][]byte {
+		ciaddrs <- netip.AddrFrom4([4]byte(req[bootCiaddrOffset:]))
+		xid := binary.BigEndian.Uint32(req[bootXidOffset:])
+		return [][]byte{
+			// An answer to another client comes first.
+			dhcpAck(req, xid+1, dhcpOptionRouter, 4, 10, 0, 0, 1),
+			dhcpAck(req, xid,
+				dhcpOptionServerID, 4, 192, 168, 1, 2,
+				dhcpOptionRouter, 8, 192, 168, 1, 1, 192, 168, 1, 254,
+				dhcpOptionDNS, 4, 192, 168, 1, 2,
+				dhcpOptionDomainName, 4, 'h', 'o', 'm', 'e'),
+		}
+	})
+	conn := listenLoopback(t, "udp4")
+
+	info, err := probeDHCPv4(conn, server, client, net.HardwareAddr{0x02, 0, 0, 0, 0, 1}, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := DHCPInfo{
+		Server:  netip.MustParseAddr("192.168.1.2"),
+		Routers: []netip.Addr{netip.MustParseAddr("192.168.1.1"), netip.MustParseAddr("192.168.1.254")},
+		DNS:     []netip.Addr{netip.MustParseAddr("192.168.1.2")},
+		Domain:  "home",
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("Expected %+v, got %+v", want, info)
+	}
+	if ciaddr := <-ciaddrs; ciaddr != client {
+		t.Errorf("Expected ciaddr %v, got %v", client, ciaddr)
+	}
+}
+
+func TestProbeDHCPv6(t *testing.T) {
+	server := udpStandIn(t, "udp6", func(req []byte) [][]byte {
+		if req[0] != dhcpv6MessageInformationRequest {
+			return nil
+		}
+		reply := []byte{dhcpv6MessageReply, req[1], req[2], req[3]}
+		reply = appendDHCPv6Option(reply, dhcpv6OptionServerID, []byte{0, 3, 0, 1, 0x02, 0, 0, 0, 0, 0xfe})
+		dns := netip.MustParseAddr("2001:db8::53").As16()
+		reply = appendDHCPv6Option(reply, dhcpv6OptionDNSServers, dns[:])
+		reply = appendDHCPv6Option(reply, dhcpv6OptionDomainList, []byte("\x04home\x04arpa\x00"))
+		return [][]byte{reply}
+	})
+	conn := listenLoopback(t, "udp6")
+
+	info, err := probeDHCPv6(conn, server, nil, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := DHCPInfo{
+		Server: netip.IPv6Loopback(),
+		DNS:    []netip.Addr{netip.MustParseAddr("2001:db8::53")},
+		Domain: "home.arpa",
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("Expected %+v, got %+v", want, info)
+	}
+}
+
+func TestProbeDHCPNoAnswer(t *testing.T) {
+	server := udpStandIn(t, "udp4", func(req []byte) [][]byte { return nil })
+	conn := listenLoopback(t, "udp4")
+
+	_, err := probeDHCPv4(conn, server, netip.MustParseAddr("192.168.1.20"), nil, 100*time.Millisecond)
+	var noGateway *ErrNoGateway
+	if !errors.As(err, &noGateway) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+}
+
+func TestSubnetBroadcast(t *testing.T) {
+	testcases := map[string]string{
+		"192.168.1.20/24": "192.168.1.255",
+		"10.1.2.3/8":      "10.255.255.255",
+		"172.16.5.4/20":   "172.16.15.255",
+		"192.0.2.1/31":    "255.255.255.255",
+		"192.0.2.1/32":    "255.255.255.255",
+	}
+	for prefix, want := range testcases {
+		if got := subnetBroadcast(netip.MustParsePrefix(prefix)); got.String() != want {
+			t.Errorf("%s: Expected %s, got %v", prefix, want, got)
+		}
+	}
+}
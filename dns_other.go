@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package gateway
+
+import "os"
+
+const (
+	resolvConfFile = "/etc/resolv.conf"
+
+	// resolvedResolvConfFile lists the upstream servers of systemd-resolved.
+	resolvedResolvConfFile = "/run/systemd/resolve/resolv.conf"
+)
+
+func discoverDNSConfigOSSpecific() (dnsConfigs, error) {
+	data, err := os.ReadFile(resolvConfFile)
+	if err != nil {
+		return dnsConfigs{}, err
+	}
+	config := ParseResolvConf(data)
+	if usesResolvedStub(config) {
+		if data, err := os.ReadFile(resolvedResolvConfFile); err == nil {
+			config = ParseResolvConf(data)
+		}
+	}
+	return dnsConfigs{global: config}, nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package gateway
+
+func discoverDNSConfigOSSpecific() (dnsConfigs, error) {
+	output, err := runHidden("ipconfig", "/all")
+	if err != nil {
+		return dnsConfigs{}, err
+	}
+	config, err := ParseWindowsIPConfig(output)
+	if err != nil {
+		return dnsConfigs{}, err
+	}
+	return config.dnsConfigs(), nil
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"net"
+	"slices"
+)
+
+// maxSplitBits is the longest prefix of the routes that may stand in for
+// a default route. OpenVPN's def1 uses two /1 routes; some WireGuard
+// configurations list a set of /2 to /8 routes instead, for example to
+// leave out a block.
+const maxSplitBits = 8
+
+// EffectiveDefault describes the routes that carry traffic to the
+// Internet. VPNs such as OpenVPN (with redirect-gateway def1) and many
+// WireGuard configurations leave the default route in place and add
+// routes that together cover the whole address space, such as 0.0.0.0/1
+// and 128.0.0.0/1. Being more specific, these split routes win over the
+// default route for every destination without a more specific route.
+type EffectiveDefault struct {
+	// Routes holds the routes that carry the traffic: the preferred
+	// default route, or the split routes that cover the address space in
+	// its place, ordered by destination.
+	Routes []Route
+
+	// Split reports whether Routes are split routes.
+	Split bool
+
+	// Shadowed holds the default routes that the split routes override,
+	// in order of preference. It is empty unless Split is set.
+	Shadowed []Route
+}
+
+// Gateways returns the distinct gateways of the effective routes. Split
+// routes of point-to-point links, such as WireGuard's, have none.
+func (e EffectiveDefault) Gateways() []net.IP {
+	return routeGateways(e.Routes)
+}
+
+// ShadowedGateways returns the distinct gateways of the shadowed default
+// routes, which are those used before the VPN came up.
+func (e EffectiveDefault) ShadowedGateways() []net.IP {
+	return routeGateways(e.Shadowed)
+}
+
+// DiscoverEffectiveDefault is the OS independent function to find the
+// routes that carry IPv4 or IPv6 traffic to the Internet, taking split
+// default routes into account.
+func DiscoverEffectiveDefault(ipv6 bool) (EffectiveDefault, error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return EffectiveDefault{}, err
+	}
+	return s.EffectiveDefault(ipv6)
+}
+
+// EffectiveDefault returns the routes of the snapshot that carry traffic
+// of the given family to the Internet. It returns ErrNoGateway if neither
+// a default route nor a set of split routes covers the address space.
+func (s Snapshot) EffectiveDefault(ipv6 bool) (EffectiveDefault, error) {
+	defaults := s.DefaultRoutes(ipv6)
+	if split, ok := splitDefaultRoutes(s.Routes, ipv6); ok {
+		return EffectiveDefault{Routes: split, Split: true, Shadowed: defaults}, nil
+	}
+	if len(defaults) == 0 {
+		return EffectiveDefault{}, &ErrNoGateway{}
+	}
+	return EffectiveDefault{Routes: defaults[:1]}, nil
+}
+
+// splitDefaultRoutes returns the routes of a set of split routes that
+// covers the whole address space of the family, and whether there is one.
+// Where several routes cover the same addresses, the most specific one
+// and then the one with the lowest metric is used, as the OS would.
+func splitDefaultRoutes(routes []Route, ipv6 bool) ([]Route, bool) {
+	// Split routes are at most /8, so the first byte of an address
+	// decides which of them apply to it.
+	var cover [256]*Route
+	for i := range routes {
+		r := &routes[i]
+		bits := r.Destination.Bits()
+		if bits < 1 || bits > maxSplitBits || r.Is6() != ipv6 || !r.forwards() {
+			continue
+		}
+		if r.Destination.Masked() != r.Destination {
+			continue
+		}
+		first := int(r.Destination.Addr().AsSlice()[0])
+		for b := first; b < first+1<<(8-bits); b++ {
+			c := cover[b]
+			if c == nil || bits > c.Destination.Bits() ||
+				bits == c.Destination.Bits() && r.EffectiveMetric() < c.EffectiveMetric() {
+				cover[b] = r
+			}
+		}
+	}
+
+	var result []Route
+	seen := make(map[*Route]bool)
+	for _, r := range cover {
+		if r == nil {
+			return nil, false
+		}
+		if !seen[r] {
+			seen[r] = true
+			result = append(result, *r)
+		}
+	}
+	return result, true
+}
+
+// routeGateways returns the distinct gateways of routes, in order.
+func routeGateways(routes []Route) []net.IP {
+	var result []net.IP
+	for _, r := range routes {
+		for _, gw := range r.gateways() {
+			ip := addrToIP(gw)
+			if !slices.ContainsFunc(result, ip.Equal) {
+				result = append(result, ip)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEffectiveDefault(t *testing.T) {
+	ipRoute := func(name string) []Route {
+		routes, err := ParseIPRoute(routeTables[name], false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return routes
+	}
+	procNetRoute := func(name string) []Route {
+		routes, err := ParseLinuxProcNetRoute(routeTables[name], binary.LittleEndian)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return routes
+	}
+
+	type testcase struct {
+		tableName   string
+		routes      []Route
+		split       []string
+		gateways    []string
+		shadowedGWs []string
+	}
+	testcases := []testcase{
+		{linux, procNetRoute(linux), []string{"0.0.0.0/0"}, []string{"192.168.8.1"}, nil},
+		{linuxOpenVPNDef1, procNetRoute(linuxOpenVPNDef1), []string{"0.0.0.0/1", "128.0.0.0/1"}, []string{"10.8.0.5"}, []string{"192.168.1.1"}},
+		// WireGuard's split routes are on-link.
+		{linuxIPRouteWireGuardSplit, ipRoute(linuxIPRouteWireGuardSplit), []string{"0.0.0.0/2", "64.0.0.0/2", "128.0.0.0/1"}, nil, []string{"192.168.1.1"}},
+		// A single /1 route leaves the default route in charge of the
+		// other half.
+		{linuxIPRouteSplitPartial, ipRoute(linuxIPRouteSplitPartial), []string{"0.0.0.0/0"}, []string{"192.168.1.1"}, nil},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			mockGetter := newMockinterfaceGetter(t)
+			mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+			s := newSnapshot(tc.routes, mockGetter)
+
+			e, err := s.EffectiveDefault(false)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if e.Split != (len(tc.split) > 1) {
+				t.Errorf("Unexpected Split %v", e.Split)
+			}
+			var dests []string
+			for _, r := range e.Routes {
+				dests = append(dests, r.Destination.String())
+			}
+			if !slices.Equal(dests, tc.split) {
+				t.Errorf("Unexpected effective routes %v", dests)
+			}
+			if got := ipStrings(e.Gateways()); !slices.Equal(got, tc.gateways) {
+				t.Errorf("Unexpected gateways %v", got)
+			}
+			if got := ipStrings(e.ShadowedGateways()); !slices.Equal(got, tc.shadowedGWs) {
+				t.Errorf("Unexpected shadowed gateways %v", got)
+			}
+		})
+	}
+
+	t.Run("most specific", func(t *testing.T) {
+		// A /8 set inside the /1 routes of another VPN wins for its block;
+		// of two equal split routes the lower metric wins.
+		routes := []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/1"), Gateway: netip.MustParseAddr("10.8.0.5"), Metric: 10},
+			{Destination: netip.MustParsePrefix("0.0.0.0/1"), Gateway: netip.MustParseAddr("10.9.0.5"), Metric: 5},
+			{Destination: netip.MustParsePrefix("128.0.0.0/1"), Gateway: netip.MustParseAddr("10.8.0.5")},
+			{Destination: netip.MustParsePrefix("32.0.0.0/8"), Gateway: netip.MustParseAddr("10.7.0.1")},
+		}
+		split, ok := splitDefaultRoutes(routes, false)
+		if !ok || len(split) != 3 {
+			t.Fatalf("Unexpected split routes %+v", split)
+		}
+		if split[0].Gateway.String() != "10.9.0.5" || split[1].Destination.String() != "32.0.0.0/8" {
+			t.Errorf("Unexpected split routes %+v", split)
+		}
+
+		if _, ok := splitDefaultRoutes(routes, true); ok {
+			t.Errorf("Unexpected IPv6 split routes")
+		}
+	})
+
+	t.Run("no route", func(t *testing.T) {
+		s := newSnapshot(nil, newMockinterfaceGetter(t))
+		if _, err := s.EffectiveDefault(false); !errors.Is(err, &ErrNoGateway{}) {
+			t.Errorf("Expected ErrNoGateway, got %v", err)
+		}
+	})
+}
+
+func ipStrings(ips []net.IP) []string {
+	var result []string
+	for _, ip := range ips {
+		result = append(result, ip.String())
+	}
+	return result
+}
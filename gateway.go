@@ -42,6 +42,11 @@ func (e *ErrInvalidRouteFileFormat) Error() string {
 }
 
 // DiscoverGateway is the OS independent function to get the default gateway
+//
+// Only default routes count: split routes that override them, such as
+// OpenVPN's 0.0.0.0/1 and 128.0.0.0/1, are ignored, so while such a VPN is
+// connected this is the gateway it shadows. DiscoverEffectiveDefault tells
+// the gateway that carries the traffic.
 func DiscoverGateway() (ip net.IP, err error) {
 	ips, err := DiscoverGateways()
 	if err != nil {
@@ -51,6 +56,7 @@ func DiscoverGateway() (ip net.IP, err error) {
 }
 
 // DiscoverGateways is the OS independent function to get all gateways.
+// Like DiscoverGateway, it ignores split default routes.
 // If err is nil, then ips is guarenteed to have at least one element.
 func DiscoverGateways() (ips []net.IP, err error) {
 	return discoverGatewaysOSSpecific()
@@ -61,7 +67,22 @@ func DiscoverInterface() (ip net.IP, err error) {
 	return discoverGatewayInterfaceOSSpecific()
 }
 
+// DiscoverPhysicalGateway is the OS independent function to get the
+// default gateway reached without a VPN or other tunnel, for example to
+// reach devices of the local network while a VPN carries the default
+// route.
+func DiscoverPhysicalGateway() (ip net.IP, err error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return s.PhysicalGateway(false)
+}
+
 // DiscoverGatewayIPv6 is the OS independent function to get the default IPv6 gateway
+//
+// Like DiscoverGateway, it ignores split default routes such as ::/1 and
+// 8000::/1.
 func DiscoverGatewayIPv6() (ip net.IP, err error) {
 	ips, err := DiscoverGatewaysIPv6()
 	if err != nil {
@@ -76,7 +97,58 @@ func DiscoverGatewaysIPv6() (ips []net.IP, err error) {
 	return discoverGatewaysIPv6OSSpecific()
 }
 
+// DiscoverGatewayIPv6Addr is the OS independent function to get the default
+// IPv6 gateway together with its zone. Link-local gateways such as fe80::1
+// can only be used on the link they belong to, so their Zone names the
+// route's interface. Global gateways have an empty Zone.
+func DiscoverGatewayIPv6Addr() (addr *net.IPAddr, err error) {
+	addrs, err := DiscoverGatewaysIPv6Addrs()
+	if err != nil {
+		return nil, err
+	}
+	return &addrs[0], nil
+}
+
+// DiscoverGatewaysIPv6Addrs is the OS independent function to get all IPv6
+// default gateways together with their zones. The same link-local gateway
+// on two interfaces is returned twice, once for each zone.
+// If err is nil, then addrs is guaranteed to have at least one element.
+func DiscoverGatewaysIPv6Addrs() (addrs []net.IPAddr, err error) {
+	return discoverGatewayAddrsIPv6OSSpecific()
+}
+
 // DiscoverInterfaceIPv6 is the OS independent function to call to get the default network interface IPv6 address that uses the default gateway
 func DiscoverInterfaceIPv6() (ip net.IP, err error) {
 	return discoverGatewayInterfaceIPv6OSSpecific()
 }
+
+// Discoverer is implemented by sources of gateway information. The
+// package-level Discover* functions query the operating system directly;
+// a Discoverer lets callers substitute a different strategy, such as a
+// CachingDiscoverer.
+type Discoverer interface {
+	DiscoverGateways() (ips []net.IP, err error)
+	DiscoverInterface() (ip net.IP, err error)
+	DiscoverGatewaysIPv6() (ips []net.IP, err error)
+	DiscoverInterfaceIPv6() (ip net.IP, err error)
+	Snapshot() (Snapshot, error)
+}
+
+// osDiscoverer is the Discoverer that queries the operating system on every call.
+type osDiscoverer struct{}
+
+func (osDiscoverer) DiscoverGateways() ([]net.IP, error) {
+	return discoverGatewaysOSSpecific()
+}
+
+func (osDiscoverer) DiscoverInterface() (net.IP, error) {
+	return discoverGatewayInterfaceOSSpecific()
+}
+
+func (osDiscoverer) DiscoverGatewaysIPv6() ([]net.IP, error) {
+	return discoverGatewaysIPv6OSSpecific()
+}
+
+func (osDiscoverer) DiscoverInterfaceIPv6() (net.IP, error) {
+	return discoverGatewayInterfaceIPv6OSSpecific()
+}
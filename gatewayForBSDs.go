@@ -4,82 +4,150 @@ package gateway
 
 import (
 	"net"
-	"os/exec"
+	"net/netip"
+	"runtime"
+	"strconv"
 	"syscall"
 
 	"golang.org/x/net/route"
 )
 
-func readNetstat() ([]byte, error) {
-	routeCmd := exec.Command("netstat", "-rn")
-	return routeCmd.CombinedOutput()
+// darwinRTFIfscope is Darwin's RTF_IFSCOPE, the flag of routes scoped to
+// their interface.
+const darwinRTFIfscope = 0x1000000
+
+// bsdZoneName names the zone of a scoped IPv6 address after its
+// interface, falling back to the numeric index.
+func bsdZoneName(ifIndex int) string {
+	if iface, err := net.InterfaceByIndex(ifIndex); err == nil {
+		return iface.Name
+	}
+	return strconv.Itoa(ifIndex)
+}
+
+// inet6Addr returns the normalized address of sa. Scoped addresses
+// without a scope of their own belong to the route's interface.
+func inet6Addr(sa *route.Inet6Addr, ifIndex int) netip.Addr {
+	addr := normalizeKAMEAddr(sa.IP, sa.ZoneID, bsdZoneName)
+	if addr.Zone() == "" && ifIndex != 0 {
+		addr = withZone(addr, bsdZoneName(ifIndex))
+	}
+	return addr
 }
 
-func discoverGatewaysByFamily(family int) ([]net.IP, error) {
-	rib, err := route.FetchRIB(family, syscall.NET_RT_DUMP, 0)
+// The Discover functions answer from a snapshot of a single RIB dump, so
+// that the gateway and the interface come from the same routes, ranked by
+// the state of their links.
+
+func discoverGatewaysOSSpecific() (ips []net.IP, err error) {
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
+	return s.Gateways()
+}
 
-	msgs, err := route.ParseRIB(syscall.NET_RT_DUMP, rib)
+func discoverGatewayInterfaceOSSpecific() (ip net.IP, err error) {
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
+	return s.Interface()
+}
 
-	seen := make(map[string]bool)
-	var result []net.IP
-	for _, m := range msgs {
-		rm, ok := m.(*route.RouteMessage)
-		if !ok {
-			continue
-		}
-		if len(rm.Addrs) <= syscall.RTAX_GATEWAY || rm.Addrs[syscall.RTAX_GATEWAY] == nil {
-			continue
-		}
-		var ip net.IP
-		switch sa := rm.Addrs[syscall.RTAX_GATEWAY].(type) {
-		case *route.Inet4Addr:
-			ip = net.IPv4(sa.IP[0], sa.IP[1], sa.IP[2], sa.IP[3])
-		case *route.Inet6Addr:
-			ip = make(net.IP, net.IPv6len)
-			copy(ip, sa.IP[:])
-		}
-		if ip != nil {
-			key := ip.String()
-			if !seen[key] {
-				seen[key] = true
-				result = append(result, ip)
-			}
-		}
-	}
-	if len(result) == 0 {
-		return nil, &ErrNoGateway{}
+func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
 	}
-	return result, nil
+	return s.GatewaysIPv6()
 }
 
-func discoverGatewaysOSSpecific() (ips []net.IP, err error) {
-	return discoverGatewaysByFamily(syscall.AF_INET)
+func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return s.InterfaceIPv6()
 }
 
-func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
-	return discoverGatewaysByFamily(syscall.AF_INET6)
+func discoverGatewayAddrsIPv6OSSpecific() (addrs []net.IPAddr, err error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return s.gatewayAddrsIPv6()
 }
 
-func discoverGatewayInterfaceOSSpecific() (ip net.IP, err error) {
-	bytes, err := readNetstat()
+func discoverSnapshotOSSpecific() (Snapshot, error) {
+	// A single dump of both families keeps IPv4 and IPv6 consistent.
+	rib, err := route.FetchRIB(syscall.AF_UNSPEC, syscall.NET_RT_DUMP, 0)
 	if err != nil {
-		return nil, err
+		return Snapshot{}, err
 	}
 
-	return parseUnixInterfaceIP(bytes)
+	msgs, err := route.ParseRIB(syscall.NET_RT_DUMP, rib)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var routes []Route
+	for _, m := range msgs {
+		rm, ok := m.(*route.RouteMessage)
+		if !ok || rm.Flags&syscall.RTF_UP == 0 {
+			continue
+		}
+		if r, ok := routeFromMessage(rm); ok {
+			routes = append(routes, r)
+		}
+	}
+	return newSnapshot(routes, &intefaceGetterImpl{}), nil
 }
 
-func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
-	bytes, err := readNetstat()
-	if err != nil {
-		return nil, err
+func routeFromMessage(rm *route.RouteMessage) (Route, bool) {
+	addrAt := func(i int) route.Addr {
+		if i < len(rm.Addrs) {
+			return rm.Addrs[i]
+		}
+		return nil
 	}
 
-	return parseUnixInterfaceIPv6(bytes)
+	var dest netip.Addr
+	switch sa := addrAt(syscall.RTAX_DST).(type) {
+	case *route.Inet4Addr:
+		dest = netip.AddrFrom4(sa.IP)
+	case *route.Inet6Addr:
+		dest = normalizeKAMEAddr(sa.IP, sa.ZoneID, bsdZoneName).WithZone("")
+	default:
+		return Route{}, false
+	}
+
+	// Host routes and default routes may come without a netmask.
+	bits := 0
+	if rm.Flags&syscall.RTF_HOST != 0 {
+		bits = dest.BitLen()
+	}
+	switch sa := addrAt(syscall.RTAX_NETMASK).(type) {
+	case *route.Inet4Addr:
+		bits, _ = net.IPMask(sa.IP[:]).Size()
+	case *route.Inet6Addr:
+		bits, _ = net.IPMask(sa.IP[:]).Size()
+	}
+
+	r := Route{
+		Destination:    netip.PrefixFrom(dest, bits).Masked(),
+		InterfaceIndex: rm.Index,
+	}
+	if runtime.GOOS == "darwin" && rm.Flags&darwinRTFIfscope != 0 {
+		r.Flags = []string{ifscopeFlag}
+	}
+	if rm.Flags&syscall.RTF_GATEWAY != 0 {
+		switch sa := addrAt(syscall.RTAX_GATEWAY).(type) {
+		case *route.Inet4Addr:
+			r.Gateway = netip.AddrFrom4(sa.IP)
+		case *route.Inet6Addr:
+			r.Gateway = inet6Addr(sa, rm.Index)
+		}
+	}
+	return r, true
 }
@@ -4,16 +4,22 @@
 package gateway
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
 	"os"
+	"os/exec"
+	"syscall"
 )
 
 const (
 	// See http://man7.org/linux/man-pages/man8/route.8.html
 	file     = "/proc/net/route"
 	fileIPv6 = "/proc/net/ipv6_route"
+
+	// sysClassNet describes the network interfaces.
+	sysClassNet = "/sys/class/net"
 )
 
 func readRoutes() ([]byte, error) {
@@ -46,34 +52,213 @@ func readRoutesIPv6() ([]byte, error) {
 	return bytes, nil
 }
 
+// The Discover functions answer from a snapshot, which checks the links of
+// the default routes: static routes and NetworkManager with ignore-carrier
+// keep routes on interfaces that are down.
+
 func discoverGatewaysOSSpecific() (ips []net.IP, err error) {
-	bytes, err := readRoutes()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
-	return parseLinuxGatewayIPs(bytes)
+	return s.Gateways()
 }
 
 func discoverGatewayInterfaceOSSpecific() (ip net.IP, err error) {
-	bytes, err := readRoutes()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
-	return parseLinuxInterfaceIP(bytes)
+	return s.Interface()
 }
 
 func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
-	bytes, err := readRoutesIPv6()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
-	return parseLinuxIPv6GatewayIPs(bytes)
+	return s.GatewaysIPv6()
 }
 
 func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
-	bytes, err := readRoutesIPv6()
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return s.InterfaceIPv6()
+}
+
+func discoverSnapshotOSSpecific() (Snapshot, error) {
+	// The netlink dump tells the table of each route, so the routes of
+	// VRFs and of the local table stay out of the snapshot.
+	if links, routes, err := discoverNetlinkRoutes(); err == nil {
+		return newLinuxSnapshot(mainRoutes(routes), links), nil
+	}
+
+	bytes, err := readRoutes()
+	if err != nil {
+		if s, ipErr := discoverToolSnapshot(); ipErr == nil {
+			return s, nil
+		}
+		return Snapshot{}, err
+	}
+	routes, err := parseLinuxRoutes(bytes, binary.NativeEndian)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	// Hosts with IPv6 disabled have no ipv6_route file. It lists the
+	// routes of all tables but only marks those of the local table;
+	// VRFs can't be configured without netlink anyway.
+	if bytes, err := readRoutesIPv6(); err == nil {
+		routes6, err := parseLinuxIPv6Routes(bytes)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		routes = append(routes, mainRoutes(routes6)...)
+	}
+
+	return newLinuxSnapshot(routes, nil), nil
+}
+
+func discoverGatewayAddrsIPv6OSSpecific() (addrs []net.IPAddr, err error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return s.gatewayAddrsIPv6()
+}
+
+// discoverToolSnapshot reads the main routing tables with iproute2, or
+// with net-tools' route on devices without it, for containers and
+// sandboxes that hide /proc/net/route but ship the tools.
+func discoverToolSnapshot() (Snapshot, error) {
+	routes, err := runIPRoute("-4")
+	if err != nil {
+		output, routeErr := exec.Command("route", "-n").Output()
+		if routeErr != nil {
+			return Snapshot{}, err
+		}
+		if routes, err = parseNetToolsRoutes(output); err != nil {
+			return Snapshot{}, err
+		}
+		// Hosts with IPv6 disabled fail here.
+		if output, err := exec.Command("route", "-A", "inet6", "-n").Output(); err == nil {
+			if routes6, err := parseNetToolsRoutes(output); err == nil {
+				routes = append(routes, routes6...)
+			}
+		}
+		return newLinuxSnapshot(routes, nil), nil
+	}
+
+	// Hosts with IPv6 disabled fail here.
+	if routes6, err := runIPRoute("-6"); err == nil {
+		routes = append(routes, routes6...)
+	}
+	return newLinuxSnapshot(routes, nil), nil
+}
+
+// runIPRoute lists the routes of the main table of one address family
+// ("-4" or "-6"), or those of the tables selected by args, such as
+// "table all". BusyBox' ip has no JSON output, so the text output is read
+// when -json fails.
+func runIPRoute(family string, args ...string) ([]Route, error) {
+	args = append([]string{family, "route", "show"}, args...)
+	output, err := exec.Command("ip", append([]string{"-json"}, args...)...).Output()
+	if err != nil {
+		if output, err = exec.Command("ip", args...).Output(); err != nil {
+			return nil, err
+		}
+	}
+	return parseIPRoute(output, family == "-6")
+}
+
+// discoverPolicyRoutesOSSpecific lists the routes of the routing tables
+// other than main, which policy rules (ip rule) select, for example by
+// interface.
+func discoverPolicyRoutesOSSpecific() ([]Route, error) {
+	// The netlink dump holds all tables, and tells their VRFs.
+	if _, routes, err := discoverVRFRoutesOSSpecific(); err == nil {
+		return policyRoutes(routes), nil
+	}
+
+	routes, err := runIPRoute("-4", "table", "all")
+	if err != nil {
+		return nil, err
+	}
+	// Hosts with IPv6 disabled fail here.
+	if routes6, err := runIPRoute("-6", "table", "all"); err == nil {
+		routes = append(routes, routes6...)
+	}
+	return policyRoutes(routes), nil
+}
+
+// discoverPolicyRulesOSSpecific lists the policy rules of one address
+// family with ip rule, as JSON or, for BusyBox, as text.
+func discoverPolicyRulesOSSpecific(ipv6 bool) ([]PolicyRule, error) {
+	family := "-4"
+	if ipv6 {
+		family = "-6"
+	}
+	output, err := exec.Command("ip", "-json", family, "rule", "show").Output()
+	if err != nil {
+		if output, err = exec.Command("ip", family, "rule", "show").Output(); err != nil {
+			return nil, err
+		}
+	}
+	return ParseIPRule(output)
+}
+
+// discoverVRFRoutesOSSpecific lists the VRF devices and the routes of all
+// routing tables from rtnetlink, tagging those of VRF tables with their
+// VRF. Unlike /proc/net/route, the dump includes the tables of VRFs.
+func discoverVRFRoutesOSSpecific() ([]VRF, []Route, error) {
+	links, routes, err := discoverNetlinkRoutes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return linuxVRFs(links), routes, nil
+}
+
+// discoverNetlinkRoutes lists the network interfaces and the routes of all
+// routing tables from rtnetlink.
+func discoverNetlinkRoutes() ([]linuxLink, []Route, error) {
+	links, err := discoverLinuxLinks()
+	if err != nil {
+		return nil, nil, err
+	}
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETROUTE, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, nil, err
+	}
+	msgs, err := parseNetlinkMessages(rib, binary.NativeEndian)
+	if err != nil {
+		return nil, nil, err
+	}
+	routes, err := parseLinuxRouteMessages(msgs, binary.NativeEndian, links)
+	if err != nil {
+		return nil, nil, err
+	}
+	return links, routes, nil
+}
+
+// discoverLinuxLinks lists the network interfaces from rtnetlink.
+func discoverLinuxLinks() ([]linuxLink, error) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := parseNetlinkMessages(rib, binary.NativeEndian)
 	if err != nil {
 		return nil, err
 	}
-	return parseLinuxIPv6InterfaceIP(bytes)
+	return parseLinuxLinks(msgs, binary.NativeEndian)
+}
+
+// newLinuxSnapshot classifies the interfaces of the routes and checks
+// their links, from their netlink flags or, without links, from sysfs.
+// Then it captures the snapshot.
+func newLinuxSnapshot(routes []Route, links []linuxLink) Snapshot {
+	annotateLinuxRoutes(routes, os.DirFS(sysClassNet), linuxLinkFlags(links))
+	return newSnapshot(routes, &intefaceGetterImpl{})
 }
@@ -5,9 +5,7 @@ package gateway
 // * https://man.freebsd.org/cgi/man.cgi?query=netstat&sektion=1
 
 import (
-	"bufio"
 	"bytes"
-	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"net"
@@ -24,6 +22,8 @@ const (
 	ns_netif       = "Netif"
 	ns_gateway     = "Gateway"
 	ns_interface   = "Interface"
+	ns_genmask     = "Genmask"
+	ns_metric      = "Metric"
 )
 
 type netstatFields map[string]int
@@ -36,14 +36,6 @@ type windowsRouteStruct struct {
 	Interface string
 }
 
-type linuxRouteStruct struct {
-	// Name of interface
-	Iface string
-
-	// big-endian hex string
-	Gateway string
-}
-
 type unixRouteStruct struct {
 	// Name of interface
 	Iface string
@@ -71,19 +63,28 @@ func discoverFields(output []byte) (int, netstatFields) {
 
 	outputLines := strings.Split(string(output), "\n")
 	for lineNo, line := range outputLines {
-		fields := strings.Fields(line)
+		// Linux' net-tools call the IPv6 gateway column "Next Hop".
+		fields := strings.Fields(strings.Replace(line, "Next Hop", ns_gateway, 1))
 
 		if len(fields) > 3 {
 			d := fieldNum(fields, ns_destination, "Destination/Mask")
-			f := fieldNum(fields, ns_flags)
+			f := fieldNum(fields, ns_flags, "Flag")
 			g := fieldNum(fields, ns_gateway)
-			n := fieldNum(fields, ns_netif, "If", ns_interface)
+			n := fieldNum(fields, ns_netif, "If", ns_interface, "Iface")
 			if d >= 0 && f >= 0 && g >= 0 && n >= 0 {
 				nf[ns_destination] = d
 				nf[ns_flags] = f
 				nf[ns_gateway] = g
 				nf[ns_netif] = n
 
+				// Optional columns of Linux' route -n
+				if m := fieldNum(fields, ns_genmask); m >= 0 {
+					nf[ns_genmask] = m
+				}
+				if m := fieldNum(fields, ns_metric, "Met"); m >= 0 {
+					nf[ns_metric] = m
+				}
+
 				return lineNo, nf
 			}
 		}
@@ -206,6 +207,9 @@ func parseToWindowsRouteStruct(output []byte) ([]windowsRouteStruct, error) {
 		return nil, &ErrNoGateway{}
 	}
 
+	// The Metric column of route print is already the route metric plus
+	// the interface metric, which is what Windows compares. Sources that
+	// report the two apart are handled by Route.EffectiveMetric.
 	slices.SortFunc(defaultRoutes,
 		func(a, b gatewayEntry) int {
 			return a.metric - b.metric
@@ -224,60 +228,6 @@ func parseToWindowsRouteStruct(output []byte) ([]windowsRouteStruct, error) {
 	return result, nil
 }
 
-func parseToLinuxRouteStructs(output []byte) ([]linuxRouteStruct, error) {
-	// parseLinuxProcNetRoute parses the route file located at /proc/net/route
-	// and returns the IP address of the default gateway. The default gateway
-	// is the one with Destination value of 0.0.0.0.
-	//
-	// The Linux route file has the following format:
-	//
-	// $ cat /proc/net/route
-	//
-	// Iface   Destination Gateway     Flags   RefCnt  Use Metric  Mask
-	// eno1    00000000    C900A8C0    0003    0   0   100 00000000    0   00
-	// eno1    0000A8C0    00000000    0001    0   0   100 00FFFFFF    0   00
-	const (
-		sep              = "\t" // field separator
-		destinationField = 1    // field containing hex destination address
-		gatewayField     = 2    // field containing hex gateway address
-		maskField        = 7    // field containing hex mask
-	)
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-
-	// Skip header line
-	if !scanner.Scan() {
-		err := scanner.Err()
-		if err == nil {
-			return nil, &ErrNoGateway{}
-		}
-
-		return nil, err
-	}
-
-	var result []linuxRouteStruct
-	for scanner.Scan() {
-		row := scanner.Text()
-		tokens := strings.Split(row, sep)
-		if len(tokens) < 11 {
-			return nil, &ErrInvalidRouteFileFormat{row: row}
-		}
-
-		// The default interface is the one that's 0 for both destination and mask.
-		if !(tokens[destinationField] == "00000000" && tokens[maskField] == "00000000") {
-			continue
-		}
-
-		result = append(result, linuxRouteStruct{
-			Iface:   tokens[0],
-			Gateway: tokens[2],
-		})
-	}
-	if len(result) == 0 {
-		return nil, &ErrNoGateway{}
-	}
-	return result, nil
-}
-
 func parseWindowsGatewayIPs(output []byte) ([]net.IP, error) {
 	parsedOutputs, err := parseToWindowsRouteStruct(output)
 	if err != nil {
@@ -320,6 +270,14 @@ func parseWindowsInterfaceIP(output []byte) ([]net.IP, error) {
 }
 
 func parseWindowsIPv6GatewayIPs(output []byte) ([]net.IP, error) {
+	addrs, err := parseWindowsIPv6GatewayAddrs(output)
+	if err != nil {
+		return nil, err
+	}
+	return ipAddrsToIPs(addrs), nil
+}
+
+func parseWindowsIPv6GatewayAddrs(output []byte) ([]net.IPAddr, error) {
 	// Windows IPv6 route table format (from 'route print -6'):
 	//
 	// ===========================================================================
@@ -330,61 +288,23 @@ func parseWindowsIPv6GatewayIPs(output []byte) ([]net.IP, error) {
 	//  12    281  ::/0                    fe80::1
 	//  12    281  ::1/128                 On-link
 	// ===========================================================================
-
-	lines := strings.Split(string(output), "\n")
-	inActiveRoutes := false
+	//
+	// Link-local gateways are zoned with the interface index of the "If"
+	// column. Windows resolves numeric zones, and unlike the interface's
+	// friendly name the index contains no spaces.
+	//
+	// The headers are localized; see splitWindowsRoutePrint.
 	seen := make(map[string]bool)
-	var result []net.IP
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "Active Routes:") || strings.HasPrefix(line, "Rutas activas:") {
-			inActiveRoutes = true
-			continue
-		}
-
-		if !inActiveRoutes {
-			continue
-		}
-
-		// End of active routes section
-		if strings.HasPrefix(line, "====") || strings.HasPrefix(line, "Persistent") || strings.HasPrefix(line, "Rutas persistentes") || line == "" {
-			if len(result) > 0 {
-				break
-			}
+	var result []net.IPAddr
+	for _, r := range windowsIPv6DefaultRoutes(output) {
+		if !r.Gateway.IsValid() {
 			continue
 		}
-
-		// Skip column header line
-		if strings.HasPrefix(line, "If") {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) < 4 {
-			continue
-		}
-
-		// Fields: If, Metric, Network Destination, Gateway
-		dest := fields[2]
-		gateway := fields[3]
-
-		// Look for default route ::/0
-		if dest != "::/0" {
-			continue
-		}
-
-		// Skip "On-link" or other text gateways
-		ip := net.ParseIP(gateway)
-		if ip == nil {
-			continue
-		}
-
-		key := ip.String()
+		addr := net.IPAddr{IP: addrToIP(r.Gateway), Zone: r.Gateway.Zone()}
+		key := addr.String()
 		if !seen[key] {
 			seen[key] = true
-			result = append(result, ip)
+			result = append(result, addr)
 		}
 	}
 
@@ -394,6 +314,22 @@ func parseWindowsIPv6GatewayIPs(output []byte) ([]net.IP, error) {
 	return result, nil
 }
 
+// windowsIPv6DefaultRoutes returns the active IPv6 default routes of
+// route print, whatever the language of the output.
+func windowsIPv6DefaultRoutes(output []byte) []Route {
+	routes, err := parseWindowsRoutes(output)
+	if err != nil {
+		return nil
+	}
+	var result []Route
+	for _, r := range routes {
+		if r.IsDefault() && r.Is6() {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
 func parseWindowsIPv6InterfaceIP(output []byte) (net.IP, error) {
 	return parseWindowsIPv6InterfaceIPImpl(output, &intefaceGetterImpl{})
 }
@@ -401,50 +337,8 @@ func parseWindowsIPv6InterfaceIP(output []byte) (net.IP, error) {
 func parseWindowsIPv6InterfaceIPImpl(output []byte, ifaceGetter interfaceGetter) (net.IP, error) {
 	// Parse the Windows IPv6 route table to find the interface index
 	// for the default route (::/0), then resolve it to an IPv6 address.
-	lines := strings.Split(string(output), "\n")
-	inActiveRoutes := false
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "Active Routes:") || strings.HasPrefix(line, "Rutas activas:") {
-			inActiveRoutes = true
-			continue
-		}
-
-		if !inActiveRoutes {
-			continue
-		}
-
-		if strings.HasPrefix(line, "====") || strings.HasPrefix(line, "Persistent") || strings.HasPrefix(line, "Rutas persistentes") || line == "" {
-			continue
-		}
-
-		if strings.HasPrefix(line, "If") {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) < 4 {
-			continue
-		}
-
-		// Fields: If, Metric, Network Destination, Gateway
-		ifIndex := fields[0]
-		dest := fields[2]
-
-		if dest != "::/0" {
-			continue
-		}
-
-		// Parse the interface index
-		idx, err := strconv.Atoi(ifIndex)
-		if err != nil {
-			continue
-		}
-
-		// Look up the interface by index
-		iface, err := ifaceGetter.InterfaceByIndex(idx)
+	for _, r := range windowsIPv6DefaultRoutes(output) {
+		iface, err := ifaceGetter.InterfaceByIndex(r.InterfaceIndex)
 		if err != nil {
 			return nil, err
 		}
@@ -483,101 +377,6 @@ func parseWindowsIPv6InterfaceIPImpl(output []byte, ifaceGetter interfaceGetter)
 	return nil, &ErrNoGateway{}
 }
 
-func parseLinuxGatewayIPs(output []byte) ([]net.IP, error) {
-	parsedStructs, err := parseToLinuxRouteStructs(output)
-	if err != nil {
-		return nil, err
-	}
-
-	result := make([]net.IP, 0, len(parsedStructs))
-	for _, parsedStruct := range parsedStructs {
-		// cast hex address to uint32
-		d, err := strconv.ParseUint(parsedStruct.Gateway, 16, 32)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"parsing default interface address field hex %q: %w",
-				parsedStruct.Gateway,
-				err,
-			)
-		}
-		// make net.IP address from uint32
-		ipd32 := make(net.IP, 4)
-		binary.LittleEndian.PutUint32(ipd32, uint32(d))
-		result = append(result, ipd32)
-	}
-	return result, nil
-}
-
-func parseLinuxInterfaceIP(output []byte) (net.IP, error) {
-	// Return the first IPv4 address we encounter.
-	return parseLinuxInterfaceIPImpl(output, &intefaceGetterImpl{})
-}
-
-func parseLinuxInterfaceIPImpl(output []byte, ifaceGetter interfaceGetter) (net.IP, error) {
-	// Mockable implemenation
-	parsedStructs, err := parseToLinuxRouteStructs(output)
-	if err != nil {
-		return nil, err
-	}
-
-	return getInterfaceIP4(parsedStructs[0].Iface, ifaceGetter)
-}
-
-// linuxIPv6RouteStruct represents a parsed entry from /proc/net/ipv6_route
-type linuxIPv6RouteStruct struct {
-	// Name of interface
-	Iface string
-
-	// 32-character hex string representing 128-bit IPv6 address
-	Gateway string
-}
-
-func parseToLinuxIPv6RouteStructs(output []byte) ([]linuxIPv6RouteStruct, error) {
-	// Parse /proc/net/ipv6_route which has the format:
-	//
-	// dest dest_prefix src src_prefix nexthop metric refcnt use flags iface
-	// 00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000242acfffe110003 00000064 00000000 00000000 00000003 eth0
-	//
-	// Fields are space-separated. All hex values.
-	// The default route has destination all zeros with prefix length 00.
-	const (
-		destinationField    = 0
-		destinationPrefField = 1
-		gatewayField        = 4
-		ifaceField          = 9
-		allZeros            = "00000000000000000000000000000000"
-	)
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-
-	var result []linuxIPv6RouteStruct
-	for scanner.Scan() {
-		row := scanner.Text()
-		fields := strings.Fields(row)
-		if len(fields) < 10 {
-			continue
-		}
-
-		// Default route: destination is all zeros, prefix length is 00
-		if fields[destinationField] != allZeros || fields[destinationPrefField] != "00" {
-			continue
-		}
-
-		// Skip if gateway is also all zeros (no real gateway)
-		if fields[gatewayField] == allZeros {
-			continue
-		}
-
-		result = append(result, linuxIPv6RouteStruct{
-			Iface:   fields[ifaceField],
-			Gateway: fields[gatewayField],
-		})
-	}
-	if len(result) == 0 {
-		return nil, &ErrNoGateway{}
-	}
-	return result, nil
-}
-
 func parseIPv6Hex(hexStr string) (net.IP, error) {
 	if len(hexStr) != 32 {
 		return nil, fmt.Errorf("invalid IPv6 hex string length: %d", len(hexStr))
@@ -589,41 +388,6 @@ func parseIPv6Hex(hexStr string) (net.IP, error) {
 	return net.IP(b), nil
 }
 
-func parseLinuxIPv6GatewayIPs(output []byte) ([]net.IP, error) {
-	parsedStructs, err := parseToLinuxIPv6RouteStructs(output)
-	if err != nil {
-		return nil, err
-	}
-
-	seen := make(map[string]bool)
-	result := make([]net.IP, 0, len(parsedStructs))
-	for _, parsedStruct := range parsedStructs {
-		ip, err := parseIPv6Hex(parsedStruct.Gateway)
-		if err != nil {
-			return nil, err
-		}
-		key := ip.String()
-		if !seen[key] {
-			seen[key] = true
-			result = append(result, ip)
-		}
-	}
-	return result, nil
-}
-
-func parseLinuxIPv6InterfaceIP(output []byte) (net.IP, error) {
-	return parseLinuxIPv6InterfaceIPImpl(output, &intefaceGetterImpl{})
-}
-
-func parseLinuxIPv6InterfaceIPImpl(output []byte, ifaceGetter interfaceGetter) (net.IP, error) {
-	parsedStructs, err := parseToLinuxIPv6RouteStructs(output)
-	if err != nil {
-		return nil, err
-	}
-
-	return getInterfaceIP6(parsedStructs[0].Iface, ifaceGetter)
-}
-
 func parseUnixInterfaceIP(output []byte) (net.IP, error) {
 	// Return the first IPv4 address we encounter.
 	return parseUnixInterfaceIPImpl(output, &intefaceGetterImpl{})
@@ -652,6 +416,16 @@ func getInterfaceIP4(name string, ifaceGetter interfaceGetter) (net.IP, error) {
 		return nil, err
 	}
 
+	if ip := pickIP4(addrs); ip != nil {
+		return ip, nil
+	}
+
+	return nil, fmt.Errorf("no IPv4 address found for interface %v",
+		name)
+}
+
+func pickIP4(addrs []net.Addr) net.IP {
+	// Return the first IPv4 address of an interface
 	for _, addr := range addrs {
 		ipnet, ok := addr.(*net.IPNet)
 		if !ok {
@@ -660,12 +434,11 @@ func getInterfaceIP4(name string, ifaceGetter interfaceGetter) (net.IP, error) {
 
 		ip := ipnet.IP.To4()
 		if ip != nil {
-			return ip, nil
+			return ip
 		}
 	}
 
-	return nil, fmt.Errorf("no IPv4 address found for interface %v",
-		name)
+	return nil
 }
 
 func parseUnixInterfaceIPv6(output []byte) (net.IP, error) {
@@ -696,6 +469,16 @@ func getInterfaceIP6(name string, ifaceGetter interfaceGetter) (net.IP, error) {
 		return nil, err
 	}
 
+	if ip := pickIP6(addrs); ip != nil {
+		return ip, nil
+	}
+
+	return nil, fmt.Errorf("no IPv6 address found for interface %v",
+		name)
+}
+
+func pickIP6(addrs []net.Addr) net.IP {
+	// Return the first global IPv6 address of an interface
 	for _, addr := range addrs {
 		ipnet, ok := addr.(*net.IPNet)
 		if !ok {
@@ -709,7 +492,7 @@ func getInterfaceIP6(name string, ifaceGetter interfaceGetter) (net.IP, error) {
 
 		ip := ipnet.IP.To16()
 		if ip != nil && !ip.IsLinkLocalUnicast() {
-			return ip, nil
+			return ip
 		}
 	}
 
@@ -726,12 +509,11 @@ func getInterfaceIP6(name string, ifaceGetter interfaceGetter) (net.IP, error) {
 
 		ip := ipnet.IP.To16()
 		if ip != nil {
-			return ip, nil
+			return ip
 		}
 	}
 
-	return nil, fmt.Errorf("no IPv6 address found for interface %v",
-		name)
+	return nil
 }
 
 func parseUnixGatewayIPs(output []byte) ([]net.IP, error) {
@@ -798,6 +580,14 @@ func parseNetstatToRouteStruct(output []byte) ([]unixRouteStruct, error) {
 }
 
 func parseSolarisIPv6GatewayIPs(output []byte) ([]net.IP, error) {
+	addrs, err := parseSolarisIPv6GatewayAddrs(output)
+	if err != nil {
+		return nil, err
+	}
+	return ipAddrsToIPs(addrs), nil
+}
+
+func parseSolarisIPv6GatewayAddrs(output []byte) ([]net.IPAddr, error) {
 	// Solaris netstat -rn output has a section "Routing Table: IPv6"
 	idx := bytes.Index(output, []byte("Routing Table: IPv6"))
 	if idx != -1 {
@@ -809,12 +599,18 @@ func parseSolarisIPv6GatewayIPs(output []byte) ([]net.IP, error) {
 		return nil, err
 	}
 
-	result := make([]net.IP, 0, len(parsedStructs))
+	result := make([]net.IPAddr, 0, len(parsedStructs))
 	for _, parsedStruct := range parsedStructs {
-		ip := net.ParseIP(parsedStruct.Gateway)
-		if ip != nil {
-			result = append(result, ip)
+		// The gateway may carry its own zone ("fe80::1%net0"); otherwise
+		// link-local gateways are zoned with the route's interface.
+		addr := parseZonedIP(parsedStruct.Gateway)
+		if addr == nil {
+			continue
+		}
+		if addr.Zone == "" {
+			*addr = zonedIPAddr(addr.IP, parsedStruct.Iface)
 		}
+		result = append(result, *addr)
 	}
 	if len(result) == 0 {
 		return nil, &ErrNoGateway{}
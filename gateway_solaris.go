@@ -13,38 +13,58 @@ func readNetstat() ([]byte, error) {
 	return routeCmd.CombinedOutput()
 }
 
+// The Discover functions answer from a snapshot, which ranks the default
+// routes by the state of their links.
+
 func discoverGatewaysOSSpecific() (ips []net.IP, err error) {
-	bytes, err := readNetstat()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
-
-	return parseUnixGatewayIPs(bytes)
+	return s.Gateways()
 }
 
 func discoverGatewayInterfaceOSSpecific() (ip net.IP, err error) {
-	bytes, err := readNetstat()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
-
-	return parseUnixInterfaceIP(bytes)
+	return s.Interface()
 }
 
 func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
-	bytes, err := readNetstat()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
-
-	return parseSolarisIPv6GatewayIPs(bytes)
+	return s.GatewaysIPv6()
 }
 
 func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
-	bytes, err := readNetstat()
+	s, err := discoverSnapshotOSSpecific()
 	if err != nil {
 		return nil, err
 	}
+	return s.InterfaceIPv6()
+}
+
+func discoverSnapshotOSSpecific() (Snapshot, error) {
+	bytes, err := readNetstat()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	routes, err := parseNetstatRoutes(bytes)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return newSnapshot(routes, &intefaceGetterImpl{}), nil
+}
 
-	return parseSolarisIPv6InterfaceIP(bytes)
+func discoverGatewayAddrsIPv6OSSpecific() (addrs []net.IPAddr, err error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return s.gatewayAddrsIPv6()
 }
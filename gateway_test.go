@@ -3,9 +3,12 @@
 package gateway
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -51,6 +54,14 @@ func TestParseWindows(t *testing.T) {
 		{windows, true, "10.88.88.2", nil},
 		{windowsLocalized, true, "10.88.88.2", nil},
 		{windowsLocalized2, true, "192.168.100.1", nil},
+		{windowsGerman, true, "192.168.178.1", nil},
+		{windowsFrench, true, "192.168.1.254", nil},
+		{windowsJapanese, true, "192.168.0.1", nil},
+		{windowsChinese, true, "192.168.1.1", nil},
+		{windowsPortuguese, true, "192.168.0.1", nil},
+		{windowsRussian, true, "192.168.1.1", nil},
+		{windowsSpanishPersistent, true, "192.168.1.1", nil},
+		{windowsGermanPersistent, false, "", &ErrNoGateway{}},
 		{windowsMultipleGateways, true, "10.21.38.1", nil},
 		{randomData, false, "", &ErrCantParse{}},
 		{windowsNoRoute, false, "", &ErrNoGateway{}},
@@ -81,6 +92,18 @@ func TestParseWindows(t *testing.T) {
 	})
 }
 
+// linuxGateways returns the gateways that the snapshot of /proc/net/route
+// read in the given byte order reports.
+func linuxGateways(order binary.ByteOrder) func([]byte) ([]net.IP, error) {
+	return func(output []byte) ([]net.IP, error) {
+		routes, err := parseLinuxRoutes(output, order)
+		if err != nil {
+			return nil, err
+		}
+		return Snapshot{Routes: routes}.Gateways()
+	}
+}
+
 func TestParseLinux(t *testing.T) {
 	// Linux route tables are extracted from  proc filesystem
 
@@ -89,8 +112,20 @@ func TestParseLinux(t *testing.T) {
 		{linuxNoRoute, false, "", &ErrNoGateway{}},
 	}
 
-	t.Run("parseLinuxGatewayIPs", func(t *testing.T) {
-		testGatewayAddress(t, testcases, parseLinuxGatewayIPs)
+	t.Run("parseLinuxRoutes", func(t *testing.T) {
+		testGatewayAddress(t, testcases, linuxGateways(binary.LittleEndian))
+	})
+
+	// s390x and big-endian MIPS routers print the addresses the other way
+	// around.
+	bigEndianTestcases := []ipTestCase{
+		{linuxBigEndian, true, "192.168.1.1", nil},
+		{linuxMIPSBigEndian, true, "192.168.8.1", nil},
+		{linuxNoRoute, false, "", &ErrNoGateway{}},
+	}
+
+	t.Run("parseLinuxRoutes big-endian", func(t *testing.T) {
+		testGatewayAddress(t, bigEndianTestcases, linuxGateways(binary.BigEndian))
 	})
 
 	interfaceTestCases := []ifaceTestCase{
@@ -98,20 +133,15 @@ func TestParseLinux(t *testing.T) {
 		{linuxNoRoute, "wlp4s0", false, "", &ErrNoGateway{}},
 	}
 
-	t.Run("parseLinuxInterfaceIP", func(t *testing.T) {
-		testInterfaceAddress(t, interfaceTestCases, parseLinuxInterfaceIPImpl)
+	t.Run("parseLinuxRoutes interface", func(t *testing.T) {
+		testInterfaceAddress(t, interfaceTestCases, func(output []byte, ifaceGetter interfaceGetter) (net.IP, error) {
+			routes, err := parseLinuxRoutes(output, binary.LittleEndian)
+			if err != nil {
+				return nil, err
+			}
+			return newSnapshot(routes, ifaceGetter).Interface()
+		})
 	})
-
-	// ifData := []byte(`Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
-	// eth0	00000000	00000000	0001	0	0	1000	0000FFFF	0	0	0
-	// `)
-	// interfaceTestCases := []testcase{
-	// 	{ifData, true, "192.168.8.238"},
-	// 	{noRoute, false, ""},
-	// }
-
-	// to run interface test in your local computer, change eth0 with your default interface name, and change the expected IP to be your default IP
-	// test(t, interfaceTestCases, parseLinuxInterfaceIP)
 }
 
 func TestParseLinuxIPv6(t *testing.T) {
@@ -120,15 +150,54 @@ func TestParseLinuxIPv6(t *testing.T) {
 		{linuxIPv6NoRoute, false, "", &ErrNoGateway{}},
 	}
 
-	t.Run("parseLinuxIPv6GatewayIPs", func(t *testing.T) {
-		testGatewayAddress(t, testcases, parseLinuxIPv6GatewayIPs)
+	t.Run("parseLinuxIPv6Routes", func(t *testing.T) {
+		testGatewayAddress(t, testcases, func(output []byte) ([]net.IP, error) {
+			routes, err := parseLinuxIPv6Routes(output)
+			if err != nil {
+				return nil, err
+			}
+			return Snapshot{Routes: routes}.GatewaysIPv6()
+		})
 	})
 }
 
+func TestParseLinuxIPv6LocalRoutes(t *testing.T) {
+	routes, err := parseLinuxIPv6Routes(routeTables[linuxIPv6RouteLocal])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The reject route is left out.
+	if len(routes) != 7 {
+		t.Fatalf("Expected 7 routes, got %d", len(routes))
+	}
+	var types []string
+	for _, r := range policyRoutes(routes) {
+		types = append(types, r.Type)
+	}
+	if want := []string{"local", "local", "local", "multicast"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("Unexpected local routes %v", types)
+	}
+	var destinations []string
+	for _, r := range mainRoutes(routes) {
+		destinations = append(destinations, r.Destination.String())
+	}
+	if want := []string{"fd00::/64", "fe80::/64", "::/0"}; !reflect.DeepEqual(destinations, want) {
+		t.Errorf("Unexpected main routes %v", destinations)
+	}
+}
+
 func TestParseWindowsIPv6(t *testing.T) {
 	testcases := []ipTestCase{
 		{windowsIPv6, true, "fe80::1", nil},
 		{windowsIPv6NoRoute, false, "", &ErrNoGateway{}},
+		{windowsLocalized2, false, "", &ErrNoGateway{}},
+		{windowsGerman, true, "fe80::3a10:d5ff:fe12:3456", nil},
+		{windowsFrench, true, "fe80::224:d4ff:fea1:b2c3", nil},
+		{windowsJapanese, true, "fe80::1", nil},
+		{windowsChinese, true, "fe80::5a41:20ff:fe01:2", nil},
+		{windowsPortuguese, true, "fe80::1", nil},
+		{windowsRussian, true, "fe80::1", nil},
+		{randomData, false, "", &ErrNoGateway{}},
 	}
 
 	t.Run("parseWindowsIPv6GatewayIPs", func(t *testing.T) {
@@ -330,3 +399,115 @@ func ExampleDiscoverGateway() {
 		fmt.Println("Gateway:", gateway.String())
 	}
 }
+
+// linuxIPv6GatewayAddrs returns the zoned gateways that the snapshot of
+// /proc/net/ipv6_route reports.
+func linuxIPv6GatewayAddrs(output []byte) ([]net.IPAddr, error) {
+	routes, err := parseLinuxIPv6Routes(output)
+	if err != nil {
+		return nil, err
+	}
+	return Snapshot{Routes: routes}.gatewayAddrsIPv6()
+}
+
+func TestParseIPv6GatewayAddrs(t *testing.T) {
+	// Link-local gateways carry the zone of their route's interface.
+	type testcase struct {
+		tableName string
+		fn        func([]byte) ([]net.IPAddr, error)
+		expected  []string
+	}
+
+	testcases := []testcase{
+		{linuxIPv6, linuxIPv6GatewayAddrs, []string{"fe80::242:acff:fe11:3%eth0"}},
+		{linuxIPv6MultiHomed, linuxIPv6GatewayAddrs, []string{"fe80::1%eth0", "fe80::1%eth1", "2001:db8::1"}},
+		{windowsIPv6, parseWindowsIPv6GatewayAddrs, []string{"fe80::1%12"}},
+		{windowsIPv6MultiHomed, parseWindowsIPv6GatewayAddrs, []string{"fe80::1%12", "fe80::1%17"}},
+		{solarisIPv6WithInterface, parseSolarisIPv6GatewayAddrs, []string{"fe80::aabb:ccdd:1234:1%net0"}},
+		{solarisNoInterface, parseSolarisIPv6GatewayAddrs, []string{"fe80::aabb:ccdd:1234:1"}},
+		{solarisIPv6MultiHomed, parseSolarisIPv6GatewayAddrs, []string{"fe80::1%net0", "fe80::1%net1", "2001:db8::1"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			addrs, err := tc.fn(routeTables[tc.tableName])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			for _, addr := range addrs {
+				got = append(got, addr.String())
+			}
+			if strings.Join(got, " ") != strings.Join(tc.expected, " ") {
+				t.Errorf("Unexpected gateway addresses %v != %v", got, tc.expected)
+			}
+		})
+	}
+
+	// Without zones the same link-local gateway is only returned once.
+	routes, err := parseLinuxIPv6Routes(routeTables[linuxIPv6MultiHomed])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ips, err := Snapshot{Routes: routes}.GatewaysIPv6()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ips) != 2 || ips[0].String() != "fe80::1" || ips[1].String() != "2001:db8::1" {
+		t.Errorf("Unexpected gateway addresses %v", ips)
+	}
+}
+
+func TestParseNetToolsRoutes(t *testing.T) {
+	type testcase struct {
+		tableName string
+		routes    int
+		want      []Route
+	}
+	testcases := []testcase{
+		{linuxRouteN, 6, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.1"), Interface: "eth0", Metric: 100},
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("10.0.0.1"), Interface: "wlan0", Metric: 600},
+		}},
+		{linuxRouteNIPv6, 7, []Route{
+			{Destination: netip.MustParsePrefix("::/0"), Gateway: netip.MustParseAddr("fe80::1%eth0"), Interface: "eth0", Metric: 1024},
+		}},
+		{busyboxRouteN, 3, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.8.1"), Interface: "wan"},
+		}},
+		{busyboxRouteNIPv6, 4, []Route{
+			{Destination: netip.MustParsePrefix("::/0"), Gateway: netip.MustParseAddr("fe80::9a:1%wan"), Interface: "wan", Metric: 512},
+		}},
+		{linuxNetstatRn, 2, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("172.17.0.1"), Interface: "eth0"},
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			routes, err := ParseNetToolsRoutes(routeTables[tc.tableName])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// Rejected routes are left out.
+			if len(routes) != tc.routes {
+				t.Errorf("Expected %d routes, got %d", tc.routes, len(routes))
+			}
+			if got := defaultRoutes(routes); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Unexpected default routes %+v", got)
+			}
+		})
+	}
+
+	t.Run("genmask", func(t *testing.T) {
+		routes, _ := ParseNetToolsRoutes(routeTables[linuxRouteN])
+		if routes[3].Destination != netip.MustParsePrefix("169.254.0.0/16") || routes[3].Gateway.IsValid() {
+			t.Errorf("Unexpected on-link route %+v", routes[3])
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := ParseNetToolsRoutes(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
@@ -22,3 +22,11 @@ func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
 func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
 	return nil, &ErrNotImplemented{}
 }
+
+func discoverSnapshotOSSpecific() (Snapshot, error) {
+	return Snapshot{}, &ErrNotImplemented{}
+}
+
+func discoverGatewayAddrsIPv6OSSpecific() (addrs []net.IPAddr, err error) {
+	return nil, &ErrNotImplemented{}
+}
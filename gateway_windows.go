@@ -6,53 +6,191 @@ package gateway
 import (
 	"net"
 	"os/exec"
+	"slices"
+	"sync"
 	"syscall"
 )
 
+// The Discover functions answer from route print alone: the other sources
+// start PowerShell or netsh, which take up to seconds. Snapshots read the
+// routes from the best source.
+
 func discoverGatewaysOSSpecific() (ips []net.IP, err error) {
-	routeCmd := exec.Command("route", "print", "0.0.0.0")
-	routeCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	output, err := routeCmd.CombinedOutput()
+	s, err := discoverRoutePrintSnapshot()
 	if err != nil {
 		return nil, err
 	}
-
-	return parseWindowsGatewayIPs(output)
+	return s.Gateways()
 }
 
 func discoverGatewayInterfaceOSSpecific() (ip net.IP, err error) {
-	routeCmd := exec.Command("route", "print", "0.0.0.0")
-	routeCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	output, err := routeCmd.CombinedOutput()
+	s, err := discoverRoutePrintSnapshot()
 	if err != nil {
 		return nil, err
 	}
+	return s.Interface()
+}
 
-	ips, err := parseWindowsInterfaceIP(output)
+func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
+	s, err := discoverRoutePrintSnapshot()
 	if err != nil {
 		return nil, err
 	}
-	return ips[0], nil
+	return s.GatewaysIPv6()
 }
 
-func discoverGatewaysIPv6OSSpecific() (ips []net.IP, err error) {
-	routeCmd := exec.Command("route", "print", "-6", "::/0")
-	routeCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	output, err := routeCmd.CombinedOutput()
+func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
+	s, err := discoverRoutePrintSnapshot()
 	if err != nil {
 		return nil, err
 	}
-
-	return parseWindowsIPv6GatewayIPs(output)
+	return s.InterfaceIPv6()
 }
 
-func discoverGatewayInterfaceIPv6OSSpecific() (ip net.IP, err error) {
-	routeCmd := exec.Command("route", "print", "-6", "::/0")
-	routeCmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	output, err := routeCmd.CombinedOutput()
+func discoverGatewayAddrsIPv6OSSpecific() (addrs []net.IPAddr, err error) {
+	s, err := discoverRoutePrintSnapshot()
 	if err != nil {
 		return nil, err
 	}
+	return s.gatewayAddrsIPv6()
+}
+
+func discoverSnapshotOSSpecific() (Snapshot, error) {
+	routes, persistent, err := discoverWindowsRoutes()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	s := newSnapshot(routes, &intefaceGetterImpl{})
+	s.Persistent = persistent
+	return s, nil
+}
+
+// discoverRoutePrintSnapshot captures the routing tables from route print.
+func discoverRoutePrintSnapshot() (Snapshot, error) {
+	routes, persistent, err := readRoutePrint()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	s := newSnapshot(routes, &intefaceGetterImpl{})
+	s.Persistent = persistent
+	return s, nil
+}
+
+// readRoutePrint reads the routing tables from route print, which, without
+// a filter, lists both the IPv4 and IPv6 tables.
+func readRoutePrint() (active, persistent []Route, err error) {
+	output, err := runHidden("route", "print")
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseWindowsRouteTablesImpl(output, &intefaceGetterImpl{})
+}
+
+// windowsRouteSources are the ways of reading the routing tables, in order
+// of preference. PowerShell and netsh report interface indexes, aliases and
+// metrics directly, while route print is always available but meant for
+// people: its sections and On-link markers are localized.
+//
+// Windows chooses routes by their route metric plus the metric of their
+// interface. PowerShell and netsh report the two apart, so the sources
+// fill in InterfaceMetric; route print reports the sum as Metric.
+//
+// netsh doesn't tell persistent routes, so that source takes them from
+// route print.
+var windowsRouteSources = []func() (active, persistent []Route, err error){
+	func() ([]Route, []Route, error) {
+		output, err := runHidden("powershell", "-NoProfile", "-NonInteractive", "-Command", getNetRouteScript)
+		if err != nil {
+			return nil, nil, err
+		}
+		active, persistent, err := parseGetNetRouteJSON(output)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Older versions of the NetTCPIP module don't report the
+		// interface metric with the routes.
+		if !slices.ContainsFunc(active, func(r Route) bool { return r.InterfaceMetric != 0 }) {
+			output, err := runHidden("powershell", "-NoProfile", "-NonInteractive", "-Command", getNetIPInterfaceScript)
+			if err == nil {
+				if metrics, err := parseGetNetIPInterfaceJSON(output); err == nil {
+					applyWindowsInterfaceMetrics(active, metrics)
+					applyWindowsInterfaceMetrics(persistent, metrics)
+				}
+			}
+		}
+		return active, persistent, nil
+	},
+	func() ([]Route, []Route, error) {
+		var routes []Route
+		for _, family := range []string{"ipv4", "ipv6"} {
+			output, err := runHidden("netsh", "interface", family, "show", "route")
+			if err != nil {
+				return nil, nil, err
+			}
+			r, err := parseNetshRoutes(output)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// Met is the route metric alone.
+			output, err = runHidden("netsh", "interface", family, "show", "interfaces")
+			if err != nil {
+				return nil, nil, err
+			}
+			metrics, err := parseNetshInterfaceMetrics(output, family == "ipv6")
+			if err != nil {
+				return nil, nil, err
+			}
+			applyWindowsInterfaceMetrics(r, metrics)
+			routes = append(routes, r...)
+		}
+
+		_, persistent, _ := readRoutePrint()
+		return routes, persistent, nil
+	},
+	readRoutePrint,
+}
+
+var (
+	windowsRouteSourceOnce sync.Once
+
+	// windowsRouteSource is the index of the first source of
+	// windowsRouteSources that worked. The sources before it failed and
+	// aren't tried again: starting PowerShell alone takes up to seconds.
+	windowsRouteSource int
+)
+
+// discoverWindowsRoutes reads the routing tables from the first source
+// that works, which the first call picks.
+func discoverWindowsRoutes() (active, persistent []Route, err error) {
+	picked := false
+	windowsRouteSourceOnce.Do(func() {
+		picked = true
+		for windowsRouteSource = range windowsRouteSources {
+			active, persistent, err = windowsRouteSources[windowsRouteSource]()
+			if err == nil {
+				return
+			}
+		}
+	})
+	if picked {
+		return active, persistent, err
+	}
+
+	for _, source := range windowsRouteSources[windowsRouteSource:] {
+		active, persistent, err = source()
+		if err == nil {
+			return active, persistent, nil
+		}
+	}
+	return nil, nil, err
+}
 
-	return parseWindowsIPv6InterfaceIP(output)
+// runHidden runs a console command without flashing a window.
+func runHidden(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	// Standard error is left out, so that PowerShell warnings don't break
+	// the JSON.
+	return cmd.Output()
 }
@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"net"
+	"slices"
+)
+
+// ifscopeFlag flags routes scoped to their interface, which only traffic
+// bound to the interface uses (Darwin's RTF_IFSCOPE, "I" in netstat).
+const ifscopeFlag = "ifscope"
+
+// DiscoverGatewaysForInterface is the OS independent function to get the
+// IPv4 gateways of the named interface rather than those of the host, for
+// example of the management port of a multi-homed server. They are taken
+// from the default routes through the interface: those of the main table
+// first, then those scoped to the interface (Darwin) and those of other
+// routing tables (Linux policy routing), each by metric. Without such
+// routes, the routers of the interface's current DHCP lease are returned.
+//
+// If err is nil, then ips is guaranteed to have at least one element.
+func DiscoverGatewaysForInterface(name string) (ips []net.IP, err error) {
+	return discoverGatewaysForInterface(name, false)
+}
+
+// DiscoverGatewaysIPv6ForInterface is DiscoverGatewaysForInterface for
+// IPv6. There is no lease to fall back to.
+func DiscoverGatewaysIPv6ForInterface(name string) (ips []net.IP, err error) {
+	return discoverGatewaysForInterface(name, true)
+}
+
+func discoverGatewaysForInterface(name string, ipv6 bool) ([]net.IP, error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err == nil {
+		if policy, err := discoverPolicyRoutesOSSpecific(); err == nil && len(policy) > 0 {
+			s = cloneSnapshot(s)
+			s.Routes = append(s.Routes, policy...)
+		}
+		if ips, gwErr := s.GatewaysForInterface(name, ipv6); gwErr == nil {
+			return ips, nil
+		}
+	}
+	if ipv6 {
+		return nil, &ErrNoGateway{}
+	}
+
+	leases, leaseErr := NewLeaseDiscoverer().currentLeases()
+	if leaseErr == nil {
+		if ips := leaseGatewaysForInterface(leases, name); len(ips) > 0 {
+			return ips, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, &ErrNoGateway{}
+}
+
+// GatewaysForInterface returns the gateways of the default routes of the
+// given family through the named interface, the same answer
+// DiscoverGatewaysForInterface gives from routes. Default routes of other
+// tables than the main one count, unlike for Gateways.
+func (s Snapshot) GatewaysForInterface(name string, ipv6 bool) (ips []net.IP, err error) {
+	// Routes of the main table come first, then those scoped to the
+	// interface, then those of the other tables.
+	rank := func(r Route) int {
+		switch {
+		case slices.Contains(r.Flags, ifscopeFlag):
+			return 1
+		case r.forwards():
+			return 0
+		default:
+			return 2
+		}
+	}
+	var routes []Route
+	for _, r := range s.Routes {
+		if r.IsDefault() && r.Is6() == ipv6 && (r.Type == "" || r.Type == "unicast") {
+			routes = append(routes, r)
+		}
+	}
+	slices.SortStableFunc(routes, func(a, b Route) int {
+		if c := rank(a) - rank(b); c != 0 {
+			return c
+		}
+		return a.EffectiveMetric() - b.EffectiveMetric()
+	})
+
+	seen := make(map[string]bool)
+	var result []net.IP
+	add := func(gateway net.IP) {
+		if key := gateway.String(); !seen[key] {
+			seen[key] = true
+			result = append(result, gateway)
+		}
+	}
+	for _, r := range routes {
+		if len(r.Nexthops) == 0 {
+			if r.Interface == name && r.Gateway.IsValid() {
+				add(addrToIP(r.Gateway))
+			}
+			continue
+		}
+		for _, nh := range r.Nexthops {
+			if nh.Interface == name && nh.Gateway.IsValid() {
+				add(addrToIP(nh.Gateway))
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, &ErrNoGateway{}
+	}
+	return result, nil
+}
+
+// leaseGatewaysForInterface returns the routers of the leases of the named
+// interface. leases are the current ones, one per interface.
+func leaseGatewaysForInterface(leases []Lease, name string) []net.IP {
+	var result []net.IP
+	for _, l := range leases {
+		if l.Interface != name {
+			continue
+		}
+		for _, router := range l.Routers {
+			result = append(result, addrToIP(router))
+		}
+	}
+	return result
+}
+
+// mainRoutes returns the routes of routes that are in the main table, or
+// whose table isn't known.
+func mainRoutes(routes []Route) []Route {
+	var result []Route
+	for _, r := range routes {
+		if r.Table == "" || r.Table == "main" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// policyRoutes returns the routes of routes that aren't in the main
+// table.
+func policyRoutes(routes []Route) []Route {
+	var result []Route
+	for _, r := range routes {
+		if r.Table != "" && r.Table != "main" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
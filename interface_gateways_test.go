@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestGatewaysForInterface(t *testing.T) {
+	linux, err := parseIPRoute(routeTables[linuxIPRouteTableAll], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	darwin, err := parseNetstatRoutes(routeTables[darwinScoped])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	multipath := []Route{{
+		Destination: netip.MustParsePrefix("0.0.0.0/0"),
+		Gateway:     netip.MustParseAddr("10.0.0.1"),
+		Interface:   "eth0",
+		Nexthops: []Nexthop{
+			{Gateway: netip.MustParseAddr("10.0.0.1"), Interface: "eth0"},
+			{Gateway: netip.MustParseAddr("10.0.1.1"), Interface: "eth1"},
+		},
+	}}
+
+	testcases := []struct {
+		name   string
+		routes []Route
+		iface  string
+		ipv6   bool
+		want   []string
+	}{
+		{"main table", linux, "eth0", false, []string{"192.168.1.1"}},
+		{"named table", linux, "eth1", false, []string{"10.10.0.1"}},
+		{"tables by metric", linux, "eth2", false, []string{"10.20.0.254", "10.20.0.1"}},
+		{"no route", linux, "eth3", false, nil},
+		{"no IPv6 route", linux, "eth0", true, nil},
+		{"darwin primary", darwin, "en0", false, []string{"192.168.1.1"}},
+		{"darwin scoped", darwin, "en7", false, []string{"10.0.0.1"}},
+		{"darwin scoped IPv6", darwin, "en7", true, []string{"fe80::aa:1"}},
+		{"multipath", multipath, "eth1", false, []string{"10.0.1.1"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ips, err := Snapshot{Routes: tc.routes}.GatewaysForInterface(tc.iface, tc.ipv6)
+			if tc.want == nil {
+				if !errors.Is(err, &ErrNoGateway{}) {
+					t.Errorf("Expected ErrNoGateway, got %v, %v", ips, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := ipStrings(ips); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestScopedDefaultRoutes(t *testing.T) {
+	routes, err := parseNetstatRoutes(routeTables[darwinScoped])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The routes scoped to en7 only count for its own traffic.
+	s := Snapshot{Routes: routes}
+	if ips, err := s.Gateways(); err != nil || !reflect.DeepEqual(ipStrings(ips), []string{"192.168.1.1"}) {
+		t.Errorf("Unexpected gateways %v, %v", ips, err)
+	}
+	if ips, err := s.GatewaysIPv6(); err != nil || !reflect.DeepEqual(ipStrings(ips), []string{"fe80::1"}) {
+		t.Errorf("Unexpected IPv6 gateways %v, %v", ips, err)
+	}
+}
+
+func TestPolicyRoutes(t *testing.T) {
+	routes, err := parseIPRoute(routeTables[linuxIPRouteTableAll], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var tables []string
+	for _, r := range policyRoutes(routes) {
+		tables = append(tables, r.Table)
+	}
+	want := []string{"mgmt", "200", "201", "300", "local", "local"}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("Expected tables %v, got %v", want, tables)
+	}
+}
+
+func TestLeaseGatewaysForInterface(t *testing.T) {
+	leases := []Lease{
+		{Interface: "eth0", Routers: []netip.Addr{netip.MustParseAddr("192.168.1.1")}},
+		{Interface: "eth1", Routers: []netip.Addr{netip.MustParseAddr("10.10.0.1"), netip.MustParseAddr("10.10.0.2")}},
+	}
+	if got := ipStrings(leaseGatewaysForInterface(leases, "eth1")); !reflect.DeepEqual(got, []string{"10.10.0.1", "10.10.0.2"}) {
+		t.Errorf("Unexpected gateways %v", got)
+	}
+	if got := leaseGatewaysForInterface(leases, "eth2"); got != nil {
+		t.Errorf("Expected no gateways, got %v", got)
+	}
+}
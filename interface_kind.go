@@ -0,0 +1,215 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// InterfaceKind classifies network interfaces by the kind of link they
+// provide.
+type InterfaceKind int
+
+const (
+	// InterfaceKindUnknown is an interface that couldn't be classified.
+	InterfaceKindUnknown InterfaceKind = iota
+
+	// InterfaceKindPhysical is a wired network adapter.
+	InterfaceKindPhysical
+
+	// InterfaceKindWireless is a Wi-Fi adapter.
+	InterfaceKindWireless
+
+	// InterfaceKindTunnel is a tunnel, usually of a VPN: tun and tap
+	// devices, WireGuard, PPP, IPsec and IP-in-IP tunnels.
+	InterfaceKindTunnel
+
+	// InterfaceKindBridge is a software bridge.
+	InterfaceKindBridge
+
+	// InterfaceKindVirtual is another software interface, such as the
+	// veth pair of a container or the adapter of a virtual machine host.
+	InterfaceKindVirtual
+
+	// InterfaceKindLoopback is the loopback interface.
+	InterfaceKindLoopback
+)
+
+var interfaceKindNames = [...]string{
+	InterfaceKindUnknown:  "unknown",
+	InterfaceKindPhysical: "physical",
+	InterfaceKindWireless: "wireless",
+	InterfaceKindTunnel:   "tunnel",
+	InterfaceKindBridge:   "bridge",
+	InterfaceKindVirtual:  "virtual",
+	InterfaceKindLoopback: "loopback",
+}
+
+func (k InterfaceKind) String() string {
+	if k < 0 || int(k) >= len(interfaceKindNames) {
+		return "InterfaceKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return interfaceKindNames[k]
+}
+
+// interfaceKindPatterns classify interfaces by name and description, in
+// order. Names are matched by prefix, descriptions by substring, both
+// ignoring case. Windows' adapter aliases, such as "Ethernet 2" or
+// "vEthernet (WSL)", are free text and matched like descriptions.
+var interfaceKindPatterns = []struct {
+	kind         InterfaceKind
+	prefixes     []string
+	descriptions []string
+}{
+	{
+		InterfaceKindLoopback,
+		[]string{"lo"},
+		[]string{"loopback"},
+	},
+	{
+		InterfaceKindTunnel,
+		[]string{
+			"tun", "tap", "wg", "utun", "ppp", "ipsec", "tailscale", "zt",
+			"gif", "stf", "gre", "ip6tnl", "sit", "vti", "nordlynx",
+		},
+		[]string{
+			"tap-windows", "wireguard", "wintun", "openvpn", "vpn", "tunnel",
+			"isatap", "teredo", "ppp", "tailscale", "zerotier",
+		},
+	},
+	{
+		InterfaceKindWireless,
+		[]string{"wl", "ath", "wifi"},
+		[]string{"wi-fi", "wifi", "wireless", "802.11", "wlan"},
+	},
+	{
+		InterfaceKindBridge,
+		[]string{"br", "virbr"},
+		[]string{"bridge"},
+	},
+	{
+		InterfaceKindVirtual,
+		[]string{"veth", "docker", "vmnet", "vboxnet", "cni", "flannel", "cali", "vnet", "dummy"},
+		[]string{"virtual", "vethernet", "hyper-v", "virtualbox", "vmware", "docker"},
+	},
+	{
+		InterfaceKindPhysical,
+		[]string{"eth", "en", "em", "ena", "igb", "ix", "re", "bge"},
+		[]string{"ethernet", "gigabit", "network connection", "local area connection"},
+	},
+}
+
+// classifyInterfaceName guesses the kind of an interface from its name
+// and, on Windows, the description of its adapter. It is used where the
+// OS doesn't say.
+func classifyInterfaceName(name, description string) InterfaceKind {
+	lowerName := strings.ToLower(name)
+	lowerDescription := strings.ToLower(description)
+	for _, p := range interfaceKindPatterns {
+		for _, prefix := range p.prefixes {
+			if strings.HasPrefix(lowerName, prefix) && !strings.ContainsRune(lowerName, ' ') {
+				return p.kind
+			}
+		}
+		for _, d := range p.descriptions {
+			if strings.Contains(lowerDescription, d) || strings.Contains(lowerName, d) {
+				return p.kind
+			}
+		}
+	}
+	return InterfaceKindUnknown
+}
+
+// Link types of /sys/class/net/<if>/type (ARPHRD_* of
+// linux/if_arp.h) that are tunnels.
+var linuxTunnelTypes = map[int]bool{
+	512:   true, // ARPHRD_PPP
+	768:   true, // ARPHRD_TUNNEL (ipip)
+	769:   true, // ARPHRD_TUNNEL6
+	776:   true, // ARPHRD_SIT
+	778:   true, // ARPHRD_IPGRE
+	823:   true, // ARPHRD_IP6GRE
+	65534: true, // ARPHRD_NONE (tun, WireGuard)
+}
+
+const linuxLoopbackType = 772 // ARPHRD_LOOPBACK
+
+// classifyLinuxInterface classifies an interface by its entries in
+// sysfs, where sys is /sys/class/net. Interfaces backed by hardware have a
+// device link; software interfaces without a more specific kind, such as
+// veth pairs and VLANs, are virtual.
+func classifyLinuxInterface(sys fs.FS, name string) InterfaceKind {
+	data, err := fs.ReadFile(sys, path.Join(name, "type"))
+	if err != nil {
+		return InterfaceKindUnknown
+	}
+	linkType, err := strconv.Atoi(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return InterfaceKindUnknown
+	}
+
+	var devType string
+	if data, err := fs.ReadFile(sys, path.Join(name, "uevent")); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(scanner.Text(), "DEVTYPE="); ok {
+				devType = value
+			}
+		}
+	}
+	exists := func(file string) bool {
+		_, err := fs.Stat(sys, path.Join(name, file))
+		return err == nil
+	}
+
+	switch {
+	case linkType == linuxLoopbackType:
+		return InterfaceKindLoopback
+	case devType == "wlan" || exists("wireless") || exists("phy80211"):
+		return InterfaceKindWireless
+	case devType == "bridge" || exists("bridge"):
+		return InterfaceKindBridge
+	case linuxTunnelTypes[linkType] || devType == "wireguard" || exists("tun_flags"):
+		return InterfaceKindTunnel
+	case exists("device"):
+		return InterfaceKindPhysical
+	default:
+		return InterfaceKindVirtual
+	}
+}
+
+// annotateLinuxRoutes sets the Kind and LinkState of the routes from
+// sysfs, where sys is /sys/class/net, and the netlink flags of the
+// interfaces by name, which take precedence over sysfs for the state.
+func annotateLinuxRoutes(routes []Route, sys fs.FS, linkFlags map[string]uint32) {
+	type annotation struct {
+		kind  InterfaceKind
+		state LinkState
+	}
+	annotations := make(map[string]annotation)
+	for i := range routes {
+		r := &routes[i]
+		if r.Interface == "" {
+			continue
+		}
+		a, ok := annotations[r.Interface]
+		if !ok {
+			a.kind = classifyLinuxInterface(sys, r.Interface)
+			if flags, ok := linkFlags[r.Interface]; ok {
+				a.state = linuxFlagsLinkState(flags)
+			} else {
+				a.state = linuxSysfsLinkState(sys, r.Interface)
+			}
+			annotations[r.Interface] = a
+		}
+		if r.Kind == InterfaceKindUnknown {
+			r.Kind = a.kind
+		}
+		if r.LinkState == LinkStateUnknown {
+			r.LinkState = a.state
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClassifyInterfaceName(t *testing.T) {
+	testcases := []struct {
+		name, description string
+		want              InterfaceKind
+	}{
+		{"lo", "", InterfaceKindLoopback},
+		{"lo0", "", InterfaceKindLoopback},
+		{"Loopback Pseudo-Interface 1", "", InterfaceKindLoopback},
+		{"eth0", "", InterfaceKindPhysical},
+		{"enp3s0", "", InterfaceKindPhysical},
+		{"Ethernet 2", "Intel(R) Ethernet Connection I219-LM", InterfaceKindPhysical},
+		{"wlp4s0", "", InterfaceKindWireless},
+		{"Wi-Fi", "", InterfaceKindWireless},
+		{"tun0", "", InterfaceKindTunnel},
+		{"wg0", "", InterfaceKindTunnel},
+		{"utun3", "", InterfaceKindTunnel},
+		{"ppp0", "", InterfaceKindTunnel},
+		{"tailscale0", "", InterfaceKindTunnel},
+		{"Ethernet 3", "TAP-Windows Adapter V9", InterfaceKindTunnel},
+		{"OpenVPN Wintun", "", InterfaceKindTunnel},
+		{"br-lan", "", InterfaceKindBridge},
+		{"virbr0", "", InterfaceKindBridge},
+		{"docker0", "", InterfaceKindVirtual},
+		{"veth1a2b3c", "", InterfaceKindVirtual},
+		{"vEthernet (WSL)", "Hyper-V Virtual Ethernet Adapter", InterfaceKindVirtual},
+		{"", "", InterfaceKindUnknown},
+		{"xyz0", "", InterfaceKindUnknown},
+	}
+	for _, tc := range testcases {
+		if got := classifyInterfaceName(tc.name, tc.description); got != tc.want {
+			t.Errorf("classifyInterfaceName(%q, %q) = %v, want %v", tc.name, tc.description, got, tc.want)
+		}
+	}
+
+	if s := InterfaceKindTunnel.String(); s != "tunnel" {
+		t.Errorf("Unexpected name %q", s)
+	}
+	if s := InterfaceKind(42).String(); s != "InterfaceKind(42)" {
+		t.Errorf("Unexpected name %q", s)
+	}
+}
+
+// sysClassNetFS is a /sys/class/net with one interface of each kind.
+var sysClassNetFS = fstest.MapFS{
+	"lo/type":                  {Data: []byte("772\n")},
+	"eth0/type":                {Data: []byte("1\n")},
+	"eth0/uevent":              {Data: []byte("INTERFACE=eth0\nIFINDEX=2\n")},
+	"eth0/device/vendor":       {Data: []byte("0x8086\n")},
+	"wlan0/type":               {Data: []byte("1\n")},
+	"wlan0/uevent":             {Data: []byte("DEVTYPE=wlan\nINTERFACE=wlan0\nIFINDEX=3\n")},
+	"wlan0/device/vendor":      {Data: []byte("0x8086\n")},
+	"tun0/type":                {Data: []byte("65534\n")},
+	"tun0/tun_flags":           {Data: []byte("0x1001\n")},
+	"tap0/type":                {Data: []byte("1\n")},
+	"tap0/tun_flags":           {Data: []byte("0x1002\n")},
+	"wg0/type":                 {Data: []byte("65534\n")},
+	"wg0/uevent":               {Data: []byte("DEVTYPE=wireguard\nINTERFACE=wg0\n")},
+	"ppp0/type":                {Data: []byte("512\n")},
+	"docker0/type":             {Data: []byte("1\n")},
+	"docker0/uevent":           {Data: []byte("DEVTYPE=bridge\nINTERFACE=docker0\n")},
+	"docker0/bridge/stp_state": {Data: []byte("0\n")},
+	"veth1a2b3c/type":          {Data: []byte("1\n")},
+	// Names don't matter: a renamed wired adapter.
+	"uplink/type":          {Data: []byte("1\n")},
+	"uplink/device/vendor": {Data: []byte("0x10ec\n")},
+}
+
+func TestClassifyLinuxInterface(t *testing.T) {
+	testcases := map[string]InterfaceKind{
+		"lo":         InterfaceKindLoopback,
+		"eth0":       InterfaceKindPhysical,
+		"wlan0":      InterfaceKindWireless,
+		"tun0":       InterfaceKindTunnel,
+		"tap0":       InterfaceKindTunnel,
+		"wg0":        InterfaceKindTunnel,
+		"ppp0":       InterfaceKindTunnel,
+		"docker0":    InterfaceKindBridge,
+		"veth1a2b3c": InterfaceKindVirtual,
+		"uplink":     InterfaceKindPhysical,
+		"missing0":   InterfaceKindUnknown,
+	}
+	for name, want := range testcases {
+		if got := classifyLinuxInterface(sysClassNetFS, name); got != want {
+			t.Errorf("classifyLinuxInterface(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPhysicalGateway(t *testing.T) {
+	routes, err := ParseIPRoute([]byte(`default via 10.8.0.5 dev tun0 metric 50
+default via 192.168.1.1 dev uplink proto dhcp metric 100
+10.8.0.0/24 dev tun0 proto kernel scope link src 10.8.0.6
+192.168.1.0/24 dev uplink proto kernel scope link src 192.168.1.10 metric 100
+`), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	annotateLinuxRoutes(routes, sysClassNetFS, nil)
+
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+	s := newSnapshot(routes, mockGetter)
+
+	if s.Routes[0].Kind != InterfaceKindTunnel || s.Routes[1].Kind != InterfaceKindPhysical {
+		t.Errorf("Unexpected kinds %v %v", s.Routes[0].Kind, s.Routes[1].Kind)
+	}
+	if ip, err := s.PhysicalGateway(false); err != nil || ip.String() != "192.168.1.1" {
+		t.Errorf("Unexpected physical gateway %v, %v", ip, err)
+	}
+	if ips, _ := s.Gateways(); ips[0].String() != "10.8.0.5" {
+		t.Errorf("Unexpected gateways %v", ips)
+	}
+	if _, err := s.PhysicalGateway(true); !errors.Is(err, &ErrNoGateway{}) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+
+	// Elsewhere the names decide.
+	s = newSnapshot([]Route{
+		{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("10.8.0.5"), Interface: "utun3"},
+		{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.254"), Interface: "en0", Metric: 1},
+	}, mockGetter)
+	if ip, err := s.PhysicalGateway(false); err != nil || ip.String() != "192.168.1.254" {
+		t.Errorf("Unexpected physical gateway %v, %v", ip, err)
+	}
+}
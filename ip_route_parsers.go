@@ -0,0 +1,287 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ipRouteEntry is a route of iproute2's ip route. The JSON tags are those
+// of ip -json route; the text parser fills in the same fields.
+type ipRouteEntry struct {
+	Type     string           `json:"type"`
+	Dst      string           `json:"dst"`
+	Gateway  string           `json:"gateway"`
+	Via      *ipRouteVia      `json:"via"`
+	Dev      string           `json:"dev"`
+	Protocol string           `json:"protocol"`
+	Prefsrc  string           `json:"prefsrc"`
+	Metric   uint32           `json:"metric"`
+	Table    string           `json:"table"`
+	Flags    []string         `json:"flags"`
+	Nexthops []ipNexthopEntry `json:"nexthops"`
+}
+
+// ipRouteVia is a gateway of another address family, such as the IPv6
+// gateway of an IPv4 route ("via inet6 fe80::1").
+type ipRouteVia struct {
+	Family string `json:"family"`
+	Host   string `json:"host"`
+}
+
+type ipNexthopEntry struct {
+	Gateway string      `json:"gateway"`
+	Via     *ipRouteVia `json:"via"`
+	Dev     string      `json:"dev"`
+	Weight  int         `json:"weight"`
+	Flags   []string    `json:"flags"`
+}
+
+// ipRouteTypes are the route types that ip route prints before the
+// destination. Unicast routes are printed without one.
+var ipRouteTypes = map[string]bool{
+	"unicast": true, "local": true, "broadcast": true, "multicast": true,
+	"throw": true, "unreachable": true, "prohibit": true, "blackhole": true,
+	"nat": true, "anycast": true,
+}
+
+// ipRouteFlags are the words of ip route that stand alone; all other
+// attributes are followed by a value.
+var ipRouteFlags = map[string]bool{
+	"dead": true, "onlink": true, "pervasive": true, "offload": true,
+	"notify": true, "linkdown": true, "unresolved": true, "trap": true,
+	"rt_offload": true, "rt_trap": true, "rt_offload_failed": true,
+}
+
+// ParseIPRoute parses the output of iproute2's ip route, as text or as
+// JSON (ip -json route), for example from a support bundle holding
+// "ip route show table all". Default routes that name no address are IPv6
+// routes if ipv6 is set, as for ip -6 route.
+func ParseIPRoute(output []byte, ipv6 bool) ([]Route, error) {
+	return parseIPRoute(output, ipv6)
+}
+
+func parseIPRoute(output []byte, ipv6 bool) ([]Route, error) {
+	var entries []ipRouteEntry
+	if trimmed := bytes.TrimSpace(output); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, &ErrCantParse{}
+		}
+	} else {
+		var ok bool
+		if entries, ok = parseIPRouteText(output); !ok {
+			return nil, &ErrCantParse{}
+		}
+	}
+
+	result := make([]Route, 0, len(entries))
+	for _, e := range entries {
+		r, ok := e.route(ipv6)
+		if !ok {
+			return nil, &ErrCantParse{}
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// parseIPRouteText parses the text output of ip route:
+//
+//	default via 192.168.1.1 dev eth0 proto dhcp src 192.168.1.10 metric 100
+//	192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.10 metric 100
+//	local 192.168.1.10 dev eth0 table local proto kernel scope host src 192.168.1.10
+//	default proto static metric 1024
+//		nexthop via 10.0.0.1 dev eth0 weight 1
+//		nexthop via 10.0.1.1 dev eth1 weight 1 dead linkdown
+//
+// It reports false if a line isn't a route.
+func parseIPRouteText(output []byte) ([]ipRouteEntry, bool) {
+	var entries []ipRouteEntry
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "nexthop" {
+			if len(entries) == 0 {
+				return nil, false
+			}
+			var nh ipNexthopEntry
+			parseIPRouteAttributes(fields[1:], func(key, value string) {
+				switch key {
+				case "via":
+					nh.Gateway = value
+				case "via inet", "via inet6":
+					nh.Via = &ipRouteVia{Family: strings.TrimPrefix(key, "via "), Host: value}
+				case "dev":
+					nh.Dev = value
+				case "weight":
+					nh.Weight, _ = strconv.Atoi(value)
+				case "":
+					nh.Flags = append(nh.Flags, value)
+				}
+			})
+			e := &entries[len(entries)-1]
+			e.Nexthops = append(e.Nexthops, nh)
+			continue
+		}
+
+		var e ipRouteEntry
+		if ipRouteTypes[fields[0]] {
+			e.Type, fields = fields[0], fields[1:]
+		}
+		if len(fields) == 0 {
+			return nil, false
+		}
+		e.Dst = fields[0]
+		parseIPRouteAttributes(fields[1:], func(key, value string) {
+			switch key {
+			case "via":
+				e.Gateway = value
+			case "via inet", "via inet6":
+				e.Via = &ipRouteVia{Family: strings.TrimPrefix(key, "via "), Host: value}
+			case "dev":
+				e.Dev = value
+			case "proto":
+				e.Protocol = value
+			case "src":
+				e.Prefsrc = value
+			case "metric":
+				metric, _ := strconv.ParseUint(value, 10, 32)
+				e.Metric = uint32(metric)
+			case "table":
+				e.Table = value
+			case "":
+				e.Flags = append(e.Flags, value)
+			}
+		})
+		entries = append(entries, e)
+	}
+	return entries, true
+}
+
+// parseIPRouteAttributes calls fn for each attribute of a route or next
+// hop. Flags are passed as values with an empty key, and gateways of
+// another address family ("via inet6 fe80::1") with the key "via inet6".
+func parseIPRouteAttributes(fields []string, fn func(key, value string)) {
+	for i := 0; i < len(fields); i++ {
+		key := fields[i]
+		if ipRouteFlags[key] || i+1 == len(fields) {
+			fn("", key)
+			continue
+		}
+		i++
+		switch {
+		case key == "via" && (fields[i] == "inet" || fields[i] == "inet6") && i+1 < len(fields):
+			key += " " + fields[i]
+			i++
+		case fields[i] == "lock" && i+1 < len(fields):
+			// A locked metric: "mtu lock 1400".
+			i++
+		}
+		fn(key, fields[i])
+	}
+}
+
+// route converts e to a Route.
+func (e ipRouteEntry) route(ipv6 bool) (Route, bool) {
+	gateway, ok := ipRouteGateway(e.Gateway, e.Via, e.Dev)
+	if !ok {
+		return Route{}, false
+	}
+	r := Route{
+		Gateway:   gateway,
+		Interface: e.Dev,
+		Metric:    int(e.Metric),
+		Protocol:  e.Protocol,
+		Table:     e.Table,
+		Type:      e.Type,
+	}
+	if len(e.Flags) > 0 {
+		r.Flags = e.Flags
+	}
+	if r.Table == "" {
+		r.Table = "main"
+	}
+	if r.Type == "" {
+		r.Type = "unicast"
+	}
+	if src, err := netip.ParseAddr(e.Prefsrc); err == nil {
+		r.Source = src
+	}
+
+	for _, nh := range e.Nexthops {
+		gateway, ok := ipRouteGateway(nh.Gateway, nh.Via, nh.Dev)
+		if !ok {
+			return Route{}, false
+		}
+		n := Nexthop{
+			Gateway:   gateway,
+			Interface: nh.Dev,
+			Weight:    nh.Weight,
+		}
+		if len(nh.Flags) > 0 {
+			n.Flags = nh.Flags
+		}
+		r.Nexthops = append(r.Nexthops, n)
+	}
+	via := e.Via
+	if len(r.Nexthops) > 0 && !r.Gateway.IsValid() && r.Interface == "" {
+		r.Gateway, r.Interface = r.Nexthops[0].Gateway, r.Nexthops[0].Interface
+		via = e.Nexthops[0].Via
+	}
+
+	// The family of a default route is that of its addresses, if any. A
+	// gateway given with its family may be of another one than the route.
+	switch {
+	case e.Dst != "default":
+	case r.Gateway.IsValid() && via == nil:
+		ipv6 = r.Gateway.Is6()
+	case r.Source.IsValid():
+		ipv6 = r.Source.Is6()
+	}
+	dst, ok := ipRouteDestination(e.Dst, ipv6)
+	if !ok {
+		return Route{}, false
+	}
+	r.Destination = dst
+	return r, true
+}
+
+// ipRouteGateway parses the gateway of a route or next hop, zoning
+// link-local addresses with the interface.
+func ipRouteGateway(gateway string, via *ipRouteVia, dev string) (netip.Addr, bool) {
+	if gateway == "" && via != nil {
+		gateway = via.Host
+	}
+	if gateway == "" {
+		return netip.Addr{}, true
+	}
+	addr, err := netip.ParseAddr(gateway)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return withZone(addr, dev), true
+}
+
+// ipRouteDestination parses the destination of a route: "default", a
+// prefix, or an address for a host route.
+func ipRouteDestination(dst string, ipv6 bool) (netip.Prefix, bool) {
+	if dst == "default" {
+		if ipv6 {
+			return netip.PrefixFrom(netip.IPv6Unspecified(), 0), true
+		}
+		return netip.PrefixFrom(netip.IPv4Unspecified(), 0), true
+	}
+	if prefix, err := netip.ParsePrefix(dst); err == nil {
+		return prefix, true
+	}
+	addr, err := netip.ParseAddr(dst)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
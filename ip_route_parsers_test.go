@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseIPRoute(t *testing.T) {
+	text, err := ParseIPRoute(routeTables[linuxIPRouteText], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(text) != 13 {
+		t.Errorf("Expected 13 routes, got %d", len(text))
+	}
+
+	// The JSON output holds the same routes.
+	json, err := ParseIPRoute(routeTables[linuxIPRouteJSON], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(text, json) {
+		t.Errorf("Text and JSON routes differ:\n%+v\n%+v", text, json)
+	}
+
+	want := []Route{
+		{
+			Destination: netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:     netip.MustParseAddr("192.168.1.1"),
+			Interface:   "eth0",
+			Source:      netip.MustParseAddr("192.168.1.10"),
+			Metric:      100,
+			Protocol:    "dhcp",
+			Table:       "main",
+			Type:        "unicast",
+		},
+		{
+			Destination: netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:     netip.MustParseAddr("10.0.0.1"),
+			Interface:   "wlan0",
+			Source:      netip.MustParseAddr("10.0.0.23"),
+			Metric:      600,
+			Protocol:    "dhcp",
+			Table:       "main",
+			Type:        "unicast",
+			Flags:       []string{"linkdown"},
+		},
+		{
+			Destination: netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:     netip.MustParseAddr("172.16.5.1"),
+			Interface:   "eth1",
+			Protocol:    "static",
+			Table:       "100",
+			Type:        "unicast",
+		},
+	}
+	if got := defaultRoutes(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected default routes\n%+v !=\n%+v", got, want)
+	}
+
+	multipath := Route{
+		Destination: netip.MustParsePrefix("10.9.0.0/16"),
+		Gateway:     netip.MustParseAddr("192.168.1.254"),
+		Interface:   "eth0",
+		Metric:      20,
+		Protocol:    "static",
+		Table:       "main",
+		Type:        "unicast",
+		Nexthops: []Nexthop{
+			{Gateway: netip.MustParseAddr("192.168.1.254"), Interface: "eth0", Weight: 1},
+			{Gateway: netip.MustParseAddr("10.0.0.254"), Interface: "wlan0", Weight: 2, Flags: []string{"dead", "linkdown"}},
+		},
+	}
+	if !reflect.DeepEqual(text[4], multipath) {
+		t.Errorf("Unexpected multipath route %+v", text[4])
+	}
+
+	// An IPv4 route through an IPv6 next hop.
+	if r := text[7]; r.Destination != netip.MustParsePrefix("192.168.2.0/24") || r.Gateway != netip.MustParseAddr("fe80::1%eth0") {
+		t.Errorf("Unexpected route %+v", r)
+	}
+	if r := text[8]; r.Type != "blackhole" || r.Gateway.IsValid() {
+		t.Errorf("Unexpected blackhole route %+v", r)
+	}
+	if r := text[10]; r.Destination != netip.MustParsePrefix("127.0.0.1/32") || r.Table != "local" || r.Type != "local" {
+		t.Errorf("Unexpected local route %+v", r)
+	}
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := ParseIPRoute(routeTables[randomData], false); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestParseIPRouteIPv6(t *testing.T) {
+	text, err := ParseIPRoute(routeTables[linuxIPRoute6Text], true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	json, err := ParseIPRoute(routeTables[linuxIPRoute6JSON], true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(text, json) {
+		t.Errorf("Text and JSON routes differ:\n%+v\n%+v", text, json)
+	}
+	if len(text) != 7 {
+		t.Fatalf("Expected 7 routes, got %d", len(text))
+	}
+
+	if r := text[4]; !r.IsDefault() || !r.Is6() || r.Type != "unreachable" || r.Metric != 4294967295 {
+		t.Errorf("Unexpected unreachable route %+v", r)
+	}
+
+	// Only the unicast default routes of the main table are used, and
+	// every next hop of a multipath route is a gateway.
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+	s := newSnapshot(text, mockGetter)
+
+	want := []string{"fe80::1%eth0", "fe80::2%eth0", "fe80::a%wlan0"}
+	addrs, err := s.gatewayAddrsIPv6()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(addrs) != len(want) {
+		t.Fatalf("Unexpected gateways %v", addrs)
+	}
+	for i := range addrs {
+		if addrs[i].String() != want[i] {
+			t.Errorf("Unexpected gateway %v != %s", addrs[i], want[i])
+		}
+	}
+}
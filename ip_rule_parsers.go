@@ -0,0 +1,265 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// PolicyRule is a rule of Linux policy routing, as listed by ip rule. The
+// rules are tried by priority; the first that matches a packet and whose
+// action decides it selects the routing table.
+type PolicyRule struct {
+	// Priority orders the rules, lowest first.
+	Priority int
+
+	// Not inverts the selectors.
+	Not bool
+
+	// From and To select packets by source and destination. The zero
+	// Prefix selects all.
+	From, To netip.Prefix
+
+	// IIF and OIF select packets by input interface ("lo" for locally
+	// generated ones) and by the interface the socket is bound to.
+	IIF, OIF string
+
+	// FWMark selects packets by firewall mark, as "0x1" or "0x1/0xff".
+	FWMark string
+
+	// L3mdev selects packets of sockets bound to a VRF, looking them up
+	// in the table of that VRF.
+	L3mdev bool
+
+	// Selectors holds the names of other selectors, such as "ipproto"
+	// or "uidrange".
+	Selectors []string
+
+	// Action is "lookup", "goto", "nop", "blackhole", "unreachable" or
+	// "prohibit".
+	Action string
+
+	// Table is the table looked up by the "lookup" action.
+	Table string
+
+	// Goto is the priority the "goto" action continues at.
+	Goto int
+
+	// SuppressPrefixLength rejects the result of the lookup if its
+	// prefix length is at most this, or is -1. WireGuard's wg-quick
+	// uses 0 to ignore the main table's default route.
+	SuppressPrefixLength int
+}
+
+// ipRuleFlags are the words of ip rule that stand alone; all other
+// attributes are followed by a value.
+var ipRuleFlags = map[string]bool{
+	"not": true, "l3mdev": true, "nop": true, "blackhole": true,
+	"unreachable": true, "prohibit": true, "[detached]": true,
+}
+
+// ipRuleAnnotations are attributes of ip rule that don't select packets.
+var ipRuleAnnotations = map[string]bool{
+	"proto": true, "protocol": true, "realms": true, "suppress_ifgroup": true,
+}
+
+// ipRuleEntry is a rule of ip -json rule.
+type ipRuleEntry struct {
+	Priority          int             `json:"priority"`
+	Not               json.RawMessage `json:"not"`
+	Src               string          `json:"src"`
+	SrcLen            *int            `json:"srclen"`
+	Dst               string          `json:"dst"`
+	DstLen            *int            `json:"dstlen"`
+	IIF               string          `json:"iif"`
+	OIF               string          `json:"oif"`
+	FWMark            string          `json:"fwmark"`
+	FWMask            string          `json:"fwmask"`
+	L3mdev            json.RawMessage `json:"l3mdev"`
+	Table             string          `json:"table"`
+	Action            string          `json:"action"`
+	Goto              *int            `json:"goto"`
+	SuppressPrefixLen *int            `json:"suppress_prefixlen"`
+}
+
+// ipRuleJSONSelectors are the keys of ip -json rule for selectors that
+// aren't evaluated.
+var ipRuleJSONSelectors = []string{
+	"tos", "dsfield", "ipproto", "sport", "dport", "uid_start", "tun_id", "flowlabel",
+}
+
+// ParseIPRule parses the output of iproute2's ip rule, as text or as JSON
+// (ip -json rule):
+//
+//	0:	from all lookup local
+//	100:	from 10.0.0.5 lookup tenant1
+//	32764:	from all lookup main suppress_prefixlength 0
+//	32765:	not from all fwmark 0xca6c lookup 51820
+//	32766:	from all lookup main
+func ParseIPRule(output []byte) ([]PolicyRule, error) {
+	if trimmed := bytes.TrimSpace(output); len(trimmed) > 0 && trimmed[0] == '[' {
+		return parseIPRuleJSON(trimmed)
+	}
+
+	var result []PolicyRule
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		priority, err := strconv.Atoi(strings.TrimSuffix(fields[0], ":"))
+		if err != nil || !strings.HasSuffix(fields[0], ":") {
+			return nil, &ErrCantParse{}
+		}
+		rule := PolicyRule{Priority: priority, SuppressPrefixLength: -1}
+		ok := true
+		fields = fields[1:]
+		for i := 0; i < len(fields); i++ {
+			key := fields[i]
+			if ipRuleFlags[key] {
+				switch key {
+				case "not":
+					rule.Not = true
+				case "l3mdev":
+					rule.L3mdev = true
+				case "[detached]":
+				default:
+					rule.Action = key
+				}
+				continue
+			}
+			if i+1 == len(fields) {
+				return nil, &ErrCantParse{}
+			}
+			i++
+			value := fields[i]
+			switch key {
+			case "from":
+				rule.From, ok = ipRulePrefix(value)
+			case "to":
+				rule.To, ok = ipRulePrefix(value)
+			case "iif":
+				rule.IIF = value
+			case "oif":
+				rule.OIF = value
+			case "fwmark":
+				rule.FWMark = value
+			case "lookup", "table":
+				rule.Action, rule.Table = "lookup", value
+			case "goto":
+				rule.Action = "goto"
+				rule.Goto, err = strconv.Atoi(value)
+				ok = err == nil
+			case "suppress_prefixlength":
+				rule.SuppressPrefixLength, err = strconv.Atoi(value)
+				ok = err == nil
+			default:
+				if !ipRuleAnnotations[key] {
+					rule.Selectors = append(rule.Selectors, key)
+				}
+			}
+			if !ok {
+				return nil, &ErrCantParse{}
+			}
+		}
+		result = append(result, rule.normalize())
+	}
+	return result, nil
+}
+
+func parseIPRuleJSON(output []byte) ([]PolicyRule, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, &ErrCantParse{}
+	}
+	result := make([]PolicyRule, 0, len(raw))
+	for _, r := range raw {
+		var e ipRuleEntry
+		var keys map[string]json.RawMessage
+		if json.Unmarshal(r, &e) != nil || json.Unmarshal(r, &keys) != nil {
+			return nil, &ErrCantParse{}
+		}
+		rule := PolicyRule{
+			Priority:             e.Priority,
+			Not:                  len(e.Not) > 0,
+			IIF:                  e.IIF,
+			OIF:                  e.OIF,
+			FWMark:               e.FWMark,
+			L3mdev:               len(e.L3mdev) > 0,
+			Action:               e.Action,
+			Table:                e.Table,
+			SuppressPrefixLength: -1,
+		}
+		if e.FWMask != "" {
+			rule.FWMark += "/" + e.FWMask
+		}
+		var ok bool
+		if rule.From, ok = ipRuleJSONPrefix(e.Src, e.SrcLen); !ok {
+			return nil, &ErrCantParse{}
+		}
+		if rule.To, ok = ipRuleJSONPrefix(e.Dst, e.DstLen); !ok {
+			return nil, &ErrCantParse{}
+		}
+		switch {
+		case e.Goto != nil:
+			rule.Action, rule.Goto = "goto", *e.Goto
+		case rule.Action == "" && rule.Table != "":
+			rule.Action = "lookup"
+		}
+		if e.SuppressPrefixLen != nil {
+			rule.SuppressPrefixLength = *e.SuppressPrefixLen
+		}
+		for _, key := range ipRuleJSONSelectors {
+			if _, ok := keys[key]; ok {
+				rule.Selectors = append(rule.Selectors, key)
+			}
+		}
+		result = append(result, rule.normalize())
+	}
+	return result, nil
+}
+
+// normalize marks rules of VRF tables, which ip rule prints as the
+// table "[l3mdev-table]".
+func (rule PolicyRule) normalize() PolicyRule {
+	if rule.Table == "[l3mdev-table]" {
+		rule.L3mdev = true
+	}
+	return rule
+}
+
+// ipRulePrefix parses a selector of ip rule: "all", a prefix, or an
+// address.
+func ipRulePrefix(value string) (netip.Prefix, bool) {
+	if value == "all" {
+		return netip.Prefix{}, true
+	}
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), true
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+// ipRuleJSONPrefix parses an address and prefix length of ip -json rule,
+// which leaves out the length of host addresses.
+func ipRuleJSONPrefix(addr string, bits *int) (netip.Prefix, bool) {
+	if addr == "" || addr == "all" {
+		return netip.Prefix{}, true
+	}
+	a, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	length := a.BitLen()
+	if bits != nil {
+		length = *bits
+	}
+	prefix := netip.PrefixFrom(a, length)
+	return prefix.Masked(), prefix.IsValid()
+}
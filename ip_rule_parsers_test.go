@@ -0,0 +1,63 @@
+package gateway
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestParseIPRule(t *testing.T) {
+	rules, err := ParseIPRule(routeTables[linuxIPRuleMultiTenant])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 12 {
+		t.Fatalf("Expected 12 rules, got %d: %+v", len(rules), rules)
+	}
+	want := map[int]PolicyRule{
+		0: {Priority: 0, Action: "lookup", Table: "local", SuppressPrefixLength: -1},
+		1: {Priority: 100, From: netip.MustParsePrefix("10.0.1.5/32"), Action: "lookup", Table: "tenant1", SuppressPrefixLength: -1},
+		3: {Priority: 150, To: netip.MustParsePrefix("198.51.100.0/24"), Action: "lookup", Table: "150", SuppressPrefixLength: -1},
+		4: {Priority: 200, From: netip.MustParsePrefix("10.0.3.5/32"), Action: "unreachable", SuppressPrefixLength: -1},
+		5: {Priority: 250, FWMark: "0x1", Action: "lookup", Table: "250", SuppressPrefixLength: -1},
+		6: {Priority: 260, Selectors: []string{"ipproto", "dport"}, Action: "lookup", Table: "250", SuppressPrefixLength: -1},
+		7: {Priority: 300, From: netip.MustParsePrefix("10.0.4.5/32"), Action: "goto", Goto: 32766, SuppressPrefixLength: -1},
+		9: {Priority: 1000, L3mdev: true, Action: "lookup", Table: "[l3mdev-table]", SuppressPrefixLength: -1},
+	}
+	for i, w := range want {
+		if !reflect.DeepEqual(rules[i], w) {
+			t.Errorf("Expected rule %d %+v, got %+v", i, w, rules[i])
+		}
+	}
+
+	rules, err = ParseIPRule(routeTables[linuxIPRuleWireGuard])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wantJSON := []PolicyRule{
+		{Priority: 0, Action: "lookup", Table: "local", SuppressPrefixLength: -1},
+		{Priority: 32764, Action: "lookup", Table: "main", SuppressPrefixLength: 0},
+		{Priority: 32765, Not: true, FWMark: "0xca6c", Action: "lookup", Table: "51820", SuppressPrefixLength: -1},
+		{Priority: 32766, Action: "lookup", Table: "main", SuppressPrefixLength: -1},
+		{Priority: 32767, Action: "lookup", Table: "default", SuppressPrefixLength: -1},
+	}
+	if !reflect.DeepEqual(rules, wantJSON) {
+		t.Errorf("Expected %+v, got %+v", wantJSON, rules)
+	}
+
+	json := `[{"priority":100,"src":"10.0.2.0","srclen":24,"dst":"2001:db8::","dstlen":32,"table":"102"},{"priority":200,"src":"all","action":"blackhole"}]`
+	rules, err = ParseIPRule([]byte(json))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rules[0].From != netip.MustParsePrefix("10.0.2.0/24") || rules[0].To != netip.MustParsePrefix("2001:db8::/32") || rules[1].Action != "blackhole" {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	for _, garbage := range []string{"from all lookup main", "100:\tfrom", "100:\tfrom nowhere lookup main", "[{"} {
+		if _, err := ParseIPRule([]byte(garbage)); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("%q: Expected ErrCantParse, got %v", garbage, err)
+		}
+	}
+}
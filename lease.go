@@ -0,0 +1,338 @@
+package gateway
+
+import (
+	"io/fs"
+	"net"
+	"net/netip"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lease is what a DHCP server told the host about a network, as recorded
+// by the DHCP client. It is known even before the routes are installed,
+// and in sandboxes that hide the routing table.
+type Lease struct {
+	// Interface is the name of the interface the lease is for, if known.
+	Interface string
+
+	// InterfaceIndex is the index of the interface, for clients that
+	// name their lease files by index (systemd-networkd), or zero.
+	InterfaceIndex int
+
+	// Address is the address leased to the host.
+	Address netip.Addr
+
+	// Subnet is the leased address with the length of the subnet mask,
+	// or the zero Prefix if the server sent no mask.
+	Subnet netip.Prefix
+
+	// Routers are the gateways, in order of preference.
+	Routers []netip.Addr
+
+	// DNS are the DNS servers.
+	DNS []netip.Addr
+
+	// Expiry is when the lease expires, or the zero Time if never or if
+	// unknown.
+	Expiry time.Time
+
+	// File is the path of the lease file.
+	File string
+}
+
+// Expired reports whether the lease had expired at the given time.
+func (l Lease) Expired(now time.Time) bool {
+	return !l.Expiry.IsZero() && !now.Before(l.Expiry)
+}
+
+// leaseFiles are the lease files of the DHCP clients, by glob relative to
+// the root directory, and their parsers.
+// Files whose interface may be followed by the SSID of a wireless network
+// are flagged ssid.
+var leaseFiles = []struct {
+	glob  string
+	parse func(name string, data []byte, modTime time.Time) ([]Lease, error)
+	ssid  bool
+}{
+	{"var/lib/dhcp/dhclient*.leases", parseDhclientLeaseFile, false},
+	{"var/lib/dhclient/dhclient*.leases", parseDhclientLeaseFile, false},
+	{"var/db/dhclient.leases.*", parseDhclientLeaseFile, false},
+	{"var/lib/dhcpcd/*.lease", parseDhcpcdLeaseFile, true},
+	{"var/lib/dhcpcd5/*.lease", parseDhcpcdLeaseFile, true},
+	{"var/db/dhcpcd/*.lease", parseDhcpcdLeaseFile, true},
+	{"run/systemd/netif/leases/*", parseNetworkdLeaseFile, false},
+	{"var/lib/NetworkManager/internal-*.lease", parseNetworkManagerLeaseFile, false},
+}
+
+func parseDhclientLeaseFile(name string, data []byte, modTime time.Time) ([]Lease, error) {
+	return ParseDhclientLeases(data)
+}
+
+// parseDhcpcdLeaseFile parses a lease of dhcpcd, which names the files
+// after the interface, followed by the SSID for wireless networks:
+// eth0.lease or wlan0-MyNet.lease. Leases finds the interface in the
+// name. DHCPv6 leases, eth0.lease6, hold no routers and aren't read.
+func parseDhcpcdLeaseFile(name string, data []byte, modTime time.Time) ([]Lease, error) {
+	lease, err := ParseDhcpcdLease(data, modTime)
+	if err != nil {
+		return nil, err
+	}
+	lease.Interface = strings.TrimSuffix(path.Base(name), ".lease")
+	return []Lease{lease}, nil
+}
+
+// parseNetworkdLeaseFile parses a lease of systemd-networkd, which names
+// the files after the interface index.
+func parseNetworkdLeaseFile(name string, data []byte, modTime time.Time) ([]Lease, error) {
+	index, err := strconv.Atoi(path.Base(name))
+	if err != nil {
+		return nil, &ErrCantParse{}
+	}
+	lease, err := ParseNetworkdLease(data, modTime)
+	if err != nil {
+		return nil, err
+	}
+	lease.InterfaceIndex = index
+	return []Lease{lease}, nil
+}
+
+// parseNetworkManagerLeaseFile parses a lease of NetworkManager's internal
+// DHCP client, which names the files after the connection's UUID and the
+// interface: internal-<uuid>-eth0.lease.
+func parseNetworkManagerLeaseFile(name string, data []byte, modTime time.Time) ([]Lease, error) {
+	const uuidLen = 36
+	lease, err := ParseNetworkdLease(data, modTime)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "internal-"), ".lease")
+	if len(base) > uuidLen+1 {
+		lease.Interface = base[uuidLen+1:]
+	}
+	return []Lease{lease}, nil
+}
+
+// LeaseDiscoverer is a Discoverer that answers from the lease files of the
+// DHCP clients rather than from the routing table: ISC dhclient, dhcpcd,
+// systemd-networkd and NetworkManager's internal client. It knows IPv4
+// gateways only.
+//
+// Use it on its own, or as the fallback of NewFallbackDiscoverer for
+// hosts whose routing table may be empty.
+type LeaseDiscoverer struct {
+	root        fs.FS
+	now         func() time.Time
+	ifaceGetter interfaceGetter
+}
+
+// NewLeaseDiscoverer returns a LeaseDiscoverer reading the lease files of
+// this host.
+func NewLeaseDiscoverer() *LeaseDiscoverer {
+	return newLeaseDiscoverer(os.DirFS("/"), &intefaceGetterImpl{})
+}
+
+func newLeaseDiscoverer(root fs.FS, ifaceGetter interfaceGetter) *LeaseDiscoverer {
+	return &LeaseDiscoverer{
+		root:        root,
+		now:         time.Now,
+		ifaceGetter: ifaceGetter,
+	}
+}
+
+// Leases returns the leases of all lease files found, including expired
+// ones, in the order the clients wrote them. Files that can't be parsed
+// are skipped.
+func (d *LeaseDiscoverer) Leases() ([]Lease, error) {
+	var result []Lease
+	for _, source := range leaseFiles {
+		names, err := fs.Glob(d.root, source.glob)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			info, err := fs.Stat(d.root, name)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			data, err := fs.ReadFile(d.root, name)
+			if err != nil {
+				continue
+			}
+			leases, err := source.parse(name, data, info.ModTime())
+			if err != nil {
+				continue
+			}
+			for _, l := range leases {
+				l.File = "/" + name
+				if source.ssid {
+					l.Interface = d.interfaceBeforeSSID(l.Interface)
+				}
+				if l.Interface == "" && l.InterfaceIndex != 0 {
+					if iface, err := d.ifaceGetter.InterfaceByIndex(l.InterfaceIndex); err == nil {
+						l.Interface = iface.Name
+					}
+				}
+				result = append(result, l)
+			}
+		}
+	}
+	return result, nil
+}
+
+// interfaceBeforeSSID returns the longest existing interface name that is
+// name or a prefix of name ending before a "-", which both interface
+// names and SSIDs may contain. Unknown names are returned as they are.
+func (d *LeaseDiscoverer) interfaceBeforeSSID(name string) string {
+	for prefix := name; ; {
+		if _, err := d.ifaceGetter.InterfaceByName(prefix); err == nil {
+			return prefix
+		}
+		i := strings.LastIndexByte(prefix, '-')
+		if i <= 0 {
+			return name
+		}
+		prefix = prefix[:i]
+	}
+}
+
+// currentLeases returns the unexpired leases with a router, the latest
+// one of each interface.
+func (d *LeaseDiscoverer) currentLeases() ([]Lease, error) {
+	leases, err := d.Leases()
+	if err != nil {
+		return nil, err
+	}
+	now := d.now()
+	var result []Lease
+	byInterface := make(map[string]int)
+	for _, l := range leases {
+		if l.Expired(now) || len(l.Routers) == 0 {
+			continue
+		}
+		if i, ok := byInterface[l.Interface]; ok && l.Interface != "" {
+			result[i] = l
+			continue
+		}
+		byInterface[l.Interface] = len(result)
+		result = append(result, l)
+	}
+	return result, nil
+}
+
+// DiscoverGateways returns the routers of the current leases.
+func (d *LeaseDiscoverer) DiscoverGateways() ([]net.IP, error) {
+	s, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.Gateways()
+}
+
+// DiscoverInterface returns the address of the current lease.
+func (d *LeaseDiscoverer) DiscoverInterface() (net.IP, error) {
+	s, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.Interface()
+}
+
+// DiscoverGatewaysIPv6 returns ErrNoGateway: IPv6 gateways are announced
+// by routers, not leased.
+func (d *LeaseDiscoverer) DiscoverGatewaysIPv6() ([]net.IP, error) {
+	return nil, &ErrNoGateway{}
+}
+
+// DiscoverInterfaceIPv6 returns ErrNoGateway.
+func (d *LeaseDiscoverer) DiscoverInterfaceIPv6() (net.IP, error) {
+	return nil, &ErrNoGateway{}
+}
+
+// Snapshot returns the routes that the current leases call for: a default
+// route through each router and a route to each subnet.
+func (d *LeaseDiscoverer) Snapshot() (Snapshot, error) {
+	leases, err := d.currentLeases()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var routes []Route
+	for _, l := range leases {
+		for i, router := range l.Routers {
+			routes = append(routes, Route{
+				Destination:    netip.PrefixFrom(netip.IPv4Unspecified(), 0),
+				Gateway:        router,
+				Interface:      l.Interface,
+				InterfaceIndex: l.InterfaceIndex,
+				Source:         l.Address,
+				Metric:         i,
+				Protocol:       "dhcp",
+			})
+		}
+		if l.Subnet.IsValid() {
+			routes = append(routes, Route{
+				Destination:    l.Subnet.Masked(),
+				Interface:      l.Interface,
+				InterfaceIndex: l.InterfaceIndex,
+				Source:         l.Address,
+				Protocol:       "dhcp",
+			})
+		}
+	}
+	return newSnapshot(routes, d.ifaceGetter), nil
+}
+
+// NewFallbackDiscoverer returns a Discoverer that answers from primary
+// and turns to fallback for the answers primary can't give, such as a
+// LeaseDiscoverer for sandboxes whose routing table is empty. If both
+// fail, the error of primary is returned.
+func NewFallbackDiscoverer(primary, fallback Discoverer) Discoverer {
+	return &fallbackDiscoverer{primary: primary, fallback: fallback}
+}
+
+type fallbackDiscoverer struct {
+	primary, fallback Discoverer
+}
+
+// withFallback returns the answer of primary, or that of fallback if
+// primary fails.
+func withFallback[T any](primary, fallback func() (T, error)) (T, error) {
+	v, err := primary()
+	if err == nil {
+		return v, nil
+	}
+	if v, fallbackErr := fallback(); fallbackErr == nil {
+		return v, nil
+	}
+	return v, err
+}
+
+func (d *fallbackDiscoverer) DiscoverGateways() ([]net.IP, error) {
+	return withFallback(d.primary.DiscoverGateways, d.fallback.DiscoverGateways)
+}
+
+func (d *fallbackDiscoverer) DiscoverInterface() (net.IP, error) {
+	return withFallback(d.primary.DiscoverInterface, d.fallback.DiscoverInterface)
+}
+
+func (d *fallbackDiscoverer) DiscoverGatewaysIPv6() ([]net.IP, error) {
+	return withFallback(d.primary.DiscoverGatewaysIPv6, d.fallback.DiscoverGatewaysIPv6)
+}
+
+func (d *fallbackDiscoverer) DiscoverInterfaceIPv6() (net.IP, error) {
+	return withFallback(d.primary.DiscoverInterfaceIPv6, d.fallback.DiscoverInterfaceIPv6)
+}
+
+// Snapshot returns the snapshot of primary, unless it has no default
+// route.
+func (d *fallbackDiscoverer) Snapshot() (Snapshot, error) {
+	return withFallback(func() (Snapshot, error) {
+		s, err := d.primary.Snapshot()
+		if err == nil && len(s.DefaultRoutes(false)) == 0 && len(s.DefaultRoutes(true)) == 0 {
+			return s, &ErrNoGateway{}
+		}
+		return s, err
+	}, d.fallback.Snapshot)
+}
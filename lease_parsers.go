@@ -0,0 +1,255 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDhclientLeases parses a lease file of ISC dhclient, such as
+// /var/lib/dhcp/dhclient.leases. dhclient appends each new lease, so the
+// last lease of an interface is its current one.
+func ParseDhclientLeases(data []byte) ([]Lease, error) {
+	// lease {
+	//   interface "eth0";
+	//   fixed-address 192.168.1.23;
+	//   option subnet-mask 255.255.255.0;
+	//   option routers 192.168.1.1;
+	//   option domain-name-servers 192.168.1.1,9.9.9.9;
+	//   renew 2 2026/10/20 03:12:45;
+	//   expire 3 2026/10/21 12:00:00;
+	// }
+	//
+	// Times are UTC, or seconds since the epoch with db-time-format local
+	// ("expire epoch 1761048000; # Tue Oct 21 12:00:00 2026").
+	var (
+		result  []Lease
+		current *Lease
+		mask    netip.Addr
+		found   bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "lease {":
+			found = true
+			current = &Lease{}
+			mask = netip.Addr{}
+			continue
+		case line == "}":
+			if current != nil {
+				current.Subnet = leaseSubnet(current.Address, mask)
+				result = append(result, *current)
+			}
+			current = nil
+			continue
+		case current == nil:
+			continue
+		}
+
+		line = strings.TrimSuffix(line, ";")
+		key, value, _ := strings.Cut(line, " ")
+		if key == "option" {
+			key, value, _ = strings.Cut(value, " ")
+		}
+		switch key {
+		case "interface":
+			current.Interface = strings.Trim(value, `"`)
+		case "fixed-address":
+			current.Address, _ = netip.ParseAddr(value)
+		case "subnet-mask":
+			mask, _ = netip.ParseAddr(value)
+		case "routers":
+			current.Routers = parseLeaseAddrs(value)
+		case "domain-name-servers":
+			current.DNS = parseLeaseAddrs(value)
+		case "expire":
+			current.Expiry = parseDhclientTime(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &ErrCantParse{}
+	}
+	return result, nil
+}
+
+// parseDhclientTime parses a time of a dhclient lease: a weekday followed
+// by a UTC date and time, "epoch" followed by seconds, or "never", which
+// is returned as the zero Time.
+func parseDhclientTime(value string) time.Time {
+	fields := strings.Fields(value)
+	switch {
+	case len(fields) == 2 && fields[0] == "epoch":
+		if seconds, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC()
+		}
+	case len(fields) == 3:
+		if t, err := time.Parse("2006/01/02 15:04:05", fields[1]+" "+fields[2]); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ParseNetworkdLease parses a lease file of systemd-networkd, such as
+// /run/systemd/netif/leases/2, or of NetworkManager's internal DHCP
+// client, such as /var/lib/NetworkManager/internal-<uuid>-eth0.lease.
+// Both use systemd's format, which gives the lease time relative to when
+// the lease was written: acquired is the modification time of the file.
+func ParseNetworkdLease(data []byte, acquired time.Time) (Lease, error) {
+	// # This is private data. Do not parse.
+	// ADDRESS=192.168.1.23
+	// NETMASK=255.255.255.0
+	// ROUTER=192.168.1.1
+	// DNS=192.168.1.1 9.9.9.9
+	// LIFETIME=86400
+	var (
+		lease Lease
+		mask  netip.Addr
+		found bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		switch key {
+		case "ADDRESS":
+			lease.Address, _ = netip.ParseAddr(value)
+			found = true
+		case "NETMASK":
+			mask, _ = netip.ParseAddr(value)
+		case "ROUTER":
+			lease.Routers = parseLeaseAddrs(value)
+		case "DNS":
+			lease.DNS = parseLeaseAddrs(value)
+		case "LIFETIME":
+			if seconds, err := strconv.Atoi(value); err == nil && !acquired.IsZero() {
+				lease.Expiry = acquired.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Lease{}, err
+	}
+	if !found {
+		return Lease{}, &ErrCantParse{}
+	}
+	lease.Subnet = leaseSubnet(lease.Address, mask)
+	return lease, nil
+}
+
+// DHCP options, see RFC 2132.
+const (
+	dhcpOptionPad        = 0
+	dhcpOptionSubnetMask = 1
+	dhcpOptionRouter     = 3
+	dhcpOptionDNS        = 6
+	dhcpOptionLeaseTime  = 51
+	dhcpOptionEnd        = 255
+)
+
+// dhcpMagicCookie starts the options of a DHCP message.
+var dhcpMagicCookie = []byte{99, 130, 83, 99}
+
+// ParseDhcpcdLease parses a lease file of dhcpcd, such as
+// /var/lib/dhcpcd/eth0.lease, which holds the DHCP acknowledgement as
+// received. Its lease time counts from when the file was written, given
+// by acquired.
+func ParseDhcpcdLease(data []byte, acquired time.Time) (Lease, error) {
+	// The BOOTP header (RFC 2131) holds the address at offset 16 and is
+	// followed by the magic cookie and the options.
+	const (
+		yiaddrOffset  = 16
+		optionsOffset = 236
+	)
+	if len(data) < optionsOffset+len(dhcpMagicCookie) ||
+		!bytes.Equal(data[optionsOffset:optionsOffset+len(dhcpMagicCookie)], dhcpMagicCookie) {
+		return Lease{}, &ErrCantParse{}
+	}
+
+	lease := Lease{Address: netip.AddrFrom4([4]byte(data[yiaddrOffset : yiaddrOffset+4]))}
+	var mask netip.Addr
+	forEachDHCPOption(data[optionsOffset+len(dhcpMagicCookie):], func(code byte, value []byte) {
+		switch code {
+		case dhcpOptionSubnetMask:
+			if len(value) == 4 {
+				mask = netip.AddrFrom4([4]byte(value))
+			}
+		case dhcpOptionRouter:
+			lease.Routers = dhcpAddrs(value)
+		case dhcpOptionDNS:
+			lease.DNS = dhcpAddrs(value)
+		case dhcpOptionLeaseTime:
+			if len(value) == 4 && !acquired.IsZero() {
+				seconds := binary.BigEndian.Uint32(value)
+				lease.Expiry = acquired.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	})
+	lease.Subnet = leaseSubnet(lease.Address, mask)
+	return lease, nil
+}
+
+// forEachDHCPOption calls fn with the code and value of each option up to
+// the end option. A truncated option ends the options.
+func forEachDHCPOption(options []byte, fn func(code byte, value []byte)) {
+	for len(options) > 0 {
+		code := options[0]
+		if code == dhcpOptionPad {
+			options = options[1:]
+			continue
+		}
+		if code == dhcpOptionEnd || len(options) < 2 || len(options) < 2+int(options[1]) {
+			return
+		}
+		value := options[2 : 2+int(options[1])]
+		options = options[2+len(value):]
+		fn(code, value)
+	}
+}
+
+// dhcpAddrs returns the IPv4 addresses of a DHCP option.
+func dhcpAddrs(value []byte) []netip.Addr {
+	var result []netip.Addr
+	for ; len(value) >= 4; value = value[4:] {
+		result = append(result, netip.AddrFrom4([4]byte(value[:4])))
+	}
+	return result
+}
+
+// parseLeaseAddrs parses a list of addresses separated by commas or
+// spaces, skipping those that aren't addresses.
+func parseLeaseAddrs(value string) []netip.Addr {
+	var result []netip.Addr
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if addr, err := netip.ParseAddr(field); err == nil {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
+
+// leaseSubnet returns the subnet of addr with the given mask, or the zero
+// Prefix if either is missing.
+func leaseSubnet(addr, mask netip.Addr) netip.Prefix {
+	if !addr.IsValid() || !mask.Is4() {
+		return netip.Prefix{}
+	}
+	bits, _ := net.IPMask(mask.AsSlice()).Size()
+	return netip.PrefixFrom(addr, bits)
+}
@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func dhcpcdLeaseBytes(t *testing.T) []byte {
+	return hexFixture(t, dhcpcdLease)
+}
+
+// hexFixture decodes a fixture of hex octets.
+func hexFixture(t *testing.T, name string) []byte {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(string(routeTables[name])), ""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return data
+}
+
+func TestParseLeases(t *testing.T) {
+	t.Run(dhclientLeases, func(t *testing.T) {
+		leases, err := ParseDhclientLeases(routeTables[dhclientLeases])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(leases) != 3 {
+			t.Fatalf("Expected 3 leases, got %d", len(leases))
+		}
+		want := Lease{
+			Interface: "eth0",
+			Address:   netip.MustParseAddr("192.168.1.23"),
+			Subnet:    netip.MustParsePrefix("192.168.1.23/24"),
+			Routers:   []netip.Addr{netip.MustParseAddr("192.168.1.1")},
+			DNS:       []netip.Addr{netip.MustParseAddr("192.168.1.1"), netip.MustParseAddr("9.9.9.9")},
+			Expiry:    time.Date(2026, 10, 20, 21, 0, 0, 0, time.UTC),
+		}
+		if !reflect.DeepEqual(leases[1], want) {
+			t.Errorf("Unexpected lease %+v", leases[1])
+		}
+		if e := leases[2].Expiry; !e.Equal(time.Date(2025, 10, 18, 22, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected epoch expiry %v", e)
+		}
+	})
+
+	acquired := time.Date(2026, 10, 19, 11, 50, 0, 0, time.UTC)
+
+	t.Run(networkdLease, func(t *testing.T) {
+		lease, err := ParseNetworkdLease(routeTables[networkdLease], acquired)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := Lease{
+			Address: netip.MustParseAddr("172.16.4.10"),
+			Subnet:  netip.MustParsePrefix("172.16.4.10/22"),
+			Routers: []netip.Addr{netip.MustParseAddr("172.16.4.1")},
+			DNS:     []netip.Addr{netip.MustParseAddr("172.16.4.1"), netip.MustParseAddr("1.1.1.1")},
+			Expiry:  acquired.Add(time.Hour),
+		}
+		if !reflect.DeepEqual(lease, want) {
+			t.Errorf("Unexpected lease %+v", lease)
+		}
+	})
+
+	t.Run(dhcpcdLease, func(t *testing.T) {
+		lease, err := ParseDhcpcdLease(dhcpcdLeaseBytes(t), acquired)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := Lease{
+			Address: netip.MustParseAddr("192.168.8.100"),
+			Subnet:  netip.MustParsePrefix("192.168.8.100/24"),
+			Routers: []netip.Addr{netip.MustParseAddr("192.168.8.1")},
+			DNS:     []netip.Addr{netip.MustParseAddr("192.168.8.1"), netip.MustParseAddr("9.9.9.9")},
+			Expiry:  acquired.Add(12 * time.Hour),
+		}
+		if !reflect.DeepEqual(lease, want) {
+			t.Errorf("Unexpected lease %+v", lease)
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := ParseDhclientLeases(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+		if _, err := ParseNetworkdLease(routeTables[randomData], acquired); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+		if _, err := ParseDhcpcdLease(routeTables[randomData], acquired); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestLeaseDiscoverer(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	root := fstest.MapFS{
+		"var/lib/dhcp/dhclient.leases": {Data: routeTables[dhclientLeases], ModTime: now.Add(-time.Hour)},
+		"var/lib/dhcpcd/eth1.lease":    {Data: dhcpcdLeaseBytes(t), ModTime: now.Add(-time.Hour)},
+		// dhcpcd adds the SSID of wireless networks to the name.
+		"var/lib/dhcpcd/wlan0-Home-Net.lease": {Data: hexFixture(t, dhcpcdLeaseWireless), ModTime: now.Add(-time.Hour)},
+		"run/systemd/netif/leases/3":          {Data: routeTables[networkdLease], ModTime: now.Add(-10 * time.Minute)},
+		// Expired a day later.
+		"var/lib/NetworkManager/internal-3f6c1f2a-6c3e-4a0b-9d55-1e0f3f7b2c11-wlp2s0.lease": {Data: routeTables[networkManagerLease], ModTime: now.Add(-24 * time.Hour)},
+		"var/lib/NetworkManager/NetworkManager.state":                                       {Data: []byte("[main]\nNetworkingEnabled=true\n")},
+	}
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByIndex", 3).Return(nil, errors.New("no such interface"))
+	mockGetter.On("InterfaceByName", "wlan0").Return(&net.Interface{Name: "wlan0"}, nil)
+	mockGetter.On("Addrs", mock.Anything).Return(nil, errors.New("no addresses"))
+	mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+	d := newLeaseDiscoverer(root, mockGetter)
+	d.now = func() time.Time { return now }
+
+	leases, err := d.Leases()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(leases) != 7 {
+		t.Fatalf("Expected 7 leases, got %+v", leases)
+	}
+	if l := leases[3]; l.Interface != "eth1" {
+		t.Errorf("Unexpected dhcpcd lease %+v", l)
+	}
+	if l := leases[4]; l.Interface != "wlan0" || l.File != "/var/lib/dhcpcd/wlan0-Home-Net.lease" {
+		t.Errorf("Unexpected wireless dhcpcd lease %+v", l)
+	}
+	if l := leases[5]; l.InterfaceIndex != 3 || l.File != "/run/systemd/netif/leases/3" {
+		t.Errorf("Unexpected networkd lease %+v", l)
+	}
+	if l := leases[6]; l.Interface != "wlp2s0" || !l.Expired(now) {
+		t.Errorf("Unexpected NetworkManager lease %+v", l)
+	}
+
+	// The superseded and the expired leases are left out.
+	ips, err := d.DiscoverGateways()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"192.168.1.1", "192.168.8.1", "172.16.4.1", "10.0.5.1"}
+	if got := ipStrings(ips); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected gateways %v", got)
+	}
+	if ip, err := d.DiscoverInterface(); err != nil || ip.String() != "192.168.1.23" {
+		t.Errorf("Unexpected interface address %v, %v", ip, err)
+	}
+	if _, err := d.DiscoverGatewaysIPv6(); !errors.Is(err, &ErrNoGateway{}) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+
+	// The gateways of an interface come from its latest unexpired lease.
+	current, err := d.currentLeases()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ipStrings(leaseGatewaysForInterface(current, "eth0")); !reflect.DeepEqual(got, []string{"192.168.1.1"}) {
+		t.Errorf("Unexpected gateways of eth0 %v", got)
+	}
+	if got := leaseGatewaysForInterface(current, "wlp2s0"); got != nil {
+		t.Errorf("Expected no gateways of wlp2s0, got %v", got)
+	}
+
+	t.Run("fallback", func(t *testing.T) {
+		// An empty routing table, as in gVisor.
+		f := NewFallbackDiscoverer(snapshotDiscoverer{}, d)
+		s, err := f.Snapshot()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ips, _ := s.Gateways(); len(ips) != 4 {
+			t.Errorf("Unexpected gateways %v", ips)
+		}
+
+		primaryErr := errors.New("no route table")
+		f = NewFallbackDiscoverer(&countingDiscoverer{err: primaryErr}, d)
+		if ips, err := f.DiscoverGateways(); err != nil || ips[0].String() != "192.168.1.1" {
+			t.Errorf("Unexpected gateways %v, %v", ips, err)
+		}
+		// The primary's answers come first.
+		if ips, err := f.DiscoverGatewaysIPv6(); err != nil || ips[0].String() != "fe80::1" {
+			t.Errorf("Unexpected gateways %v, %v", ips, err)
+		}
+
+		empty := newLeaseDiscoverer(fstest.MapFS{}, mockGetter)
+		f = NewFallbackDiscoverer(&countingDiscoverer{err: primaryErr}, empty)
+		if _, err := f.DiscoverGateways(); err != primaryErr {
+			t.Errorf("Expected the primary's error, got %v", err)
+		}
+	})
+}
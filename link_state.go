@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"bytes"
+	"io/fs"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+)
+
+// LinkState is the operational state of a route's interface. Routes on
+// interfaces that are down or have no carrier can't carry traffic, but
+// some configurations keep them: static routes, or NetworkManager with
+// ignore-carrier.
+type LinkState int
+
+const (
+	// LinkStateUnknown is the state of an interface that couldn't be
+	// checked.
+	LinkStateUnknown LinkState = iota
+
+	// LinkStateUp is an interface that is up and has a carrier.
+	LinkStateUp
+
+	// LinkStateDown is an interface that is administratively down.
+	LinkStateDown
+
+	// LinkStateNoCarrier is an interface that is up but whose link is
+	// not, such as an unplugged cable or a Wi-Fi adapter that isn't
+	// associated.
+	LinkStateNoCarrier
+)
+
+var linkStateNames = [...]string{
+	LinkStateUnknown:   "unknown",
+	LinkStateUp:        "up",
+	LinkStateDown:      "down",
+	LinkStateNoCarrier: "no carrier",
+}
+
+func (s LinkState) String() string {
+	if s < 0 || int(s) >= len(linkStateNames) {
+		return "LinkState(" + strconv.Itoa(int(s)) + ")"
+	}
+	return linkStateNames[s]
+}
+
+// dead reports whether an interface in state s can't carry traffic.
+func (s LinkState) dead() bool {
+	return s == LinkStateDown || s == LinkStateNoCarrier
+}
+
+// Interface flags of Linux' netlink(7) link messages, see netdevice(7).
+const (
+	linuxIFFUp      = 0x1
+	linuxIFFLowerUp = 0x10000
+)
+
+// linuxFlagsLinkState returns the state of an interface with the given
+// netlink flags. Unlike IFF_RUNNING, IFF_LOWER_UP reports the carrier of
+// the link itself.
+func linuxFlagsLinkState(flags uint32) LinkState {
+	switch {
+	case flags&linuxIFFUp == 0:
+		return LinkStateDown
+	case flags&linuxIFFLowerUp == 0:
+		return LinkStateNoCarrier
+	default:
+		return LinkStateUp
+	}
+}
+
+// linuxSysfsLinkState returns the state of an interface from sysfs, where
+// sys is /sys/class/net. Reading carrier fails while the interface is
+// administratively down.
+func linuxSysfsLinkState(sys fs.FS, name string) LinkState {
+	operstate, err := fs.ReadFile(sys, path.Join(name, "operstate"))
+	if err != nil {
+		return LinkStateUnknown
+	}
+	carrier, err := fs.ReadFile(sys, path.Join(name, "carrier"))
+	if err != nil {
+		return LinkStateDown
+	}
+	switch {
+	case string(bytes.TrimSpace(carrier)) == "0":
+		return LinkStateNoCarrier
+	case string(bytes.TrimSpace(operstate)) == "lowerlayerdown":
+		// A VLAN or bridge port whose underlying link is down.
+		return LinkStateNoCarrier
+	default:
+		// Tunnels and loopback report "unknown" though they work.
+		return LinkStateUp
+	}
+}
+
+// interfaceLinkState returns the state of iface from its flags, for
+// operating systems that don't tell more.
+func interfaceLinkState(iface *net.Interface) LinkState {
+	switch {
+	case iface.Flags&net.FlagUp == 0:
+		return LinkStateDown
+	case iface.Flags&net.FlagRunning == 0:
+		return LinkStateNoCarrier
+	default:
+		return LinkStateUp
+	}
+}
+
+// flagsLinkState returns the state that the flags of r report, such as
+// "linkdown" of Linux' ip route.
+func flagsLinkState(r Route) LinkState {
+	if slices.Contains(r.Flags, "linkdown") {
+		return LinkStateNoCarrier
+	}
+	return LinkStateUnknown
+}
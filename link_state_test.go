@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLinuxLinkState(t *testing.T) {
+	flagCases := map[uint32]LinkState{
+		0x0:     LinkStateDown,
+		0x1003:  LinkStateNoCarrier, // IFF_UP|IFF_BROADCAST|IFF_MULTICAST
+		0x11043: LinkStateUp,        // and IFF_RUNNING|IFF_LOWER_UP
+		0x10000: LinkStateDown,
+	}
+	for flags, want := range flagCases {
+		if got := linuxFlagsLinkState(flags); got != want {
+			t.Errorf("linuxFlagsLinkState(%#x) = %v, want %v", flags, got, want)
+		}
+	}
+
+	sys := fstest.MapFS{
+		"eth0/operstate":   {Data: []byte("up\n")},
+		"eth0/carrier":     {Data: []byte("1\n")},
+		"eth1/operstate":   {Data: []byte("down\n")},
+		"eth1/carrier":     {Data: []byte("0\n")},
+		"eth2/operstate":   {Data: []byte("down\n")},
+		"vlan10/operstate": {Data: []byte("lowerlayerdown\n")},
+		"vlan10/carrier":   {Data: []byte("1\n")},
+		"wg0/operstate":    {Data: []byte("unknown\n")},
+		"wg0/carrier":      {Data: []byte("1\n")},
+	}
+	sysfsCases := map[string]LinkState{
+		"eth0":     LinkStateUp,
+		"eth1":     LinkStateNoCarrier,
+		"eth2":     LinkStateDown,
+		"vlan10":   LinkStateNoCarrier,
+		"wg0":      LinkStateUp,
+		"missing0": LinkStateUnknown,
+	}
+	for name, want := range sysfsCases {
+		if got := linuxSysfsLinkState(sys, name); got != want {
+			t.Errorf("linuxSysfsLinkState(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	// Netlink flags take precedence over sysfs.
+	routes := []Route{
+		{Destination: netip.MustParsePrefix("0.0.0.0/0"), Interface: "eth0"},
+		{Destination: netip.MustParsePrefix("0.0.0.0/0"), Interface: "eth1"},
+	}
+	annotateLinuxRoutes(routes, sys, map[string]uint32{"eth0": 0x1003})
+	if routes[0].LinkState != LinkStateNoCarrier || routes[1].LinkState != LinkStateNoCarrier {
+		t.Errorf("Unexpected link states %v %v", routes[0].LinkState, routes[1].LinkState)
+	}
+
+	if s := LinkStateNoCarrier.String(); s != "no carrier" {
+		t.Errorf("Unexpected name %q", s)
+	}
+}
+
+func TestSnapshotLinkDown(t *testing.T) {
+	routes, err := ParseIPRoute(routeTables[linuxIPRouteLinkDown], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+	s := newSnapshot(routes, mockGetter)
+
+	// The static route on the unplugged cable has the lower metric but
+	// is tried last.
+	ips, err := s.Gateways()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ips) != 2 || ips[0].String() != "192.168.1.1" || ips[1].String() != "10.0.0.1" {
+		t.Errorf("Unexpected gateways %v", ips)
+	}
+	if r := s.Routes[1]; r.LinkState != LinkStateNoCarrier {
+		t.Errorf("Unexpected link state %v of %+v", r.LinkState, r)
+	}
+
+	ips, err = s.Filter(Options{ExcludeDownLinks: true}).Gateways()
+	if err != nil || len(ips) != 1 || ips[0].String() != "192.168.1.1" {
+		t.Errorf("Unexpected gateways %v, %v", ips, err)
+	}
+
+	t.Run("interface flags", func(t *testing.T) {
+		// Elsewhere the flags of the interface tell the state.
+		mockGetter := newMockinterfaceGetter(t)
+		mockGetter.On("InterfaceByName", "en0").Return(&net.Interface{Name: "en0", Flags: net.FlagUp | net.FlagBroadcast}, nil)
+		mockGetter.On("InterfaceByName", "en1").Return(&net.Interface{Name: "en1", Flags: net.FlagUp | net.FlagRunning}, nil)
+		mockGetter.On("Addrs", mock.Anything).Return(nil, nil)
+		s := newSnapshot([]Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("10.0.0.1"), Interface: "en0"},
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.1"), Interface: "en1", Metric: 10},
+		}, mockGetter)
+		if s.Routes[0].Interface != "en1" || s.Routes[0].LinkState != LinkStateUp || s.Routes[1].LinkState != LinkStateNoCarrier {
+			t.Errorf("Unexpected routes %+v", s.Routes)
+		}
+	})
+}
@@ -195,6 +195,63 @@ func (_c *mockinterfaceGetter_InterfaceByName_Call) RunAndReturn(run func(string
 	return _c
 }
 
+// Interfaces provides a mock function with no fields
+func (_m *mockinterfaceGetter) Interfaces() ([]net.Interface, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Interfaces")
+	}
+
+	var r0 []net.Interface
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]net.Interface, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []net.Interface); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]net.Interface)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mockinterfaceGetter_Interfaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Interfaces'
+type mockinterfaceGetter_Interfaces_Call struct {
+	*mock.Call
+}
+
+// Interfaces is a helper method to define mock.On call
+func (_e *mockinterfaceGetter_Expecter) Interfaces() *mockinterfaceGetter_Interfaces_Call {
+	return &mockinterfaceGetter_Interfaces_Call{Call: _e.mock.On("Interfaces")}
+}
+
+func (_c *mockinterfaceGetter_Interfaces_Call) Run(run func()) *mockinterfaceGetter_Interfaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *mockinterfaceGetter_Interfaces_Call) Return(_a0 []net.Interface, _a1 error) *mockinterfaceGetter_Interfaces_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *mockinterfaceGetter_Interfaces_Call) RunAndReturn(run func() ([]net.Interface, error)) *mockinterfaceGetter_Interfaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // newMockinterfaceGetter creates a new instance of mockinterfaceGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockinterfaceGetter(t interface {
@@ -0,0 +1,269 @@
+package gateway
+
+import (
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// NAT64Source is where the NAT64 prefix of a network was learned.
+type NAT64Source int
+
+const (
+	// NAT64SourceNone means no NAT64 prefix was found.
+	NAT64SourceNone NAT64Source = iota
+
+	// NAT64SourceRA is the PREF64 option of a Router Advertisement
+	// (RFC 8781).
+	NAT64SourceRA
+
+	// NAT64SourceDNS is the resolution of ipv4only.arpa (RFC 7050).
+	NAT64SourceDNS
+)
+
+var nat64SourceNames = [...]string{
+	NAT64SourceNone: "none",
+	NAT64SourceRA:   "ra",
+	NAT64SourceDNS:  "dns",
+}
+
+func (s NAT64Source) String() string {
+	if s < 0 || int(s) >= len(nat64SourceNames) {
+		return "NAT64Source(" + strconv.Itoa(int(s)) + ")"
+	}
+	return nat64SourceNames[s]
+}
+
+// NAT64 describes how an IPv6-only network reaches the IPv4 Internet.
+type NAT64 struct {
+	// Prefix is the prefix the NAT64 translator maps IPv4 addresses
+	// into, such as 64:ff9b::/96.
+	Prefix netip.Prefix
+
+	// Source is where Prefix was learned.
+	Source NAT64Source
+
+	// Server is the router that advertised Prefix or the DNS server
+	// that synthesized it.
+	Server netip.Addr
+
+	// CLATInterface is the name of the interface of a 464XLAT client
+	// translator (CLAT), such as clat4 or v4-rmnet_data0, which gives
+	// the host IPv4 connectivity, or empty if there is none.
+	CLATInterface string
+}
+
+const (
+	// ipv4OnlyName is the name that only has A records (RFC 7050).
+	ipv4OnlyName = "ipv4only.arpa."
+
+	// nat64Timeout bounds the router solicitation and each DNS query of
+	// DiscoverNAT64.
+	nat64Timeout = 2 * time.Second
+)
+
+// ipv4OnlyAddrs are the addresses of ipv4only.arpa, which a DNS64 server
+// synthesizes into the NAT64 prefix.
+var ipv4OnlyAddrs = []netip.Addr{
+	netip.AddrFrom4([4]byte{192, 0, 0, 170}),
+	netip.AddrFrom4([4]byte{192, 0, 0, 171}),
+}
+
+// clatInterfacePrefixes are the name prefixes of CLAT interfaces: clat
+// (clatd) and v4- (Android).
+var clatInterfacePrefixes = []string{"clat", "v4-"}
+
+// DiscoverNAT64 is the OS independent function to find the NAT64 prefix
+// of an IPv6-only network, where DiscoverGateway fails while IPv4
+// destinations are reached through NAT64. The prefix is taken from the
+// PREF64 option of the Router Advertisements on the interface of the
+// preferred IPv6 default route, which needs privileges, or else from
+// resolving ipv4only.arpa with the configured DNS servers.
+//
+// ErrNoGateway is returned, together with the CLAT interface if any, if
+// the network has no NAT64 prefix.
+func DiscoverNAT64() (NAT64, error) {
+	result := NAT64{}
+	if ifaces, err := net.Interfaces(); err == nil {
+		result.CLATInterface = findCLATInterface(ifaces)
+	}
+
+	if s, err := discoverSnapshotOSSpecific(); err == nil {
+		if r, ok := s.preferredDefaultRoute(true); ok && r.Interface != "" {
+			if ras, err := DiscoverRouters(r.Interface, nat64Timeout); err == nil {
+				if prefix, router, ok := pref64FromAdvertisements(ras); ok {
+					result.Prefix, result.Source, result.Server = prefix, NAT64SourceRA, router
+					return result, nil
+				}
+			}
+		}
+	}
+
+	dns, _ := discoverDNSConfigOSSpecific()
+	var servers []netip.AddrPort
+	for _, server := range dns.allServers() {
+		servers = append(servers, netip.AddrPortFrom(server, 53))
+	}
+	prefix, server, err := resolveNAT64Prefix(servers, nat64Timeout)
+	if err != nil {
+		return result, err
+	}
+	result.Prefix, result.Source, result.Server = prefix, NAT64SourceDNS, server
+	return result, nil
+}
+
+// allServers returns the DNS servers of the host and of its interfaces,
+// without repetitions.
+func (c dnsConfigs) allServers() []netip.Addr {
+	var result []netip.Addr
+	add := func(servers []netip.Addr) {
+		for _, server := range servers {
+			if !slices.Contains(result, server) {
+				result = append(result, server)
+			}
+		}
+	}
+	add(c.global.Servers)
+	for _, iface := range c.interfaces {
+		add(iface.Servers)
+	}
+	return result
+}
+
+// pref64FromAdvertisements returns the first PREF64 prefix that is still
+// valid, with the router that advertised it.
+func pref64FromAdvertisements(ras []RouterAdvertisement) (netip.Prefix, netip.Addr, bool) {
+	for _, ra := range ras {
+		if ra.PREF64.IsValid() && ra.PREF64Lifetime > 0 {
+			return ra.PREF64, ra.Router, true
+		}
+	}
+	return netip.Prefix{}, netip.Addr{}, false
+}
+
+// findCLATInterface returns the name of the first CLAT interface that is
+// up, or empty.
+func findCLATInterface(ifaces []net.Interface) string {
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		for _, prefix := range clatInterfacePrefixes {
+			if strings.HasPrefix(iface.Name, prefix) {
+				return iface.Name
+			}
+		}
+	}
+	return ""
+}
+
+// resolveNAT64Prefix asks the servers in turn for the AAAA records of
+// ipv4only.arpa and derives the NAT64 prefix from the first answer that
+// embeds one of its addresses.
+func resolveNAT64Prefix(servers []netip.AddrPort, timeout time.Duration) (netip.Prefix, netip.Addr, error) {
+	for _, server := range servers {
+		addrs, err := queryAAAA(server, ipv4OnlyName, timeout)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if prefix, ok := nat64Prefix(addr); ok {
+				return prefix, server.Addr(), nil
+			}
+		}
+	}
+	return netip.Prefix{}, netip.Addr{}, &ErrNoGateway{}
+}
+
+// nat64Prefix returns the prefix of a synthesized address of
+// ipv4only.arpa, trying the prefix lengths of RFC 6052 in turn.
+func nat64Prefix(addr netip.Addr) (netip.Prefix, bool) {
+	if !addr.Is6() || addr.Is4In6() {
+		return netip.Prefix{}, false
+	}
+	for _, bits := range []int{96, 64, 56, 48, 40, 32} {
+		if embedded, ok := extractIPv4(addr, bits); ok && slices.Contains(ipv4OnlyAddrs, embedded) {
+			return netip.PrefixFrom(addr, bits).Masked(), true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// extractIPv4 returns the IPv4 address embedded in addr behind a prefix
+// of the given length, see RFC 6052 2.2. The octet of bits 64 to 71 is
+// skipped and must be zero.
+func extractIPv4(addr netip.Addr, bits int) (netip.Addr, bool) {
+	a := addr.As16()
+	if bits == 96 {
+		return netip.AddrFrom4([4]byte(a[12:16])), true
+	}
+	if a[8] != 0 {
+		return netip.Addr{}, false
+	}
+	var v4 []byte
+	for i := bits / 8; len(v4) < 4; i++ {
+		if i != 8 {
+			v4 = append(v4, a[i])
+		}
+	}
+	return netip.AddrFrom4([4]byte(v4)), true
+}
+
+// queryAAAA asks the DNS server for the AAAA records of name over UDP.
+func queryAAAA(server netip.AddrPort, name string, timeout time.Duration) ([]netip.Addr, error) {
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET},
+		},
+	}
+	b, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialUDP("udp", nil, net.UDPAddrFromAddrPort(server))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var reply dnsmessage.Message
+		if err := reply.Unpack(buf[:n]); err != nil || reply.ID != id || !reply.Response {
+			continue
+		}
+		if reply.RCode != dnsmessage.RCodeSuccess {
+			return nil, &ErrNoGateway{}
+		}
+		var result []netip.Addr
+		for _, answer := range reply.Answers {
+			if aaaa, ok := answer.Body.(*dnsmessage.AAAAResource); ok {
+				result = append(result, netip.AddrFrom16(aaaa.AAAA))
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestNAT64Prefix(t *testing.T) {
+	// The synthesized addresses of 192.0.0.170 for each prefix length of
+	// RFC 6052 2.4.
+	testcases := map[string]string{
+		"64:ff9b::192.0.0.170":          "64:ff9b::/96",
+		"64:ff9b::c000:ab":              "64:ff9b::/96",
+		"2001:db8:c000:aa::":            "2001:db8::/32",
+		"2001:db8:1c0:0:aa::":           "2001:db8:100::/40",
+		"2001:db8:122:c000:0:aa00::":    "2001:db8:122::/48",
+		"2001:db8:122:3c0:0:aa::":       "2001:db8:122:300::/56",
+		"2001:db8:122:344:c0:0:aa00::":  "2001:db8:122:344::/64",
+		"2001:db8:122:344:ff:c000:0:aa": "",
+		"2001:db8::1":                   "",
+		"::ffff:192.0.0.170":            "",
+		"192.0.0.170":                   "",
+	}
+	for addr, want := range testcases {
+		prefix, ok := nat64Prefix(netip.MustParseAddr(addr))
+		if want == "" {
+			if ok {
+				t.Errorf("%s: Expected no prefix, got %v", addr, prefix)
+			}
+			continue
+		}
+		if !ok || prefix != netip.MustParsePrefix(want) {
+			t.Errorf("%s: Expected %s, got %v", addr, want, prefix)
+		}
+	}
+}
+
+// dns64Resolver answers each query for AAAA records of ipv4only.arpa with
+// rcode and addrs.
+func dns64Resolver(t *testing.T, rcode dnsmessage.RCode, addrs ...string) func(req []byte) [][]byte {
+	return func(req []byte) [][]byte {
+		var query dnsmessage.Message
+		if err := query.Unpack(req); err != nil || len(query.Questions) != 1 {
+			return nil
+		}
+		q := query.Questions[0]
+		reply := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: rcode},
+			Questions: query.Questions,
+		}
+		if q.Name.String() == ipv4OnlyName && q.Type == dnsmessage.TypeAAAA {
+			for _, addr := range addrs {
+				reply.Answers = append(reply.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60},
+					Body:   &dnsmessage.AAAAResource{AAAA: netip.MustParseAddr(addr).As16()},
+				})
+			}
+		}
+		msg, err := reply.Pack()
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			return nil
+		}
+		return [][]byte{msg}
+	}
+}
+
+func TestResolveNAT64Prefix(t *testing.T) {
+	nxdomain := udpStandIn(t, "udp4", dns64Resolver(t, dnsmessage.RCodeNameError))
+	noDNS64 := udpStandIn(t, "udp4", dns64Resolver(t, dnsmessage.RCodeSuccess))
+	dns64 := udpStandIn(t, "udp4", dns64Resolver(t, dnsmessage.RCodeSuccess, "2001:db8:64::c000:aa", "2001:db8:64::c000:ab"))
+
+	prefix, server, err := resolveNAT64Prefix([]netip.AddrPort{nxdomain, noDNS64, dns64}, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prefix != netip.MustParsePrefix("2001:db8:64::/96") {
+		t.Errorf("Expected 2001:db8:64::/96, got %v", prefix)
+	}
+	if server != dns64.Addr() {
+		t.Errorf("Expected server %v, got %v", dns64.Addr(), server)
+	}
+
+	_, _, err = resolveNAT64Prefix([]netip.AddrPort{nxdomain, noDNS64}, time.Second)
+	var noGateway *ErrNoGateway
+	if !errors.As(err, &noGateway) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+}
+
+func TestPref64FromAdvertisements(t *testing.T) {
+	router := netip.MustParseAddr("fe80::1%wlan0")
+	ras := []RouterAdvertisement{
+		{Router: netip.MustParseAddr("fe80::2%wlan0")},
+		{Router: netip.MustParseAddr("fe80::3%wlan0"), PREF64: netip.MustParsePrefix("64:ff9b::/96")},
+		{Router: router, PREF64: netip.MustParsePrefix("2001:db8:64::/96"), PREF64Lifetime: time.Hour},
+	}
+	prefix, got, ok := pref64FromAdvertisements(ras)
+	if !ok || prefix != netip.MustParsePrefix("2001:db8:64::/96") || got != router {
+		t.Errorf("Expected 2001:db8:64::/96 from %v, got %v from %v", router, prefix, got)
+	}
+	if _, _, ok := pref64FromAdvertisements(ras[:2]); ok {
+		t.Errorf("Expected no prefix from expired options")
+	}
+}
+
+func TestFindCLATInterface(t *testing.T) {
+	ifaces := []net.Interface{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Name: "clat4", Flags: 0},
+		{Name: "rmnet_data0", Flags: net.FlagUp},
+		{Name: "v4-rmnet_data0", Flags: net.FlagUp},
+	}
+	if got := findCLATInterface(ifaces); got != "v4-rmnet_data0" {
+		t.Errorf("Expected v4-rmnet_data0, got %q", got)
+	}
+	if got := findCLATInterface(ifaces[:3]); got != "" {
+		t.Errorf("Expected no CLAT interface, got %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// natPMPPort is the port of NAT-PMP (RFC 6886) and PCP (RFC 6887) servers
+// on the gateway.
+const natPMPPort = 5351
+
+// NAT-PMP and PCP messages.
+const (
+	natPMPVersion            = 0
+	natPMPOpExternalAddress  = 0
+	natPMPResponseBit        = 0x80
+	natPMPExternalAddressLen = 12
+	pcpVersion               = 2
+	pcpOpMap                 = 1
+	pcpHeaderLen             = 24
+	pcpMapLen                = 36
+	pcpProtocolUDP           = 17
+
+	// pcpMapLifetime is the lifetime of the mapping a PCP probe asks
+	// for. The mapping is of the probe's own socket, which is closed
+	// right away, so it's kept as short as the server allows.
+	pcpMapLifetime = 1
+)
+
+// STUN Binding, see RFC 5389.
+const (
+	stunBindingRequest       = 0x0001
+	stunBindingSuccess       = 0x0101
+	stunMagicCookie          = 0x2112a442
+	stunHeaderLen            = 20
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXorMappedAddress = 0x0020
+	stunFamilyIPv4           = 0x01
+	stunFamilyIPv6           = 0x02
+)
+
+// exchangeUDP sends req to server and returns the first answer accept
+// takes, skipping others, or ErrNoGateway if none arrives within timeout.
+func exchangeUDP(server netip.AddrPort, req func(local netip.AddrPort) []byte, timeout time.Duration,
+	accept func(b []byte) bool) error {
+	conn, err := net.DialUDP("udp", nil, net.UDPAddrFromAddrPort(server))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(req(conn.LocalAddr().(*net.UDPAddr).AddrPort())); err != nil {
+		return err
+	}
+
+	b := make([]byte, 1500)
+	for {
+		n, err := conn.Read(b)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return &ErrNoGateway{}
+		}
+		if err != nil {
+			return err
+		}
+		if accept(b[:n]) {
+			return nil
+		}
+	}
+}
+
+// natPMPExternalAddress asks a NAT-PMP server for the external address of
+// its NAT.
+func natPMPExternalAddress(server netip.AddrPort, timeout time.Duration) (netip.Addr, error) {
+	var external netip.Addr
+	err := exchangeUDP(server, func(netip.AddrPort) []byte {
+		return []byte{natPMPVersion, natPMPOpExternalAddress}
+	}, timeout, func(b []byte) bool {
+		var ok bool
+		external, ok = parseNATPMPExternalAddress(b)
+		return ok
+	})
+	return external, err
+}
+
+// parseNATPMPExternalAddress decodes a successful answer to an external
+// address request.
+func parseNATPMPExternalAddress(b []byte) (netip.Addr, bool) {
+	if len(b) < natPMPExternalAddressLen || b[0] != natPMPVersion ||
+		b[1] != natPMPResponseBit|natPMPOpExternalAddress || binary.BigEndian.Uint16(b[2:4]) != 0 {
+		return netip.Addr{}, false
+	}
+	return netip.AddrFrom4([4]byte(b[8:12])), true
+}
+
+// pcpExternalAddress asks a PCP server for a mapping of the probe's socket
+// and returns the external address of the mapping. Unlike the other
+// probes, it changes the state of the server: the mapping lasts for
+// pcpMapLifetime.
+func pcpExternalAddress(server netip.AddrPort, timeout time.Duration) (netip.Addr, error) {
+	nonce := randomID()
+	var external netip.Addr
+	err := exchangeUDP(server, func(local netip.AddrPort) []byte {
+		return marshalPCPMap(nonce, local)
+	}, timeout, func(b []byte) bool {
+		var ok bool
+		external, ok = parsePCPMap(b, nonce)
+		return ok
+	})
+	return external, err
+}
+
+// randomID returns a PCP nonce or a STUN transaction ID. These only tell
+// the answers to a probe apart, so math/rand is good enough.
+func randomID() [12]byte {
+	var id [12]byte
+	binary.BigEndian.PutUint64(id[0:8], rand.Uint64())
+	binary.BigEndian.PutUint32(id[8:12], rand.Uint32())
+	return id
+}
+
+// marshalPCPMap encodes a MAP request for the UDP port of local.
+func marshalPCPMap(nonce [12]byte, local netip.AddrPort) []byte {
+	b := make([]byte, pcpHeaderLen+pcpMapLen)
+	b[0] = pcpVersion
+	b[1] = pcpOpMap
+	binary.BigEndian.PutUint32(b[4:8], pcpMapLifetime)
+	client := netip.AddrFrom16(local.Addr().As16()).As16()
+	copy(b[8:24], client[:])
+
+	m := b[pcpHeaderLen:]
+	copy(m[0:12], nonce[:])
+	m[12] = pcpProtocolUDP
+	binary.BigEndian.PutUint16(m[16:18], local.Port())
+	return b
+}
+
+// parsePCPMap decodes a successful answer to the MAP request with nonce.
+// The assigned external address is IPv4-mapped for IPv4 NATs.
+func parsePCPMap(b []byte, nonce [12]byte) (netip.Addr, bool) {
+	if len(b) < pcpHeaderLen+pcpMapLen || b[0] != pcpVersion ||
+		b[1] != natPMPResponseBit|pcpOpMap || b[3] != 0 {
+		return netip.Addr{}, false
+	}
+	m := b[pcpHeaderLen:]
+	if !bytes.Equal(m[0:12], nonce[:]) {
+		return netip.Addr{}, false
+	}
+	return netip.AddrFrom16([16]byte(m[20:36])).Unmap(), true
+}
+
+// stunMappedAddress asks a STUN server for the address and port it sees
+// the probe's socket at.
+func stunMappedAddress(server netip.AddrPort, timeout time.Duration) (netip.AddrPort, error) {
+	txid := randomID()
+	var mapped netip.AddrPort
+	err := exchangeUDP(server, func(netip.AddrPort) []byte {
+		return marshalSTUNBinding(txid)
+	}, timeout, func(b []byte) bool {
+		var ok bool
+		mapped, ok = parseSTUNBinding(b, txid)
+		return ok
+	})
+	return mapped, err
+}
+
+func marshalSTUNBinding(txid [12]byte) []byte {
+	b := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(b[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint32(b[4:8], stunMagicCookie)
+	copy(b[8:20], txid[:])
+	return b
+}
+
+// parseSTUNBinding decodes the mapped address of a successful Binding
+// response to txid, preferring XOR-MAPPED-ADDRESS over MAPPED-ADDRESS.
+func parseSTUNBinding(b []byte, txid [12]byte) (netip.AddrPort, bool) {
+	if len(b) < stunHeaderLen || binary.BigEndian.Uint16(b[0:2]) != stunBindingSuccess ||
+		binary.BigEndian.Uint32(b[4:8]) != stunMagicCookie || !bytes.Equal(b[8:20], txid[:]) {
+		return netip.AddrPort{}, false
+	}
+	length := int(binary.BigEndian.Uint16(b[2:4]))
+	if len(b) < stunHeaderLen+length {
+		return netip.AddrPort{}, false
+	}
+
+	var mapped netip.AddrPort
+	for attrs := b[stunHeaderLen : stunHeaderLen+length]; len(attrs) >= 4; {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if len(attrs) < 4+attrLen {
+			return netip.AddrPort{}, false
+		}
+		value := attrs[4 : 4+attrLen]
+		// Attributes are padded to multiples of 4 octets.
+		attrs = attrs[min(len(attrs), 4+(attrLen+3)&^3):]
+
+		switch attrType {
+		case stunAttrXorMappedAddress:
+			// The address is XORed with the magic cookie and the
+			// transaction ID, the port with the cookie's first half.
+			key := b[4:20]
+			if addr, ok := parseSTUNAddress(value, key); ok {
+				return addr, true
+			}
+		case stunAttrMappedAddress:
+			if addr, ok := parseSTUNAddress(value, nil); ok {
+				mapped = addr
+			}
+		}
+	}
+	return mapped, mapped.IsValid()
+}
+
+// parseSTUNAddress decodes an address attribute, XORed with key if not
+// nil.
+func parseSTUNAddress(value, key []byte) (netip.AddrPort, bool) {
+	if len(value) < 4 {
+		return netip.AddrPort{}, false
+	}
+	var addrLen int
+	switch value[1] {
+	case stunFamilyIPv4:
+		addrLen = 4
+	case stunFamilyIPv6:
+		addrLen = 16
+	default:
+		return netip.AddrPort{}, false
+	}
+	if len(value) < 4+addrLen {
+		return netip.AddrPort{}, false
+	}
+	port := []byte{value[2], value[3]}
+	addr := bytes.Clone(value[4 : 4+addrLen])
+	if key != nil {
+		for i := range port {
+			port[i] ^= key[i]
+		}
+		for i := range addr {
+			addr[i] ^= key[i]
+		}
+	}
+	ip, _ := netip.AddrFromSlice(addr)
+	return netip.AddrPortFrom(ip, binary.BigEndian.Uint16(port)), true
+}
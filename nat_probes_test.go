@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParseSTUNBinding(t *testing.T) {
+	// The sample IPv4 response of RFC 5769 2.2.
+	b, err := hex.DecodeString(strings.Join(strings.Fields(string(routeTables[stunBindingResponse])), ""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	txid := [12]byte(b[8:20])
+	mapped, ok := parseSTUNBinding(b, txid)
+	if want := netip.MustParseAddrPort("192.0.2.1:32853"); !ok || mapped != want {
+		t.Errorf("Expected %v, got %v", want, mapped)
+	}
+
+	other := txid
+	other[0]++
+	if _, ok := parseSTUNBinding(b, other); ok {
+		t.Errorf("Expected a response to another transaction to be skipped")
+	}
+	if _, ok := parseSTUNBinding(b[:len(b)-4], txid); ok {
+		t.Errorf("Expected a truncated response to be skipped")
+	}
+	if _, ok := parseSTUNBinding(marshalSTUNBinding(txid), txid); ok {
+		t.Errorf("Expected a request to be skipped")
+	}
+
+	// Old servers send MAPPED-ADDRESS only.
+	classic := append([]byte(nil), b[:stunHeaderLen]...)
+	binary.BigEndian.PutUint16(classic[2:4], 12)
+	classic = append(classic, 0, 1, 0, 8, 0, 1, 0x0d, 0x96, 203, 0, 113, 7)
+	mapped, ok = parseSTUNBinding(classic, txid)
+	if want := netip.MustParseAddrPort("203.0.113.7:3478"); !ok || mapped != want {
+		t.Errorf("Expected %v, got %v", want, mapped)
+	}
+}
+
+func TestParseNATPMPExternalAddress(t *testing.T) {
+	answer := []byte{0, 128, 0, 0, 0, 0, 0x1c, 0x20, 100, 64, 12, 34}
+	external, ok := parseNATPMPExternalAddress(answer)
+	if !ok || external != netip.MustParseAddr("100.64.12.34") {
+		t.Errorf("Expected 100.64.12.34, got %v", external)
+	}
+
+	// Result code 3: network failure, the router has no external
+	// address yet.
+	failure := []byte{0, 128, 0, 3, 0, 0, 0x1c, 0x20, 0, 0, 0, 0}
+	if _, ok := parseNATPMPExternalAddress(failure); ok {
+		t.Errorf("Expected a failure to be skipped")
+	}
+	if _, ok := parseNATPMPExternalAddress(answer[:8]); ok {
+		t.Errorf("Expected a truncated answer to be skipped")
+	}
+}
+
+func TestPCPMap(t *testing.T) {
+	nonce := [12]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	local := netip.MustParseAddrPort("192.168.1.20:40000")
+	req := marshalPCPMap(nonce, local)
+	if len(req) != pcpHeaderLen+pcpMapLen || req[0] != pcpVersion || req[1] != pcpOpMap {
+		t.Fatalf("Unexpected request %x", req)
+	}
+	if client := netip.AddrFrom16([16]byte(req[8:24])); client != netip.MustParseAddr("::ffff:192.168.1.20") {
+		t.Errorf("Expected an IPv4-mapped client address, got %v", client)
+	}
+	if port := binary.BigEndian.Uint16(req[pcpHeaderLen+16:]); port != 40000 {
+		t.Errorf("Expected internal port 40000, got %d", port)
+	}
+
+	answer := append([]byte(nil), req...)
+	answer[1] |= natPMPResponseBit
+	external := netip.MustParseAddr("::ffff:198.51.100.9").As16()
+	copy(answer[pcpHeaderLen+20:], external[:])
+	got, ok := parsePCPMap(answer, nonce)
+	if !ok || got != netip.MustParseAddr("198.51.100.9") {
+		t.Errorf("Expected 198.51.100.9, got %v", got)
+	}
+
+	if _, ok := parsePCPMap(answer, [12]byte{}); ok {
+		t.Errorf("Expected an answer with another nonce to be skipped")
+	}
+	answer[3] = 2 // NOT_AUTHORIZED
+	if _, ok := parsePCPMap(answer, nonce); ok {
+		t.Errorf("Expected a failure to be skipped")
+	}
+}
@@ -0,0 +1,218 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// NATType classifies how the host reaches the Internet over IPv4.
+type NATType int
+
+const (
+	// NATUnknown means the evidence doesn't tell.
+	NATUnknown NATType = iota
+
+	// NATNone means the host has a public address.
+	NATNone
+
+	// NATSingle means one NAT, usually the home router, translates the
+	// host's address to a public one.
+	NATSingle
+
+	// NATDouble means two NATs under the host's control translate its
+	// address, such as a router behind the router of the ISP.
+	NATDouble
+
+	// NATCarrierGrade means the ISP translates the address again, with
+	// a carrier-grade NAT (RFC 6598). Port mapping requests to the
+	// gateway don't reach beyond it.
+	NATCarrierGrade
+)
+
+var natTypeNames = [...]string{
+	NATUnknown:      "unknown",
+	NATNone:         "direct",
+	NATSingle:       "single NAT",
+	NATDouble:       "double NAT",
+	NATCarrierGrade: "CGNAT",
+}
+
+func (t NATType) String() string {
+	if t < 0 || int(t) >= len(natTypeNames) {
+		return "NATType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return natTypeNames[t]
+}
+
+// NATTopology is the result of AnalyzeNATTopology: the classification and
+// the evidence it rests on.
+type NATTopology struct {
+	Type NATType
+
+	// Local is the host's IPv4 address on the interface of the default
+	// route.
+	Local netip.Addr
+
+	// Gateway is the IPv4 default gateway.
+	Gateway netip.Addr
+
+	// GatewayExternal is the external address of the gateway's NAT, as
+	// reported by NAT-PMP or PCP, or the zero Addr if the gateway
+	// answered neither.
+	GatewayExternal netip.Addr
+
+	// GatewayProtocol is "NAT-PMP" or "PCP", whichever reported
+	// GatewayExternal.
+	GatewayProtocol string
+
+	// Public is the address and port a STUN server saw the host's
+	// traffic come from, or the zero AddrPort if none was asked.
+	Public netip.AddrPort
+
+	// Evidence explains the classification, one finding per entry.
+	Evidence []string
+}
+
+// cgnatPrefix is the shared address space of carrier-grade NATs
+// (RFC 6598).
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// AnalyzeNATTopology tells whether the host is behind no NAT, one NAT, a
+// double NAT or a carrier-grade NAT, for deciding whether port mapping can
+// work. It combines the addresses of the host and of the default gateway,
+// the external address the gateway reports by NAT-PMP or PCP, and, if
+// stunServer (host:port) isn't empty, the address a STUN server sees.
+//
+// NAT-PMP reports the external address without side effects. PCP can
+// only tell it by creating a port mapping, so it is asked only if usePCP
+// is set and the gateway doesn't speak NAT-PMP. The mapping is of the
+// probe's own UDP socket and expires within seconds.
+//
+// Probes that get no answer within timeout are left out of the evidence;
+// only failing to find the gateway or the host's address is an error.
+func AnalyzeNATTopology(stunServer string, usePCP bool, timeout time.Duration) (NATTopology, error) {
+	gw, err := DiscoverGateway()
+	if err != nil {
+		return NATTopology{}, err
+	}
+	local, err := DiscoverInterface()
+	if err != nil {
+		return NATTopology{}, err
+	}
+	gateway, _ := ipToAddr(gw)
+	localAddr, _ := ipToAddr(local)
+
+	var stun netip.AddrPort
+	if stunServer != "" {
+		stun, err = resolveUDPAddrPort(stunServer)
+		if err != nil {
+			return NATTopology{}, err
+		}
+	}
+	return analyzeNATTopology(localAddr, gateway, netip.AddrPortFrom(gateway, natPMPPort), usePCP, stun, timeout), nil
+}
+
+func resolveUDPAddrPort(hostport string) (netip.AddrPort, error) {
+	addr, err := net.ResolveUDPAddr("udp4", hostport)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ap := addr.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+}
+
+// analyzeNATTopology probes the NAT-PMP server router, or its PCP server
+// if usePCP is set, and, if valid, the STUN server stun, and classifies
+// the result.
+func analyzeNATTopology(local, gateway netip.Addr, router netip.AddrPort, usePCP bool, stun netip.AddrPort, timeout time.Duration) NATTopology {
+	t := NATTopology{Local: local, Gateway: gateway}
+	external, err := natPMPExternalAddress(router, timeout)
+	protocol := "NAT-PMP"
+	if err != nil && usePCP {
+		external, err = pcpExternalAddress(router, timeout)
+		protocol = "PCP"
+	}
+	if err == nil {
+		t.GatewayExternal, t.GatewayProtocol = external, protocol
+	}
+	if stun.IsValid() {
+		if mapped, err := stunMappedAddress(stun, timeout); err == nil {
+			t.Public = mapped
+		}
+	}
+	classifyNATTopology(&t)
+	return t
+}
+
+// addressScope names the kind of an IPv4 address for the evidence.
+func addressScope(addr netip.Addr) string {
+	switch {
+	case cgnatPrefix.Contains(addr):
+		return "in the CGNAT range 100.64.0.0/10"
+	case addr.IsPrivate():
+		return "private (RFC 1918)"
+	case addr.IsLinkLocalUnicast() || addr.IsLoopback():
+		return "link-local"
+	default:
+		return "public"
+	}
+}
+
+// isPublic reports whether addr is routable on the Internet.
+func isPublic(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnatPrefix.Contains(addr)
+}
+
+// classifyNATTopology sets Type and Evidence from the addresses of t.
+func classifyNATTopology(t *NATTopology) {
+	note := func(format string, args ...any) {
+		t.Evidence = append(t.Evidence, fmt.Sprintf(format, args...))
+	}
+	note("host address %v is %s", t.Local, addressScope(t.Local))
+	if t.Gateway.IsValid() {
+		note("gateway %v is %s", t.Gateway, addressScope(t.Gateway))
+	}
+	if t.GatewayExternal.IsValid() {
+		note("gateway reports external address %v by %s, which is %s",
+			t.GatewayExternal, t.GatewayProtocol, addressScope(t.GatewayExternal))
+	} else {
+		note("gateway answered neither NAT-PMP nor PCP")
+	}
+	public := t.Public.Addr()
+	if public.IsValid() {
+		note("STUN server sees %v", t.Public)
+	}
+
+	switch {
+	case isPublic(t.Local):
+		if public.IsValid() && public != t.Local {
+			note("traffic from the public host address leaves from another one")
+			t.Type = NATSingle
+		} else {
+			t.Type = NATNone
+		}
+	case cgnatPrefix.Contains(t.Local):
+		t.Type = NATCarrierGrade
+	case t.GatewayExternal.IsValid():
+		switch {
+		case cgnatPrefix.Contains(t.GatewayExternal):
+			t.Type = NATCarrierGrade
+		case !isPublic(t.GatewayExternal):
+			t.Type = NATDouble
+		case public.IsValid() && public != t.GatewayExternal:
+			note("traffic leaves from another address than the gateway's external one")
+			t.Type = NATCarrierGrade
+		default:
+			t.Type = NATSingle
+		}
+	case public.IsValid():
+		// Without the gateway's view, one NAT is all the STUN answer
+		// proves.
+		t.Type = NATSingle
+	default:
+		t.Type = NATUnknown
+	}
+}
@@ -0,0 +1,121 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestClassifyNATTopology(t *testing.T) {
+	addr := netip.MustParseAddr
+	testcases := []struct {
+		name string
+		t    NATTopology
+		want NATType
+	}{
+		{"public host", NATTopology{Local: addr("198.51.100.7"), Gateway: addr("198.51.100.1")}, NATNone},
+		{"public host seen elsewhere", NATTopology{Local: addr("198.51.100.7"), Public: netip.MustParseAddrPort("203.0.113.5:4000")}, NATSingle},
+		{"home router", NATTopology{Local: addr("192.168.1.20"), Gateway: addr("192.168.1.1"), GatewayExternal: addr("203.0.113.5")}, NATSingle},
+		{"home router and STUN agree", NATTopology{Local: addr("192.168.1.20"), GatewayExternal: addr("203.0.113.5"), Public: netip.MustParseAddrPort("203.0.113.5:4000")}, NATSingle},
+		{"router behind router", NATTopology{Local: addr("192.168.1.20"), GatewayExternal: addr("192.168.0.10")}, NATDouble},
+		{"router in shared space", NATTopology{Local: addr("192.168.1.20"), GatewayExternal: addr("100.72.3.4")}, NATCarrierGrade},
+		{"host in shared space", NATTopology{Local: addr("100.72.3.4"), Gateway: addr("100.64.0.1")}, NATCarrierGrade},
+		{"STUN disagrees", NATTopology{Local: addr("10.0.0.5"), GatewayExternal: addr("203.0.113.5"), Public: netip.MustParseAddrPort("198.51.100.200:4000")}, NATCarrierGrade},
+		{"STUN only", NATTopology{Local: addr("10.0.0.5"), Public: netip.MustParseAddrPort("198.51.100.200:4000")}, NATSingle},
+		{"no evidence", NATTopology{Local: addr("10.0.0.5"), Gateway: addr("10.0.0.1")}, NATUnknown},
+	}
+	for _, tc := range testcases {
+		classifyNATTopology(&tc.t)
+		if tc.t.Type != tc.want {
+			t.Errorf("%s: Expected %v, got %v (%q)", tc.name, tc.want, tc.t.Type, tc.t.Evidence)
+		}
+		if len(tc.t.Evidence) == 0 {
+			t.Errorf("%s: Expected evidence", tc.name)
+		}
+	}
+}
+
+// natPMPRouter answers NAT-PMP external address requests with external.
+func natPMPRouter(external netip.Addr) func(req []byte) [][]byte {
+	return func(req []byte) [][]byte {
+		if len(req) != 2 || req[0] != natPMPVersion {
+			return nil
+		}
+		a := external.As4()
+		return [][]byte{{0, 128, 0, 0, 0, 0, 0, 60, a[0], a[1], a[2], a[3]}}
+	}
+}
+
+// pcpRouter answers PCP MAP requests with external and rejects NAT-PMP
+// with UNSUPP_VERSION.
+func pcpRouter(external netip.Addr) func(req []byte) [][]byte {
+	return func(req []byte) [][]byte {
+		if len(req) < pcpHeaderLen+pcpMapLen || req[0] != pcpVersion {
+			return [][]byte{{pcpVersion, natPMPResponseBit | req[1], 0, 1}}
+		}
+		answer := append([]byte(nil), req...)
+		answer[1] |= natPMPResponseBit
+		a := external.As16()
+		copy(answer[pcpHeaderLen+20:], a[:])
+		return [][]byte{answer}
+	}
+}
+
+// stunServer answers Binding requests with mapped as XOR-MAPPED-ADDRESS.
+func stunServer(mapped netip.AddrPort) func(req []byte) [][]byte {
+	return func(req []byte) [][]byte {
+		if len(req) < stunHeaderLen || binary.BigEndian.Uint16(req) != stunBindingRequest {
+			return nil
+		}
+		answer := append([]byte(nil), req[:stunHeaderLen]...)
+		binary.BigEndian.PutUint16(answer[0:2], stunBindingSuccess)
+		binary.BigEndian.PutUint16(answer[2:4], 12)
+		a := mapped.Addr().As4()
+		answer = append(answer, 0, 0x20, 0, 8, 0, stunFamilyIPv4,
+			byte(mapped.Port()>>8)^0x21, byte(mapped.Port())^0x12,
+			a[0]^0x21, a[1]^0x12, a[2]^0xa4, a[3]^0x42)
+		return [][]byte{answer}
+	}
+}
+
+func TestAnalyzeNATTopology(t *testing.T) {
+	local := netip.MustParseAddr("192.168.1.20")
+	gateway := netip.MustParseAddr("192.168.1.1")
+
+	t.Run("NAT-PMP", func(t *testing.T) {
+		router := udpStandIn(t, "udp4", natPMPRouter(netip.MustParseAddr("100.64.7.8")))
+		topology := analyzeNATTopology(local, gateway, router, false, netip.AddrPort{}, time.Second)
+		if topology.Type != NATCarrierGrade || topology.GatewayProtocol != "NAT-PMP" ||
+			topology.GatewayExternal != netip.MustParseAddr("100.64.7.8") {
+			t.Errorf("Unexpected topology %+v", topology)
+		}
+	})
+
+	t.Run("PCP and STUN", func(t *testing.T) {
+		router := udpStandIn(t, "udp4", pcpRouter(netip.MustParseAddr("203.0.113.5")))
+		stun := udpStandIn(t, "udp4", stunServer(netip.MustParseAddrPort("203.0.113.5:61000")))
+		topology := analyzeNATTopology(local, gateway, router, true, stun, time.Second)
+		if topology.Type != NATSingle || topology.GatewayProtocol != "PCP" ||
+			topology.Public != netip.MustParseAddrPort("203.0.113.5:61000") {
+			t.Errorf("Unexpected topology %+v", topology)
+		}
+	})
+
+	t.Run("PCP not allowed", func(t *testing.T) {
+		router := udpStandIn(t, "udp4", pcpRouter(netip.MustParseAddr("203.0.113.5")))
+		topology := analyzeNATTopology(local, gateway, router, false, netip.AddrPort{}, 100*time.Millisecond)
+		if topology.GatewayExternal.IsValid() || topology.GatewayProtocol != "" {
+			t.Errorf("Unexpected topology %+v", topology)
+		}
+	})
+
+	t.Run("silent router", func(t *testing.T) {
+		router := udpStandIn(t, "udp4", func([]byte) [][]byte { return nil })
+		stun := udpStandIn(t, "udp4", stunServer(netip.MustParseAddrPort("198.51.100.9:61000")))
+		topology := analyzeNATTopology(local, gateway, router, true, stun, 100*time.Millisecond)
+		if topology.Type != NATSingle || topology.GatewayExternal.IsValid() {
+			t.Errorf("Unexpected topology %+v", topology)
+		}
+	})
+}
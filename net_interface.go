@@ -7,6 +7,7 @@ type interfaceGetter interface {
 	InterfaceByName(name string) (*net.Interface, error)
 	InterfaceByIndex(index int) (*net.Interface, error)
 	Addrs(iface *net.Interface) ([]net.Addr, error)
+	Interfaces() ([]net.Interface, error)
 }
 
 // Concrete implementation of above interface
@@ -23,3 +24,7 @@ func (*intefaceGetterImpl) InterfaceByIndex(index int) (*net.Interface, error) {
 func (*intefaceGetterImpl) Addrs(iface *net.Interface) ([]net.Addr, error) {
 	return iface.Addrs()
 }
+
+func (*intefaceGetterImpl) Interfaces() ([]net.Interface, error) {
+	return net.Interfaces()
+}
@@ -0,0 +1,323 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"strconv"
+)
+
+// rtnetlink message types, attributes and values, see rtnetlink(7). They
+// are spelled out here because the messages are parsed on every OS, for
+// example from recordings.
+const (
+	nlmsgHeaderLen = 16
+	nlmsgError     = 2
+	nlmsgDone      = 3
+	rtmNewLink     = 16
+	rtmNewRoute    = 24
+
+	ifinfomsgLen   = 16
+	iflaIfname     = 3
+	iflaMaster     = 10
+	iflaLinkinfo   = 18
+	iflaInfoKind   = 1
+	iflaInfoData   = 2
+	iflaVRFTable   = 1
+	nlaTypeMask    = 0x3fff
+	rtmsgLen       = 12
+	rtaDst         = 1
+	rtaOif         = 4
+	rtaGateway     = 5
+	rtaPriority    = 6
+	rtaPrefsrc     = 7
+	rtaMultipath   = 9
+	rtaTable       = 15
+	rtnexthopLen   = 8
+	rtmFCloned     = 0x200
+	afInet         = 2
+	afInet6        = 10
+	rtTableDefault = 253
+	rtTableMain    = 254
+	rtTableLocal   = 255
+)
+
+// linuxRouteTypes names the types of routes as ip route does.
+var linuxRouteTypes = map[byte]string{
+	1: "unicast", 2: "local", 3: "broadcast", 4: "anycast", 5: "multicast",
+	6: "blackhole", 7: "unreachable", 8: "prohibit", 9: "throw", 10: "nat",
+}
+
+// linuxRouteProtocols names the origins of routes as ip route does.
+// Routes of boot, the default origin, name none.
+var linuxRouteProtocols = map[byte]string{
+	1: "redirect", 2: "kernel", 4: "static", 8: "gated", 9: "ra", 10: "mrt",
+	11: "zebra", 12: "bird", 13: "dnrouted", 14: "xorp", 15: "ntk",
+	16: "dhcp", 17: "mrouted", 42: "babel", 186: "bgp", 187: "isis",
+	188: "ospf", 189: "rip", 192: "eigrp",
+}
+
+// linuxRouteTables names the reserved routing tables as ip route does.
+var linuxRouteTables = map[uint32]string{
+	rtTableDefault: "default", rtTableMain: "main", rtTableLocal: "local",
+}
+
+// netlinkMessage is a message of an rtnetlink dump.
+type netlinkMessage struct {
+	Type uint16
+	Data []byte
+}
+
+// netlinkAttr is a route attribute of a netlink message.
+type netlinkAttr struct {
+	Type  uint16
+	Value []byte
+}
+
+// linuxLink is a network interface as rtnetlink reports it.
+type linuxLink struct {
+	Index  int
+	Name   string
+	Master int
+
+	// Flags are the interface flags (ifi_flags). Unlike those of sysfs and
+	// net.Interface, they include IFF_LOWER_UP.
+	Flags uint32
+
+	// Kind is the kind of virtual interfaces, such as "vrf" or "veth".
+	Kind string
+
+	// VRFTable is the routing table of a VRF device.
+	VRFTable int
+}
+
+// nlmsgAlign rounds n up to the 4 octet alignment of netlink.
+func nlmsgAlign(n int) int {
+	return (n + 3) &^ 3
+}
+
+// parseNetlinkMessages splits an rtnetlink dump, in the byte order of the
+// host that made it, into its messages, up to NLMSG_DONE.
+func parseNetlinkMessages(b []byte, order binary.ByteOrder) ([]netlinkMessage, error) {
+	var result []netlinkMessage
+	for len(b) >= nlmsgHeaderLen {
+		length := int(order.Uint32(b[0:4]))
+		if length < nlmsgHeaderLen || length > len(b) {
+			return nil, &ErrCantParse{}
+		}
+		m := netlinkMessage{Type: order.Uint16(b[4:6]), Data: b[nlmsgHeaderLen:length]}
+		switch m.Type {
+		case nlmsgDone:
+			return result, nil
+		case nlmsgError:
+			return nil, &ErrCantParse{}
+		}
+		result = append(result, m)
+		b = b[min(nlmsgAlign(length), len(b)):]
+	}
+	if len(b) != 0 {
+		return nil, &ErrCantParse{}
+	}
+	return result, nil
+}
+
+// parseNetlinkAttrs splits the attributes of a message, dropping the
+// nested and byte order flags from their types.
+func parseNetlinkAttrs(b []byte, order binary.ByteOrder) ([]netlinkAttr, bool) {
+	var result []netlinkAttr
+	for len(b) >= 4 {
+		length := int(order.Uint16(b[0:2]))
+		if length < 4 || length > len(b) {
+			return nil, false
+		}
+		result = append(result, netlinkAttr{Type: order.Uint16(b[2:4]) & nlaTypeMask, Value: b[4:length]})
+		b = b[min(nlmsgAlign(length), len(b)):]
+	}
+	return result, true
+}
+
+// parseLinuxLinks returns the interfaces of the RTM_NEWLINK messages of a
+// dump.
+func parseLinuxLinks(msgs []netlinkMessage, order binary.ByteOrder) ([]linuxLink, error) {
+	var result []linuxLink
+	for _, m := range msgs {
+		if m.Type != rtmNewLink {
+			continue
+		}
+		if len(m.Data) < ifinfomsgLen {
+			return nil, &ErrCantParse{}
+		}
+		// struct ifinfomsg: family, pad, type, index, flags, change.
+		link := linuxLink{
+			Index: int(int32(order.Uint32(m.Data[4:8]))),
+			Flags: order.Uint32(m.Data[8:12]),
+		}
+		attrs, ok := parseNetlinkAttrs(m.Data[ifinfomsgLen:], order)
+		if !ok {
+			return nil, &ErrCantParse{}
+		}
+		for _, a := range attrs {
+			switch a.Type {
+			case iflaIfname:
+				link.Name = string(bytes.TrimRight(a.Value, "\x00"))
+			case iflaMaster:
+				if len(a.Value) == 4 {
+					link.Master = int(order.Uint32(a.Value))
+				}
+			case iflaLinkinfo:
+				info, _ := parseNetlinkAttrs(a.Value, order)
+				for _, i := range info {
+					switch i.Type {
+					case iflaInfoKind:
+						link.Kind = string(bytes.TrimRight(i.Value, "\x00"))
+					case iflaInfoData:
+						data, _ := parseNetlinkAttrs(i.Value, order)
+						for _, d := range data {
+							if d.Type == iflaVRFTable && len(d.Value) == 4 {
+								link.VRFTable = int(order.Uint32(d.Value))
+							}
+						}
+					}
+				}
+			}
+		}
+		if link.Kind != "vrf" {
+			link.VRFTable = 0
+		}
+		result = append(result, link)
+	}
+	return result, nil
+}
+
+// linuxLinkFlags returns the flags of links by name.
+func linuxLinkFlags(links []linuxLink) map[string]uint32 {
+	flags := make(map[string]uint32)
+	for _, l := range links {
+		flags[l.Name] = l.Flags
+	}
+	return flags
+}
+
+// linuxVRFs returns the VRF devices of links.
+func linuxVRFs(links []linuxLink) []VRF {
+	var result []VRF
+	for _, l := range links {
+		if l.Kind == "vrf" {
+			result = append(result, VRF{Name: l.Name, Index: l.Index, Table: l.VRFTable})
+		}
+	}
+	return result
+}
+
+// parseLinuxRouteMessages returns the routes of the RTM_NEWROUTE messages
+// of a dump of all tables, naming interfaces after links and tagging the
+// routes of VRF tables with their VRF. Cached routes are left out.
+func parseLinuxRouteMessages(msgs []netlinkMessage, order binary.ByteOrder, links []linuxLink) ([]Route, error) {
+	names := make(map[int]string)
+	for _, l := range links {
+		names[l.Index] = l.Name
+	}
+
+	var result []Route
+	for _, m := range msgs {
+		if m.Type != rtmNewRoute {
+			continue
+		}
+		if len(m.Data) < rtmsgLen {
+			return nil, &ErrCantParse{}
+		}
+		// struct rtmsg: family, dst_len, src_len, tos, table, protocol,
+		// scope, type, flags.
+		family, dstLen, table, protocol, routeType := m.Data[0], int(m.Data[1]), uint32(m.Data[4]), m.Data[5], m.Data[7]
+		if order.Uint32(m.Data[8:12])&rtmFCloned != 0 || (family != afInet && family != afInet6) {
+			continue
+		}
+		attrs, ok := parseNetlinkAttrs(m.Data[rtmsgLen:], order)
+		if !ok {
+			return nil, &ErrCantParse{}
+		}
+
+		dst := netip.IPv4Unspecified()
+		if family == afInet6 {
+			dst = netip.IPv6Unspecified()
+		}
+		r := Route{Protocol: linuxRouteProtocols[protocol], Type: linuxRouteTypes[routeType]}
+		var gateway netip.Addr
+		for _, a := range attrs {
+			switch a.Type {
+			case rtaDst:
+				if addr, ok := netip.AddrFromSlice(a.Value); ok {
+					dst = addr
+				}
+			case rtaOif:
+				if len(a.Value) == 4 {
+					r.InterfaceIndex = int(order.Uint32(a.Value))
+				}
+			case rtaGateway:
+				gateway, _ = netip.AddrFromSlice(a.Value)
+			case rtaPriority:
+				if len(a.Value) == 4 {
+					r.Metric = int(order.Uint32(a.Value))
+				}
+			case rtaPrefsrc:
+				r.Source, _ = netip.AddrFromSlice(a.Value)
+			case rtaTable:
+				if len(a.Value) == 4 {
+					table = order.Uint32(a.Value)
+				}
+			case rtaMultipath:
+				nexthops, ok := parseLinuxNexthops(a.Value, order, names)
+				if !ok {
+					return nil, &ErrCantParse{}
+				}
+				r.Nexthops = nexthops
+			}
+		}
+		r.Destination = netip.PrefixFrom(dst, dstLen).Masked()
+		if !r.Destination.IsValid() {
+			return nil, &ErrCantParse{}
+		}
+		r.Interface = names[r.InterfaceIndex]
+		r.Gateway = withZone(gateway, r.Interface)
+		if len(r.Nexthops) > 0 && !r.Gateway.IsValid() && r.Interface == "" {
+			r.Gateway, r.Interface = r.Nexthops[0].Gateway, r.Nexthops[0].Interface
+		}
+		r.Table = linuxRouteTables[table]
+		if r.Table == "" {
+			r.Table = strconv.FormatUint(uint64(table), 10)
+		}
+		result = append(result, r)
+	}
+	tagVRFRoutes(result, linuxVRFs(links))
+	return result, nil
+}
+
+// parseLinuxNexthops parses the struct rtnexthop entries of RTA_MULTIPATH.
+func parseLinuxNexthops(b []byte, order binary.ByteOrder, names map[int]string) ([]Nexthop, bool) {
+	var result []Nexthop
+	for len(b) >= rtnexthopLen {
+		// struct rtnexthop: len, flags, hops, ifindex.
+		length := int(order.Uint16(b[0:2]))
+		if length < rtnexthopLen || length > len(b) {
+			return nil, false
+		}
+		nh := Nexthop{
+			Interface: names[int(order.Uint32(b[4:8]))],
+			// The kernel keeps the weight less one.
+			Weight: int(b[3]) + 1,
+		}
+		attrs, ok := parseNetlinkAttrs(b[rtnexthopLen:length], order)
+		if !ok {
+			return nil, false
+		}
+		for _, a := range attrs {
+			if a.Type == rtaGateway {
+				gateway, _ := netip.AddrFromSlice(a.Value)
+				nh.Gateway = withZone(gateway, nh.Interface)
+			}
+		}
+		result = append(result, nh)
+		b = b[min(nlmsgAlign(length), len(b)):]
+	}
+	return result, true
+}
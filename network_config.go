@@ -0,0 +1,190 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// NetworkConfig is the configuration of the network through which the
+// host reaches the Internet in one address family, as needed to bootstrap
+// networking code. Fields the OS doesn't report are left zero.
+type NetworkConfig struct {
+	// Gateway is the gateway of the preferred default route. It is the
+	// zero Addr for on-link default routes.
+	Gateway netip.Addr
+
+	// Interface is the name of the interface of that route.
+	Interface string
+
+	// Address is the host's address on that interface, with the length
+	// of its subnet.
+	Address netip.Prefix
+
+	// MTU is the MTU of the interface.
+	MTU int
+
+	// DNS holds the DNS servers of the address family, in order of
+	// preference.
+	DNS []netip.Addr
+
+	// SearchDomains holds the domains searched for names that aren't
+	// fully qualified.
+	SearchDomains []string
+}
+
+// DNSConfig is the DNS configuration of a host or of an interface.
+type DNSConfig struct {
+	// Servers holds the DNS servers, in order of preference.
+	Servers []netip.Addr
+
+	// Search holds the search domains.
+	Search []string
+}
+
+// DiscoverNetworkConfig is the OS independent function to get the
+// configuration of the network used for IPv4 (or IPv6) traffic to the
+// Internet in one call: gateway, interface, address and subnet, MTU, DNS
+// servers and search domains. The DNS configuration is read from
+// resolv.conf, or from ipconfig /all on Windows; it is empty if that
+// fails.
+func DiscoverNetworkConfig(ipv6 bool) (NetworkConfig, error) {
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return NetworkConfig{}, err
+	}
+	dns, _ := discoverDNSConfigOSSpecific()
+	return newNetworkConfig(s, ipv6, &intefaceGetterImpl{}, dns)
+}
+
+// dnsConfigs is the DNS configuration of a host, with that of the
+// interfaces where the OS keeps them apart (Windows).
+type dnsConfigs struct {
+	global     DNSConfig
+	interfaces map[string]DNSConfig
+}
+
+// forInterface returns the DNS configuration that applies to the named
+// interface: its own servers, if any, and the host's search domains
+// followed by the interface's own.
+func (c dnsConfigs) forInterface(name string) DNSConfig {
+	result := DNSConfig{
+		Servers: c.global.Servers,
+		Search:  slices.Clone(c.global.Search),
+	}
+	if iface, ok := c.interfaces[name]; ok {
+		if len(iface.Servers) > 0 {
+			result.Servers = iface.Servers
+		}
+		for _, domain := range iface.Search {
+			if !slices.Contains(result.Search, domain) {
+				result.Search = append(result.Search, domain)
+			}
+		}
+	}
+	return result
+}
+
+func newNetworkConfig(s Snapshot, ipv6 bool, ifaceGetter interfaceGetter, dns dnsConfigs) (NetworkConfig, error) {
+	r, ok := s.preferredDefaultRoute(ipv6)
+	if !ok {
+		return NetworkConfig{}, &ErrNoGateway{}
+	}
+	config := NetworkConfig{
+		Gateway:   r.Gateway,
+		Interface: r.Interface,
+		Address:   pickPrefix(s.addrs[r.Interface], r.Source, ipv6),
+	}
+	if r.Interface != "" {
+		if iface, err := ifaceGetter.InterfaceByName(r.Interface); err == nil {
+			config.MTU = iface.MTU
+		}
+	}
+
+	d := dns.forInterface(r.Interface)
+	for _, server := range d.Servers {
+		if server.Unmap().Is6() == ipv6 {
+			config.DNS = append(config.DNS, server)
+		}
+	}
+	config.SearchDomains = d.Search
+	return config, nil
+}
+
+// pickPrefix returns the address of addrs that matches source, or else
+// the first one of the family, preferring global IPv6 addresses over
+// link-local ones.
+func pickPrefix(addrs []net.Addr, source netip.Addr, ipv6 bool) netip.Prefix {
+	var candidates []netip.Prefix
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		addr, ok := ipToAddr(ipNet.IP)
+		if !ok || addr.Is6() != ipv6 {
+			continue
+		}
+		bits, _ := ipNet.Mask.Size()
+		prefix := netip.PrefixFrom(addr, bits)
+		if source.IsValid() && addr == source.WithZone("") {
+			return prefix
+		}
+		candidates = append(candidates, prefix)
+	}
+	for _, p := range candidates {
+		if !p.Addr().IsLinkLocalUnicast() {
+			return p
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	if source.IsValid() {
+		return netip.PrefixFrom(source.WithZone(""), source.BitLen())
+	}
+	return netip.Prefix{}
+}
+
+// ParseResolvConf parses resolv.conf(5). Lines it doesn't know are
+// skipped, so the result is empty rather than an error for other files.
+func ParseResolvConf(data []byte) DNSConfig {
+	var config DNSConfig
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			if addr, err := netip.ParseAddr(fields[1]); err == nil {
+				config.Servers = append(config.Servers, addr)
+			}
+		case "search":
+			// The last search or domain line wins.
+			config.Search = slices.Clone(fields[1:])
+		case "domain":
+			config.Search = []string{fields[1]}
+		}
+	}
+	return config
+}
+
+// usesResolvedStub reports whether the resolv.conf of config points at
+// the local stub resolver of systemd-resolved, whose upstream servers are
+// listed in /run/systemd/resolve/resolv.conf.
+func usesResolvedStub(config DNSConfig) bool {
+	if len(config.Servers) == 0 {
+		return false
+	}
+	for _, server := range config.Servers {
+		if server != netip.MustParseAddr("127.0.0.53") && server != netip.MustParseAddr("127.0.0.54") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseResolvConf(t *testing.T) {
+	type testcase struct {
+		tableName string
+		want      DNSConfig
+		stub      bool
+	}
+	testcases := []testcase{
+		{resolvConf, DNSConfig{
+			Servers: []netip.Addr{
+				netip.MustParseAddr("192.168.1.1"),
+				netip.MustParseAddr("2001:db8:1::53"),
+				netip.MustParseAddr("fe80::1%eth0"),
+			},
+			Search: []string{"corp.example.com", "example.com"},
+		}, false},
+		{resolvConfStub, DNSConfig{
+			Servers: []netip.Addr{netip.MustParseAddr("127.0.0.53")},
+			Search:  []string{"lan"},
+		}, true},
+		{resolvConfResolved, DNSConfig{
+			Servers: []netip.Addr{netip.MustParseAddr("192.168.1.1"), netip.MustParseAddr("fd00::1")},
+			Search:  []string{"lan"},
+		}, false},
+		{randomData, DNSConfig{}, false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			got := ParseResolvConf(routeTables[tc.tableName])
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Unexpected DNS config %+v", got)
+			}
+			if usesResolvedStub(got) != tc.stub {
+				t.Errorf("Unexpected stub detection for %+v", got)
+			}
+		})
+	}
+}
+
+func TestParseWindowsIPConfig(t *testing.T) {
+	config, err := ParseWindowsIPConfig(routeTables[windowsIPConfigAll])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := WindowsIPConfig{
+		SearchList: []string{"corp.example.com", "example.com"},
+		Adapters: []WindowsAdapter{
+			{
+				Name:        "Ethernet",
+				Description: "Intel(R) Ethernet Connection (7) I219-LM",
+				DNSSuffix:   "lan",
+				DNSServers:  []netip.Addr{netip.MustParseAddr("192.168.1.1"), netip.MustParseAddr("fd00::1")},
+			},
+			{
+				Name:        "Wi-Fi",
+				Description: "Intel(R) Wi-Fi 6 AX201 160MHz",
+			},
+			{
+				Name:        "OpenVPN Wintun",
+				Description: "Wintun Userspace Tunnel",
+				DNSSuffix:   "vpn.example.com",
+				DNSServers:  []netip.Addr{netip.MustParseAddr("10.8.0.1")},
+			},
+		},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Run(windowsIPConfigAllGerman, func(t *testing.T) {
+		config, err := ParseWindowsIPConfig(routeTables[windowsIPConfigAllGerman])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := WindowsIPConfig{
+			SearchList: []string{"fritz.box"},
+			Adapters: []WindowsAdapter{{
+				Name:        "Ethernet",
+				Description: "Realtek PCIe GbE Family Controller",
+				DNSSuffix:   "fritz.box",
+				DNSServers:  []netip.Addr{netip.MustParseAddr("192.168.178.1")},
+			}},
+		}
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("Unexpected config %+v", config)
+		}
+	})
+
+	t.Run(windowsIPConfigAllFrench, func(t *testing.T) {
+		config, err := ParseWindowsIPConfig(routeTables[windowsIPConfigAllFrench])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := WindowsIPConfig{
+			SearchList: []string{"home"},
+			Adapters: []WindowsAdapter{
+				{
+					Name:        "Ethernet",
+					Description: "Realtek PCIe GbE Family Controller",
+					DNSSuffix:   "home",
+					DNSServers:  []netip.Addr{netip.MustParseAddr("192.168.1.254"), netip.MustParseAddr("2a01:cb00::1")},
+				},
+				{
+					Name:        "Wi-Fi",
+					Description: "Intel(R) Wi-Fi 6 AX201 160MHz",
+				},
+				{
+					Name:        "Teredo Tunneling Pseudo-Interface",
+					Description: "Microsoft Teredo Tunneling Adapter",
+				},
+			},
+		}
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("Unexpected config %+v", config)
+		}
+		if _, ok := config.dnsConfigs().interfaces["Ethernet"]; !ok {
+			t.Errorf("Expected the DNS configuration of Ethernet")
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := ParseWindowsIPConfig(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestNetworkConfig(t *testing.T) {
+	routes, err := ParseIPRoute(routeTables[linuxIPRouteText], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes6, err := ParseIPRoute(routeTables[linuxIPRoute6Text], true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes = append(routes, routes6...)
+
+	eth0 := &net.Interface{Index: 2, Name: "eth0", MTU: 1500, Flags: net.FlagUp | net.FlagRunning}
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByName", "eth0").Return(eth0, nil)
+	mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+	mockGetter.On("Addrs", eth0).Return([]net.Addr{
+		&net.IPNet{IP: net.ParseIP("fe80::8c3a:21ff:fe4b:1c2d"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("2001:db8:1::10"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("192.168.1.10").To4(), Mask: net.CIDRMask(24, 32)},
+	}, nil)
+	s := newSnapshot(routes, mockGetter)
+	dns := dnsConfigs{global: ParseResolvConf(routeTables[resolvConf])}
+
+	config, err := newNetworkConfig(s, false, mockGetter, dns)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := NetworkConfig{
+		Gateway:       netip.MustParseAddr("192.168.1.1"),
+		Interface:     "eth0",
+		Address:       netip.MustParsePrefix("192.168.1.10/24"),
+		MTU:           1500,
+		DNS:           []netip.Addr{netip.MustParseAddr("192.168.1.1")},
+		SearchDomains: []string{"corp.example.com", "example.com"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("Unexpected IPv4 config %+v", config)
+	}
+
+	config, err = newNetworkConfig(s, true, mockGetter, dns)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Address != netip.MustParsePrefix("2001:db8:1::10/64") || len(config.DNS) != 2 || config.MTU != 1500 {
+		t.Errorf("Unexpected IPv6 config %+v", config)
+	}
+
+	t.Run("windows adapters", func(t *testing.T) {
+		ipconfig, err := ParseWindowsIPConfig(routeTables[windowsIPConfigAll])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		d := ipconfig.dnsConfigs().forInterface("Ethernet")
+		want := DNSConfig{
+			Servers: []netip.Addr{netip.MustParseAddr("192.168.1.1"), netip.MustParseAddr("fd00::1")},
+			Search:  []string{"corp.example.com", "example.com", "lan"},
+		}
+		if !reflect.DeepEqual(d, want) {
+			t.Errorf("Unexpected DNS config %+v", d)
+		}
+	})
+
+	t.Run("no route", func(t *testing.T) {
+		if _, err := newNetworkConfig(newSnapshot(nil, mockGetter), false, mockGetter, dns); !errors.Is(err, &ErrNoGateway{}) {
+			t.Errorf("Expected ErrNoGateway, got %v", err)
+		}
+	})
+}
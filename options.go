@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"net"
+	"path"
+	"slices"
+)
+
+// Family selects the address families of a discovery.
+type Family int
+
+const (
+	// FamilyAny selects both IPv4 and IPv6.
+	FamilyAny Family = iota
+	// FamilyIPv4 selects IPv4 only.
+	FamilyIPv4
+	// FamilyIPv6 selects IPv6 only.
+	FamilyIPv6
+)
+
+// Options restrict discovery to some of the routes, for example to keep
+// the default routes of container bridges and virtual machine hosts from
+// winning over the uplink. The zero Options select all routes.
+//
+// Options apply to the Discoverers of NewDiscoverer and
+// NewFilteringDiscoverer, which answer from snapshots of every backend,
+// and to Snapshot.Filter. The package-level Discover functions take no
+// options and answer from all routes.
+type Options struct {
+	// IncludeInterfaces, if not empty, selects the routes whose
+	// interface name matches one of these patterns. Patterns use the
+	// syntax of path.Match, such as "eth*" or "en?".
+	IncludeInterfaces []string
+
+	// ExcludeInterfaces removes the routes whose interface name matches
+	// one of these patterns, such as "docker*" or "cni*".
+	ExcludeInterfaces []string
+
+	// ExcludeKinds removes the routes whose interface is of one of these
+	// kinds, such as InterfaceKindBridge and InterfaceKindVirtual.
+	ExcludeKinds []InterfaceKind
+
+	// RequireGlobalSource removes the routes without a global unicast
+	// source address: the route's Source or an address of its interface.
+	// An IPv6 default route on an interface with only a link-local
+	// address can't reach the Internet. Routes whose source addresses
+	// aren't known are removed too.
+	RequireGlobalSource bool
+
+	// ExcludeDownLinks removes the routes on interfaces that are down or
+	// have no carrier. Without it, such default routes are only tried
+	// after the others.
+	ExcludeDownLinks bool
+
+	// Family selects the address families.
+	Family Family
+}
+
+// Filter returns a copy of s without the routes opts exclude, so that all
+// answers of the copy honor opts.
+func (s Snapshot) Filter(opts Options) Snapshot {
+	s = cloneSnapshot(s)
+	looked := make(map[string][]net.Addr)
+	addrsOf := func(name string) []net.Addr {
+		if addrs, ok := s.addrs[name]; ok {
+			return addrs
+		}
+		if addrs, ok := looked[name]; ok {
+			return addrs
+		}
+		var addrs []net.Addr
+		if name != "" && s.ifaceGetter != nil {
+			if iface, err := s.ifaceGetter.InterfaceByName(name); err == nil {
+				addrs, _ = s.ifaceGetter.Addrs(iface)
+			}
+		}
+		looked[name] = addrs
+		return addrs
+	}
+	excluded := func(r Route) bool {
+		// Only routes without a source need the addresses of their
+		// interface, which the snapshot holds for default routes only.
+		var addrs []net.Addr
+		if opts.RequireGlobalSource && !r.Source.IsValid() {
+			addrs = addrsOf(r.Interface)
+		}
+		return !opts.match(r, addrs)
+	}
+	s.Routes = slices.DeleteFunc(s.Routes, excluded)
+	s.Persistent = slices.DeleteFunc(s.Persistent, excluded)
+	return s
+}
+
+// match reports whether opts select r. addrs are the addresses of r's
+// interface, if known.
+func (opts Options) match(r Route, addrs []net.Addr) bool {
+	switch {
+	case opts.Family == FamilyIPv4 && r.Is6():
+		return false
+	case opts.Family == FamilyIPv6 && !r.Is6():
+		return false
+	case slices.Contains(opts.ExcludeKinds, r.Kind):
+		return false
+	case len(opts.IncludeInterfaces) > 0 && !matchInterface(opts.IncludeInterfaces, r.Interface):
+		return false
+	case matchInterface(opts.ExcludeInterfaces, r.Interface):
+		return false
+	case opts.RequireGlobalSource && !hasGlobalSource(r, addrs):
+		return false
+	case opts.ExcludeDownLinks && r.LinkState.dead():
+		return false
+	}
+	return true
+}
+
+// matchInterface reports whether name matches one of patterns. Malformed
+// patterns match nothing.
+func matchInterface(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGlobalSource reports whether r has a global unicast source address of
+// its family, given the addresses of its interface.
+func hasGlobalSource(r Route, addrs []net.Addr) bool {
+	if r.Source.IsValid() {
+		return r.Source.IsGlobalUnicast()
+	}
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		addr, ok := ipToAddr(ipNet.IP)
+		if ok && addr.Is6() == r.Is6() && addr.IsGlobalUnicast() {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDiscoverer returns a Discoverer that queries the operating system and
+// answers from the routes that opts select. Unlike the package-level
+// Discover functions, it reads a whole snapshot for every answer.
+func NewDiscoverer(opts Options) Discoverer {
+	return NewFilteringDiscoverer(osDiscoverer{}, opts)
+}
+
+// NewFilteringDiscoverer returns a Discoverer that answers from the
+// snapshots of backend, such as a CachingDiscoverer, restricted to the
+// routes that opts select.
+func NewFilteringDiscoverer(backend Discoverer, opts Options) Discoverer {
+	return &filteringDiscoverer{backend: backend, opts: opts}
+}
+
+type filteringDiscoverer struct {
+	backend Discoverer
+	opts    Options
+}
+
+func (d *filteringDiscoverer) DiscoverGateways() ([]net.IP, error) {
+	s, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.Gateways()
+}
+
+func (d *filteringDiscoverer) DiscoverInterface() (net.IP, error) {
+	s, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.Interface()
+}
+
+func (d *filteringDiscoverer) DiscoverGatewaysIPv6() ([]net.IP, error) {
+	s, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.GatewaysIPv6()
+}
+
+func (d *filteringDiscoverer) DiscoverInterfaceIPv6() (net.IP, error) {
+	s, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.InterfaceIPv6()
+}
+
+func (d *filteringDiscoverer) Snapshot() (Snapshot, error) {
+	s, err := d.backend.Snapshot()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return s.Filter(d.opts), nil
+}
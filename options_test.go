@@ -0,0 +1,129 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// snapshotDiscoverer is a Discoverer whose snapshots are fixed.
+type snapshotDiscoverer struct {
+	osDiscoverer
+	s Snapshot
+}
+
+func (d snapshotDiscoverer) Snapshot() (Snapshot, error) {
+	return cloneSnapshot(d.s), nil
+}
+
+func TestOptions(t *testing.T) {
+	routes, err := ParseIPRoute(routeTables[linuxIPRouteContainers], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByName", mock.Anything).Return(nil, errors.New("no such interface"))
+	s := newSnapshot(routes, mockGetter)
+
+	// Unfiltered, the flannel route wins.
+	if ips, _ := s.Gateways(); ips[0].String() != "10.244.0.1" {
+		t.Fatalf("Unexpected gateways %v", ips)
+	}
+
+	testcases := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"zero", Options{}, []string{"10.244.0.1", "192.168.122.1", "10.88.0.1", "192.168.1.1"}},
+		{"exclude kinds", Options{ExcludeKinds: []InterfaceKind{InterfaceKindBridge, InterfaceKindVirtual}}, []string{"192.168.1.1"}},
+		{"exclude interfaces", Options{ExcludeInterfaces: []string{"virbr*", "cni*", "flannel*"}}, []string{"192.168.1.1"}},
+		{"include interfaces", Options{IncludeInterfaces: []string{"eth*", "virbr?"}}, []string{"192.168.122.1", "192.168.1.1"}},
+		{"include and exclude", Options{IncludeInterfaces: []string{"*"}, ExcludeInterfaces: []string{"flannel.1"}}, []string{"192.168.122.1", "10.88.0.1", "192.168.1.1"}},
+		// Only the eth0 route names a source; the interfaces of the others
+		// are unknown to the mock.
+		{"global source", Options{RequireGlobalSource: true}, []string{"192.168.1.1"}},
+		{"IPv4", Options{Family: FamilyIPv4}, []string{"10.244.0.1", "192.168.122.1", "10.88.0.1", "192.168.1.1"}},
+		{"IPv6", Options{Family: FamilyIPv6}, nil},
+		{"bad pattern", Options{ExcludeInterfaces: []string{"["}}, []string{"10.244.0.1", "192.168.122.1", "10.88.0.1", "192.168.1.1"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewFilteringDiscoverer(snapshotDiscoverer{s: s}, tc.opts)
+			ips, err := d.DiscoverGateways()
+			if tc.want == nil {
+				if !errors.Is(err, &ErrNoGateway{}) {
+					t.Errorf("Expected ErrNoGateway, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(ips) != len(tc.want) {
+				t.Fatalf("Unexpected gateways %v", ips)
+			}
+			for i := range ips {
+				if ips[i].String() != tc.want[i] {
+					t.Errorf("Unexpected gateway %v != %s", ips[i], tc.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("interface", func(t *testing.T) {
+		d := NewFilteringDiscoverer(snapshotDiscoverer{s: s}, Options{ExcludeKinds: []InterfaceKind{InterfaceKindBridge, InterfaceKindVirtual}})
+		if ip, err := d.DiscoverInterface(); err != nil || ip.String() != "192.168.1.10" {
+			t.Errorf("Unexpected interface address %v, %v", ip, err)
+		}
+	})
+
+	t.Run("global source of the interface", func(t *testing.T) {
+		route := s.Routes[0]
+		route.Source = netip.Addr{}
+		addrs := []net.Addr{
+			&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+			&net.IPNet{IP: net.ParseIP("10.244.0.5"), Mask: net.CIDRMask(24, 32)},
+		}
+		if !hasGlobalSource(route, addrs) || hasGlobalSource(route, addrs[:1]) {
+			t.Errorf("Unexpected global source of %+v", route)
+		}
+	})
+
+	t.Run("global source of other routes", func(t *testing.T) {
+		// The snapshot holds the addresses of default route interfaces
+		// only; the others are looked up.
+		mockGetter := newMockinterfaceGetter(t)
+		for _, name := range []string{"eth0", "eth1", "eth2"} {
+			mockGetter.On("InterfaceByName", name).Return(&net.Interface{Name: name}, nil).Once()
+		}
+		mockGetter.On("Addrs", mock.MatchedBy(func(iface *net.Interface) bool { return iface.Name == "eth0" })).Return([]net.Addr{
+			&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+		}, nil).Once()
+		mockGetter.On("Addrs", mock.MatchedBy(func(iface *net.Interface) bool { return iface.Name == "eth1" })).Return([]net.Addr{
+			&net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(8, 32)},
+		}, nil).Once()
+		mockGetter.On("Addrs", mock.MatchedBy(func(iface *net.Interface) bool { return iface.Name == "eth2" })).Return([]net.Addr{
+			&net.IPNet{IP: net.ParseIP("fe80::2"), Mask: net.CIDRMask(64, 128)},
+		}, nil).Once()
+		s := newSnapshot([]Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.1"), Interface: "eth0"},
+			{Destination: netip.MustParsePrefix("10.0.0.0/8"), Interface: "eth1"},
+			{Destination: netip.MustParsePrefix("2001:db8:5::/64"), Interface: "eth2"},
+		}, mockGetter)
+
+		filtered := s.Filter(Options{RequireGlobalSource: true})
+		if len(filtered.Routes) != 2 || filtered.Routes[1].Interface != "eth1" {
+			t.Errorf("Unexpected routes %+v", filtered.Routes)
+		}
+	})
+
+	// Filtering leaves the original snapshot alone.
+	s.Filter(Options{Family: FamilyIPv6})
+	if len(s.Routes) != len(routes) {
+		t.Errorf("Filter modified the snapshot")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package gateway
+
+// discoverPolicyRoutesOSSpecific lists routes of routing tables other than
+// the main one. Only Linux keeps such tables apart.
+func discoverPolicyRoutesOSSpecific() ([]Route, error) {
+	return nil, nil
+}
+
+// discoverPolicyRulesOSSpecific lists the policy rules of one address
+// family. Only Linux has them.
+func discoverPolicyRulesOSSpecific(ipv6 bool) ([]PolicyRule, error) {
+	return nil, nil
+}
+
+// discoverVRFRoutesOSSpecific lists the VRF devices and the routes of
+// their tables. Only Linux has VRFs.
+func discoverVRFRoutesOSSpecific() ([]VRF, []Route, error) {
+	return nil, nil, &ErrNotImplemented{}
+}
@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+)
+
+// Route is an entry of an operating system routing table.
+type Route struct {
+	// Destination is the network reached through this route. Default
+	// routes have a prefix length of zero.
+	Destination netip.Prefix
+
+	// Gateway is the next hop. It is the zero Addr for routes to
+	// directly connected (on-link) networks.
+	Gateway netip.Addr
+
+	// Interface is the name of the outgoing interface, if known.
+	Interface string
+
+	// InterfaceIndex is the index of the outgoing interface, or zero if
+	// unknown.
+	InterfaceIndex int
+
+	// InterfaceDescription is the description of the outgoing interface,
+	// for route tables that report one. Windows' route print lists the
+	// adapter descriptions, such as "Intel(R) Ethernet Connection
+	// I219-LM", in its Interface List.
+	InterfaceDescription string
+
+	// HardwareAddr is the hardware address of the outgoing interface, if
+	// known.
+	HardwareAddr net.HardwareAddr
+
+	// Kind is the kind of the outgoing interface, such as a tunnel of a
+	// VPN, as far as it can be told.
+	Kind InterfaceKind
+
+	// LinkState is the operational state of the outgoing interface, if
+	// known. Default routes on interfaces that are down or have no
+	// carrier are tried last.
+	LinkState LinkState
+
+	// Source is the local address used for this route, for route tables
+	// that report one. Windows' route print does so in its "Interface"
+	// column.
+	Source netip.Addr
+
+	// Metric is the metric reported by the OS. Lower is preferred.
+	Metric int
+
+	// InterfaceMetric is the metric of the outgoing interface, for
+	// route tables that report it separately (Windows' Get-NetRoute and
+	// netsh). Windows' route print reports only the sum, in Metric.
+	InterfaceMetric int
+
+	// Protocol is the origin of the route as named by the OS, such as
+	// "Dhcp" or "NetMgmt" (Get-NetRoute) or "Manual" and "System"
+	// (netsh), if known.
+	Protocol string
+
+	// Table is the routing table holding the route, such as "main" or
+	// "local" on Linux, for route tables that report one.
+	Table string
+
+	// VRF is the Linux VRF (l3mdev) device whose routing table holds the
+	// route, or empty for routes of the default VRF.
+	VRF string
+
+	// Type is the type of the route, such as "unicast", "local" or
+	// "blackhole" on Linux, for route tables that report one. Only
+	// unicast routes forward packets to a gateway.
+	Type string
+
+	// Flags holds the flags of the route as named by the OS, such as
+	// "onlink" or "linkdown" for Linux' ip route.
+	Flags []string
+
+	// Nexthops holds the next hops of a multipath route. Gateway and
+	// Interface are those of the first next hop.
+	Nexthops []Nexthop
+
+	// Persistent reports whether the route is configured to survive
+	// restarts, as listed under "Persistent Routes" by Windows' route
+	// print.
+	Persistent bool
+
+	// Inactive reports whether a persistent route is configured but not
+	// currently in effect, for example because its interface is disabled.
+	Inactive bool
+}
+
+// Nexthop is one of the next hops of a multipath route.
+type Nexthop struct {
+	// Gateway is the next hop. It is the zero Addr for on-link next hops.
+	Gateway netip.Addr
+
+	// Interface is the name of the outgoing interface, if known.
+	Interface string
+
+	// Weight is the share of the traffic sent to this next hop relative
+	// to the others.
+	Weight int
+
+	// Flags holds the flags of the next hop as named by the OS.
+	Flags []string
+}
+
+// IsDefault reports whether r is a default route (0.0.0.0/0 or ::/0).
+func (r Route) IsDefault() bool {
+	return r.Destination.IsValid() && r.Destination.Bits() == 0
+}
+
+// EffectiveMetric returns the metric the OS compares when choosing between
+// routes. Windows adds the interface metric to the route metric; elsewhere
+// this is Metric.
+func (r Route) EffectiveMetric() int {
+	return r.Metric + r.InterfaceMetric
+}
+
+// Is6 reports whether r is an IPv6 route.
+func (r Route) Is6() bool {
+	return r.Destination.Addr().Is6() && !r.Destination.Addr().Is4In6()
+}
+
+// forwardsByDefault reports whether r is a default route that the OS uses
+// for packets without a more specific route: a unicast route of the main
+// table, for route tables that have several. Routes scoped to their
+// interface only carry traffic bound to it.
+func (r Route) forwardsByDefault() bool {
+	return r.IsDefault() && r.forwards() && !slices.Contains(r.Flags, ifscopeFlag)
+}
+
+// forwards reports whether r is a unicast route of the main table.
+func (r Route) forwards() bool {
+	return (r.Type == "" || r.Type == "unicast") &&
+		(r.Table == "" || r.Table == "main")
+}
+
+// gateways returns the gateways of r, which are those of its next hops
+// for multipath routes.
+func (r Route) gateways() []netip.Addr {
+	var result []netip.Addr
+	if r.Gateway.IsValid() {
+		result = append(result, r.Gateway)
+	}
+	for _, nh := range r.Nexthops {
+		if nh.Gateway.IsValid() && nh.Gateway != r.Gateway {
+			result = append(result, nh.Gateway)
+		}
+	}
+	return result
+}
+
+// addrToIP converts addr to a net.IP, dropping the zone.
+func addrToIP(addr netip.Addr) net.IP {
+	return net.IP(addr.AsSlice())
+}
+
+// ipToAddr converts ip to a netip.Addr, unmapping IPv4-mapped IPv6 addresses.
+func ipToAddr(ip net.IP) (netip.Addr, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	return addr.Unmap(), ok
+}
@@ -0,0 +1,676 @@
+package gateway
+
+// The parsers in this file turn a complete routing table into Routes,
+// unlike those in gateway_parsers.go which only extract default gateways.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Route flags of /proc/net/route and /proc/net/ipv6_route, see route(8).
+const (
+	linuxRTFUp      = 0x0001
+	linuxRTFGateway = 0x0002
+	linuxRTFReject  = 0x0200
+	linuxRTFLocal   = 0x80000000
+)
+
+// ParseLinuxProcNetRoute parses /proc/net/route, for example from a
+// support bundle. The kernel prints addresses as numbers in the byte order
+// of the machine the file was read on, so a dump from a big-endian router
+// (s390x, most MIPS and PowerPC) needs binary.BigEndian. A nil order is
+// that of this machine.
+func ParseLinuxProcNetRoute(output []byte, order binary.ByteOrder) ([]Route, error) {
+	if order == nil {
+		order = binary.NativeEndian
+	}
+	return parseLinuxRoutes(output, order)
+}
+
+// parseLinuxRoutes parses all rows of /proc/net/route, decoding addresses
+// in the given byte order.
+func parseLinuxRoutes(output []byte, order binary.ByteOrder) ([]Route, error) {
+	// Iface   Destination Gateway     Flags   RefCnt  Use Metric  Mask  MTU  Window  IRTT
+	// eno1    00000000    C900A8C0    0003    0   0   100 00000000    0   0   0
+	const (
+		ifaceField       = 0
+		destinationField = 1
+		gatewayField     = 2
+		flagsField       = 3
+		metricField      = 6
+		maskField        = 7
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	var result []Route
+	for scanner.Scan() {
+		row := scanner.Text()
+		tokens := strings.Fields(row)
+		if len(tokens) == 0 || tokens[ifaceField] == "Iface" {
+			// Blank line or header
+			continue
+		}
+		if len(tokens) < 11 {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+
+		dest, err := parseLinuxHexIPv4(tokens[destinationField], order)
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseLinuxHexIPv4(tokens[maskField], order)
+		if err != nil {
+			return nil, err
+		}
+		gw, err := parseLinuxHexIPv4(tokens[gatewayField], order)
+		if err != nil {
+			return nil, err
+		}
+		flags, err := strconv.ParseUint(tokens[flagsField], 16, 32)
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		metric, err := strconv.Atoi(tokens[metricField])
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		if flags&linuxRTFUp == 0 || flags&linuxRTFReject != 0 {
+			continue
+		}
+
+		bits, _ := net.IPMask(mask.AsSlice()).Size()
+		r := Route{
+			Destination: netip.PrefixFrom(dest, bits),
+			Interface:   tokens[ifaceField],
+			Metric:      metric,
+		}
+		if flags&linuxRTFGateway != 0 {
+			r.Gateway = gw
+		}
+		result = append(result, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseLinuxHexIPv4 parses an address of /proc/net/route. The kernel
+// prints the address as a number in host byte order.
+func parseLinuxHexIPv4(hexStr string, order binary.ByteOrder) (netip.Addr, error) {
+	d, err := strconv.ParseUint(hexStr, 16, 32)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("parsing IPv4 hex %q: %w", hexStr, err)
+	}
+	var b [4]byte
+	order.PutUint32(b[:], uint32(d))
+	return netip.AddrFrom4(b), nil
+}
+
+// parseLinuxIPv6Routes parses all rows of /proc/net/ipv6_route. The file
+// lists the routes of all tables without telling which; the routes to local
+// addresses and to multicast groups are put in the local table, like the
+// kernel does.
+func parseLinuxIPv6Routes(output []byte) ([]Route, error) {
+	// dest dest_prefix src src_prefix nexthop metric refcnt use flags iface
+	const (
+		destinationField     = 0
+		destinationPrefField = 1
+		gatewayField         = 4
+		metricField          = 5
+		flagsField           = 8
+		ifaceField           = 9
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	var result []Route
+	for scanner.Scan() {
+		row := scanner.Text()
+		fields := strings.Fields(row)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 10 {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+
+		dest, err := parseIPv6Hex(fields[destinationField])
+		if err != nil {
+			return nil, err
+		}
+		bits, err := strconv.ParseUint(fields[destinationPrefField], 16, 8)
+		if err != nil || bits > 128 {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		gw, err := parseIPv6Hex(fields[gatewayField])
+		if err != nil {
+			return nil, err
+		}
+		metric, err := strconv.ParseUint(fields[metricField], 16, 32)
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		flags, err := strconv.ParseUint(fields[flagsField], 16, 32)
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		if flags&linuxRTFUp == 0 || flags&linuxRTFReject != 0 {
+			continue
+		}
+
+		destAddr, _ := netip.AddrFromSlice(dest)
+		r := Route{
+			Destination: netip.PrefixFrom(destAddr, int(bits)),
+			Interface:   fields[ifaceField],
+			Metric:      int(metric),
+		}
+		if gwAddr, _ := netip.AddrFromSlice(gw); !gwAddr.IsUnspecified() {
+			r.Gateway = withZone(gwAddr, r.Interface)
+		}
+		switch {
+		case flags&linuxRTFLocal != 0:
+			r.Table = "local"
+			r.Type = "local"
+		case destAddr.IsMulticast():
+			r.Table = "local"
+			r.Type = "multicast"
+		}
+		result = append(result, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseNetstatRoutes parses the IPv4 and IPv6 sections of netstat -rn
+// output, as printed by the BSDs, Darwin and Solaris.
+func parseNetstatRoutes(output []byte) ([]Route, error) {
+	// Each section starts with a title ("Internet:", "Internet6:",
+	// "Routing Table: IPv4", "Routing Table: IPv6") followed by its own
+	// column headers.
+	var (
+		result   []Route
+		nsFields netstatFields
+		ipv6     bool
+		found    bool
+	)
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "Internet6") || strings.HasSuffix(trimmed, "IPv6"):
+			ipv6 = true
+			nsFields = nil
+			continue
+		case strings.HasPrefix(trimmed, "Internet") || strings.HasSuffix(trimmed, "IPv4"):
+			ipv6 = false
+			nsFields = nil
+			continue
+		case strings.Contains(line, "-----"):
+			continue
+		}
+
+		if headerLine, nf := discoverFields([]byte(line)); headerLine == 0 {
+			nsFields = nf
+			found = true
+			continue
+		}
+		if nsFields == nil {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		if !flagsContain(fields[nsFields[ns_flags]], "U") {
+			continue
+		}
+
+		dest, ok := parseNetstatDestination(fields[nsFields[ns_destination]], ipv6)
+		if !ok {
+			continue
+		}
+		r := Route{Destination: dest}
+		if ifaceIdx := nsFields[ns_netif]; ifaceIdx < len(fields) {
+			r.Interface = fields[ifaceIdx]
+		}
+		// Darwin scopes routes to their interface ("I"): only sockets
+		// bound to it use them.
+		if flagsContain(fields[nsFields[ns_flags]], "I") {
+			r.Flags = []string{ifscopeFlag}
+		}
+		if flagsContain(fields[nsFields[ns_flags]], "G") {
+			// The gateway may carry its own zone ("fe80::1%en0").
+			if gw, err := netip.ParseAddr(fields[nsFields[ns_gateway]]); err == nil {
+				r.Gateway = withZone(gw, r.Interface)
+			}
+		}
+		result = append(result, r)
+	}
+	if !found {
+		return nil, &ErrCantParse{}
+	}
+	return result, nil
+}
+
+// ParseNetToolsRoutes parses the output of Linux' net-tools and BusyBox
+//
+//	route -n
+//	route -A inet6 -n
+//	netstat -rn
+//
+// for example collected from an embedded device.
+func ParseNetToolsRoutes(output []byte) ([]Route, error) {
+	return parseNetToolsRoutes(output)
+}
+
+func parseNetToolsRoutes(output []byte) ([]Route, error) {
+	// Kernel IP routing table
+	// Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
+	// 0.0.0.0         192.168.1.1     0.0.0.0         UG    100    0        0 eth0
+	// 192.168.1.0     0.0.0.0         255.255.255.0   U     100    0        0 eth0
+	//
+	// Kernel IPv6 routing table
+	// Destination                    Next Hop                   Flag Met Ref Use If
+	// ::/0                           fe80::1                    UGDAe 1024 2     0 eth0
+	//
+	// netstat -rn has no Metric column. IPv4 destinations come with a
+	// Genmask, IPv6 destinations are prefixes.
+	var (
+		result   []Route
+		nsFields netstatFields
+	)
+	for _, line := range strings.Split(string(output), "\n") {
+		if headerLine, nf := discoverFields([]byte(line)); headerLine == 0 {
+			nsFields = nf
+			continue
+		}
+		if nsFields == nil {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) <= nsFields[ns_netif] || len(fields) <= nsFields[ns_flags] {
+			continue
+		}
+		// Rejected routes ("!n") aren't up.
+		flags := fields[nsFields[ns_flags]]
+		if !flagsContain(flags, "U") {
+			continue
+		}
+
+		var dest netip.Prefix
+		if m, ok := nsFields[ns_genmask]; ok {
+			addr, err := netip.ParseAddr(fields[nsFields[ns_destination]])
+			if err != nil || !addr.Is4() {
+				continue
+			}
+			mask, err := netip.ParseAddr(fields[m])
+			if err != nil || !mask.Is4() {
+				continue
+			}
+			bits, _ := net.IPMask(mask.AsSlice()).Size()
+			dest = netip.PrefixFrom(addr, bits)
+		} else {
+			prefix, err := netip.ParsePrefix(fields[nsFields[ns_destination]])
+			if err != nil {
+				continue
+			}
+			dest = prefix
+		}
+
+		r := Route{
+			Destination: dest,
+			Interface:   fields[nsFields[ns_netif]],
+		}
+		if m, ok := nsFields[ns_metric]; ok {
+			r.Metric, _ = strconv.Atoi(fields[m])
+		}
+		if flagsContain(flags, "G") {
+			if gw, err := netip.ParseAddr(fields[nsFields[ns_gateway]]); err == nil {
+				r.Gateway = withZone(gw, r.Interface)
+			}
+		}
+		result = append(result, r)
+	}
+	if nsFields == nil {
+		return nil, &ErrCantParse{}
+	}
+	return result, nil
+}
+
+// parseNetstatDestination parses the destination column of netstat -rn,
+// which may be "default", a host address, a prefix, or an IPv4 network
+// with trailing zero octets omitted ("127/8", "172.31.16/20", "169.254").
+func parseNetstatDestination(dest string, ipv6 bool) (netip.Prefix, bool) {
+	if dest == "default" {
+		if ipv6 {
+			return netip.PrefixFrom(netip.IPv6Unspecified(), 0), true
+		}
+		return netip.PrefixFrom(netip.IPv4Unspecified(), 0), true
+	}
+
+	addrStr, bitsStr, hasBits := strings.Cut(dest, "/")
+	// Scoped addresses carry their zone ("fe80::%en0/64"); the zone
+	// doesn't belong in a prefix.
+	addrStr, _, _ = strings.Cut(addrStr, "%")
+
+	if !ipv6 && !strings.Contains(addrStr, ":") {
+		octets := strings.Split(addrStr, ".")
+		if len(octets) < 4 {
+			if !hasBits {
+				bitsStr = strconv.Itoa(8 * len(octets))
+				hasBits = true
+			}
+			for len(octets) < 4 {
+				octets = append(octets, "0")
+			}
+			addrStr = strings.Join(octets, ".")
+		}
+	}
+
+	addr, err := netip.ParseAddr(addrStr)
+	if err != nil || addr.Is6() != ipv6 {
+		return netip.Prefix{}, false
+	}
+	bits := addr.BitLen()
+	if hasBits {
+		bits, err = strconv.Atoi(bitsStr)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+	}
+	prefix := netip.PrefixFrom(addr, bits)
+	return prefix, prefix.IsValid()
+}
+
+// parseWindowsRoutes parses the IPv4 and IPv6 active routes of route print.
+func parseWindowsRoutes(output []byte) ([]Route, error) {
+	active, _, err := parseWindowsRouteTables(output)
+	return active, err
+}
+
+// parseWindowsRouteTables parses the active and the persistent routes of
+// route print.
+func parseWindowsRouteTables(output []byte) (active, persistent []Route, err error) {
+	return parseWindowsRouteTablesImpl(output, nil)
+}
+
+// parseWindowsRouteTablesImpl is parseWindowsRouteTables that also names
+// the interfaces of the routes with ifaceGetter, if not nil. The IPv4 rows
+// tell their interface only by its address.
+func parseWindowsRouteTablesImpl(output []byte, ifaceGetter interfaceGetter) (active, persistent []Route, err error) {
+	// The IPv4 rows have the columns
+	//   Network Destination, Netmask, Gateway, Interface, Metric
+	// where Gateway may be the (localized) word "On-link". The IPv6 rows
+	// have the columns
+	//   If, Metric, Network Destination, Gateway
+	// and long destinations push the gateway onto the following line.
+	// Link-local gateways are zoned with the interface index.
+	//
+	// The persistent IPv4 rows lack the Interface column and their metric
+	// may be the (localized) word "Default":
+	//   Network Address, Netmask, Gateway Address, Metric
+	// The persistent IPv6 rows look like the active ones, with an If of 0
+	// when the route isn't bound to an interface.
+	lines, separators := splitWindowsRoutePrint(output)
+	if separators == 0 {
+		// We saw no separator lines, so input must have been garbage.
+		return nil, nil, &ErrCantParse{}
+	}
+
+	var pending *Route
+	for _, line := range lines {
+		var routes *[]Route
+		switch line.section {
+		case windowsActiveRoutes:
+			routes = &active
+		case windowsPersistentRoutes:
+			routes = &persistent
+		default:
+			pending = nil
+			continue
+		}
+
+		switch line.family {
+		case 4:
+			if line.section == windowsPersistentRoutes {
+				if r, ok := parseWindowsIPv4PersistentRow(line.text); ok {
+					*routes = append(*routes, r)
+				}
+			} else if r, ok := parseWindowsIPv4RouteRow(line.text); ok {
+				*routes = append(*routes, r)
+			}
+		case 6:
+			if pending != nil {
+				if gw, err := netip.ParseAddr(line.text); err == nil {
+					pending.Gateway = withZone(gw, windowsIndexZone(pending.InterfaceIndex))
+				}
+				pending = nil
+				continue
+			}
+			if r, complete, ok := parseWindowsIPv6RouteRow(line.text); ok {
+				*routes = append(*routes, r)
+				if !complete {
+					pending = &(*routes)[len(*routes)-1]
+				}
+			}
+		}
+	}
+
+	if ifaceGetter != nil {
+		resolveWindowsRouteInterfaces(active, ifaceGetter)
+		resolveWindowsRouteInterfaces(persistent, ifaceGetter)
+	}
+
+	// The IPv6 routes name their interface by index, which the Interface
+	// List resolves without asking the OS.
+	interfaces := make(map[int]WindowsInterface)
+	for _, iface := range windowsInterfaceList(lines) {
+		interfaces[iface.Index] = iface
+	}
+	for _, routes := range [][]Route{active, persistent} {
+		for i := range routes {
+			if iface, ok := interfaces[routes[i].InterfaceIndex]; ok && routes[i].InterfaceIndex != 0 {
+				routes[i].InterfaceDescription = iface.Description
+				routes[i].HardwareAddr = iface.HardwareAddr
+			}
+		}
+	}
+
+	markPersistentRoutes(active, persistent)
+	return active, persistent, nil
+}
+
+// resolveWindowsRouteInterfaces fills in the interface names of routes,
+// and the indexes of the routes that tell their interface by a local
+// address (Source) only.
+func resolveWindowsRouteInterfaces(routes []Route, ifaceGetter interfaceGetter) {
+	ifaces, err := ifaceGetter.Interfaces()
+	if err != nil {
+		return
+	}
+	names := make(map[int]string)
+	indexes := make(map[netip.Addr]int)
+	for i := range ifaces {
+		names[ifaces[i].Index] = ifaces[i].Name
+		addrs, err := ifaceGetter.Addrs(&ifaces[i])
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok {
+				if addr, ok := ipToAddr(ipNet.IP); ok {
+					indexes[addr] = ifaces[i].Index
+				}
+			}
+		}
+	}
+	for i := range routes {
+		r := &routes[i]
+		if r.InterfaceIndex == 0 && r.Source.IsValid() {
+			r.InterfaceIndex = indexes[r.Source]
+		}
+		if r.Interface == "" {
+			r.Interface = names[r.InterfaceIndex]
+		}
+	}
+}
+
+// markPersistentRoutes flags the persistent routes, and the active routes
+// that are in the persistent store too. A persistent route is in effect
+// when the active table holds the same route; otherwise it is Inactive.
+func markPersistentRoutes(active, persistent []Route) {
+	for i := range persistent {
+		p := &persistent[i]
+		p.Persistent, p.Inactive = true, true
+		for j := range active {
+			a := &active[j]
+			if a.Destination == p.Destination && a.Gateway.WithZone("") == p.Gateway.WithZone("") &&
+				(p.InterfaceIndex == 0 || p.InterfaceIndex == a.InterfaceIndex) {
+				a.Persistent, p.Inactive = true, false
+			}
+		}
+	}
+}
+
+// windowsIndexZone returns the zone of link-local addresses on the
+// interface with the given index. Persistent routes that aren't bound to
+// an interface have an index of 0 and no zone.
+func windowsIndexZone(index int) string {
+	if index == 0 {
+		return ""
+	}
+	return strconv.Itoa(index)
+}
+
+// windowsLogicalFields splits a row of route print into fields, joining
+// consecutive words such as the localized "On-link" ("En vínculo").
+func windowsLogicalFields(line string) []string {
+	fields := strings.Fields(line)
+	var logicalFields []string
+	for f := 0; f < len(fields); f++ {
+		field := fields[f]
+		if len(field) > 0 && unicode.IsLetter(rune(field[0])) {
+			for f+1 < len(fields) {
+				nextField := fields[f+1]
+				if len(nextField) > 0 && unicode.IsLetter(rune(nextField[0])) {
+					field += " " + nextField
+					f++
+				} else {
+					break
+				}
+			}
+		}
+		logicalFields = append(logicalFields, field)
+	}
+	return logicalFields
+}
+
+func parseWindowsIPv4RouteRow(line string) (Route, bool) {
+	fields := windowsLogicalFields(line)
+	if len(fields) < 5 {
+		return Route{}, false
+	}
+	dest, err := netip.ParseAddr(fields[0])
+	if err != nil || !dest.Is4() {
+		return Route{}, false
+	}
+	mask, err := netip.ParseAddr(fields[1])
+	if err != nil || !mask.Is4() {
+		return Route{}, false
+	}
+	bits, _ := net.IPMask(mask.AsSlice()).Size()
+	metric, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return Route{}, false
+	}
+
+	r := Route{
+		Destination: netip.PrefixFrom(dest, bits),
+		Metric:      metric,
+	}
+	// On-link routes have a word instead of an address.
+	if gw, err := netip.ParseAddr(fields[2]); err == nil {
+		r.Gateway = gw
+	}
+	if src, err := netip.ParseAddr(fields[3]); err == nil {
+		r.Source = src
+	}
+	return r, true
+}
+
+// parseWindowsIPv4PersistentRow parses a row of the IPv4 persistent
+// routes.
+func parseWindowsIPv4PersistentRow(line string) (Route, bool) {
+	fields := windowsLogicalFields(line)
+	if len(fields) < 4 {
+		return Route{}, false
+	}
+	dest, err := netip.ParseAddr(fields[0])
+	if err != nil || !dest.Is4() {
+		return Route{}, false
+	}
+	mask, err := netip.ParseAddr(fields[1])
+	if err != nil || !mask.Is4() {
+		return Route{}, false
+	}
+	bits, _ := net.IPMask(mask.AsSlice()).Size()
+
+	r := Route{Destination: netip.PrefixFrom(dest, bits)}
+	if gw, err := netip.ParseAddr(fields[2]); err == nil {
+		r.Gateway = gw
+	}
+	// Routes added without a metric show a word such as "Default"; the
+	// metric is then chosen by Windows when the route becomes active.
+	if metric, err := strconv.Atoi(fields[3]); err == nil {
+		r.Metric = metric
+	}
+	return r, true
+}
+
+// parseWindowsIPv6RouteRow parses a row of the IPv6 route table. If the
+// gateway was pushed onto the next line, complete is false.
+func parseWindowsIPv6RouteRow(line string) (r Route, complete bool, ok bool) {
+	// Destinations such as "fe80::/64" start with a letter, so the
+	// logical fields of the IPv4 rows don't apply.
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return Route{}, false, false
+	}
+	index, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Route{}, false, false
+	}
+	metric, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Route{}, false, false
+	}
+	dest, err := netip.ParsePrefix(fields[2])
+	if err != nil {
+		return Route{}, false, false
+	}
+
+	r = Route{
+		Destination:    dest,
+		InterfaceIndex: index,
+		Metric:         metric,
+	}
+	if len(fields) < 4 {
+		return r, false, true
+	}
+	// On-link routes have a word instead of an address.
+	if gw, err := netip.ParseAddr(fields[3]); err == nil {
+		r.Gateway = withZone(gw, windowsIndexZone(index))
+	}
+	return r, true, true
+}
@@ -2,38 +2,123 @@
 package gateway
 
 const (
-	darwinBadRoute          = "darwinBadRoute"
-	darwinNoRoute           = "darwinNoRoute"
-	darwin                  = "darwin"
-	freeBSDBadRoute         = "freeBSDBadRoute"
-	freeBSDNoRoute          = "freeBSDNoRoute"
-	freeBSD                 = "freeBSD"
-	linuxNoRoute            = "linuxNoRoute"
-	linux                   = "linux"
-	linuxIPv6               = "linuxIPv6"
-	linuxIPv6NoRoute        = "linuxIPv6NoRoute"
-	solarisIPv6WithInterface = "solarisIPv6WithInterface"
-	netBSDBadRoute          = "netBSDBadRoute"
-	netBSDNoRoute           = "netBSDNoRoute"
-	netBSD                  = "netBSD"
-	randomData              = "randomData"
-	solarisBadRoute         = "solarisBadRoute"
-	solarisNoInterface      = "solarisNoInterface"
-	solarisNoRoute          = "solarisNoRoute"
-	solaris                 = "solaris"
-	windowsBadRoute1        = "windowsBadRoute1"
-	windowsBadRoute2        = "windowsBadRoute2"
-	windowsLocalized2       = "windowsLocalized2"
-	windowsLocalized        = "windowsLocalized"
-	windowsMultipleGateways = "windowsMultipleGateways"
-	windowsNoDefaultRoute   = "windowsNoDefaultRoute"
-	windowsNoRoute          = "windowsNoRoute"
-	windows                 = "windows"
-	windowsIPv6             = "windowsIPv6"
-	windowsIPv6NoRoute      = "windowsIPv6NoRoute"
+	busyboxRouteN              = "busyboxRouteN"
+	busyboxRouteNIPv6          = "busyboxRouteNIPv6"
+	darwin                     = "darwin"
+	darwinBadRoute             = "darwinBadRoute"
+	darwinNoRoute              = "darwinNoRoute"
+	darwinScoped               = "darwinScoped"
+	dhclientLeases             = "dhclientLeases"
+	dhcpcdLease                = "dhcpcdLease"
+	dhcpcdLeaseWireless        = "dhcpcdLeaseWireless"
+	freeBSD                    = "freeBSD"
+	freeBSDBadRoute            = "freeBSDBadRoute"
+	freeBSDNoRoute             = "freeBSDNoRoute"
+	linux                      = "linux"
+	linuxBigEndian             = "linuxBigEndian"
+	linuxIPRoute6JSON          = "linuxIPRoute6JSON"
+	linuxIPRoute6Text          = "linuxIPRoute6Text"
+	linuxIPRouteContainers     = "linuxIPRouteContainers"
+	linuxIPRouteJSON           = "linuxIPRouteJSON"
+	linuxIPRouteLinkDown       = "linuxIPRouteLinkDown"
+	linuxIPRouteMultiTenant    = "linuxIPRouteMultiTenant"
+	linuxIPRouteSplitPartial   = "linuxIPRouteSplitPartial"
+	linuxIPRouteTableAll       = "linuxIPRouteTableAll"
+	linuxIPRouteText           = "linuxIPRouteText"
+	linuxIPRouteWireGuard      = "linuxIPRouteWireGuard"
+	linuxIPRouteWireGuardSplit = "linuxIPRouteWireGuardSplit"
+	linuxIPRuleMultiTenant     = "linuxIPRuleMultiTenant"
+	linuxIPRuleWireGuard       = "linuxIPRuleWireGuard"
+	linuxIPv6                  = "linuxIPv6"
+	linuxIPv6MultiHomed        = "linuxIPv6MultiHomed"
+	linuxIPv6NoRoute           = "linuxIPv6NoRoute"
+	linuxIPv6RouteLocal        = "linuxIPv6RouteLocal"
+	linuxMIPSBigEndian         = "linuxMIPSBigEndian"
+	linuxNetlinkVRFLinks       = "linuxNetlinkVRFLinks"
+	linuxNetlinkVRFRoutes      = "linuxNetlinkVRFRoutes"
+	linuxNetstatRn             = "linuxNetstatRn"
+	linuxNoRoute               = "linuxNoRoute"
+	linuxOpenVPNDef1           = "linuxOpenVPNDef1"
+	linuxRouteN                = "linuxRouteN"
+	linuxRouteNIPv6            = "linuxRouteNIPv6"
+	netBSD                     = "netBSD"
+	netBSDBadRoute             = "netBSDBadRoute"
+	netBSDNoRoute              = "netBSDNoRoute"
+	networkManagerLease        = "networkManagerLease"
+	networkdLease              = "networkdLease"
+	randomData                 = "randomData"
+	resolvConf                 = "resolvConf"
+	resolvConfResolved         = "resolvConfResolved"
+	resolvConfStub             = "resolvConfStub"
+	routerAdvertisement        = "routerAdvertisement"
+	solaris                    = "solaris"
+	solarisBadRoute            = "solarisBadRoute"
+	solarisIPv6MultiHomed      = "solarisIPv6MultiHomed"
+	solarisIPv6WithInterface   = "solarisIPv6WithInterface"
+	solarisNoInterface         = "solarisNoInterface"
+	solarisNoRoute             = "solarisNoRoute"
+	stunBindingResponse        = "stunBindingResponse"
+	windows                    = "windows"
+	windowsBadRoute1           = "windowsBadRoute1"
+	windowsBadRoute2           = "windowsBadRoute2"
+	windowsChinese             = "windowsChinese"
+	windowsDualStack           = "windowsDualStack"
+	windowsFrench              = "windowsFrench"
+	windowsGerman              = "windowsGerman"
+	windowsGermanPersistent    = "windowsGermanPersistent"
+	windowsGetNetIPInterface   = "windowsGetNetIPInterface"
+	windowsGetNetRoute         = "windowsGetNetRoute"
+	windowsGetNetRouteSingle   = "windowsGetNetRouteSingle"
+	windowsIPConfigAll         = "windowsIPConfigAll"
+	windowsIPConfigAllFrench   = "windowsIPConfigAllFrench"
+	windowsIPConfigAllGerman   = "windowsIPConfigAllGerman"
+	windowsIPv6                = "windowsIPv6"
+	windowsIPv6MultiHomed      = "windowsIPv6MultiHomed"
+	windowsIPv6NoRoute         = "windowsIPv6NoRoute"
+	windowsJapanese            = "windowsJapanese"
+	windowsLocalized           = "windowsLocalized"
+	windowsLocalized2          = "windowsLocalized2"
+	windowsMultipleGateways    = "windowsMultipleGateways"
+	windowsNetshGerman         = "windowsNetshGerman"
+	windowsNetshIPv4           = "windowsNetshIPv4"
+	windowsNetshIPv6           = "windowsNetshIPv6"
+	windowsNetshInterfaces     = "windowsNetshInterfaces"
+	windowsNetshVPN            = "windowsNetshVPN"
+	windowsNoDefaultRoute      = "windowsNoDefaultRoute"
+	windowsNoRoute             = "windowsNoRoute"
+	windowsPortuguese          = "windowsPortuguese"
+	windowsRoutePrintVPN       = "windowsRoutePrintVPN"
+	windowsRussian             = "windowsRussian"
+	windowsSpanishPersistent   = "windowsSpanishPersistent"
 )
 
 var routeTables = map[string][]byte{
+	busyboxRouteN: []byte(`
+Kernel IP routing table
+Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
+0.0.0.0         192.168.8.1     0.0.0.0         UG    0      0        0 wan
+192.168.1.0     0.0.0.0         255.255.255.0   U     0      0        0 br-lan
+192.168.8.0     0.0.0.0         255.255.255.0   U     0      0        0 wan
+`),
+
+	busyboxRouteNIPv6: []byte(`
+Kernel IPv6 routing table
+Destination                                 Next Hop                                Flags Metric Ref    Use Iface
+::/0                                        fe80::9a:1                              UG    512    1        0 wan
+fd12:3456:789a::/64                         ::                                      U     1024   0        0 br-lan
+fe80::/64                                   ::                                      U     256    0        0 br-lan
+fe80::/64                                   ::                                      U     256    0        0 wan
+`),
+
+	darwin: []byte(`
+Routing tables
+
+Internet:
+Destination        Gateway            Flags           Netif Expire
+default            link#17            UCSg            utun3
+default            192.168.1.254      UGScIg            en0
+                            `),
+
 	darwinBadRoute: []byte(`
 Routing tables
 
@@ -55,34 +140,86 @@ Destination        Gateway            Flags      Netif Expire
 127.0.0.1          link#2             UH          lo0
 `),
 
-	darwin: []byte(`
+	darwinScoped: []byte(`
 Routing tables
 
 Internet:
-Destination        Gateway            Flags           Netif Expire
-default            link#17            UCSg            utun3
-default            192.168.1.254      UGScIg            en0
-                            `),
+Destination        Gateway            Flags               Netif Expire
+default            192.168.1.1        UGScg                 en0
+default            10.0.0.1           UGScIg                en7
+default            192.168.1.1        UGScIg                en0
+10.0.0/24          link#12            UCS                   en7      !
+127                127.0.0.1          UCS                   lo0
+192.168.1          link#6             UCS                   en0      !
 
-	freeBSDBadRoute: []byte(`
-Routing tables
+Internet6:
+Destination                             Gateway                                 Flags               Netif Expire
+default                                 fe80::1%en0                             UGcg                  en0
+default                                 fe80::aa:1%en7                          UGcIg                 en7
+`),
 
-Internet:
-Destination        Gateway            Flags      Netif Expire
-default            foo                UGS        ena0
-10.88.88.0/24      link#1             U          ena0
-10.88.88.148       link#1             UHS         lo0
-127.0.0.1          link#2             UH          lo0
+	dhclientLeases: []byte(`
+default-duid "\000\001\000\001-\215\301\033RT\000\022\064V";
+lease {
+  interface "eth0";
+  fixed-address 192.168.1.23;
+  option subnet-mask 255.255.255.0;
+  option routers 192.168.1.254;
+  option dhcp-lease-time 86400;
+  option dhcp-message-type 5;
+  option domain-name-servers 192.168.1.254;
+  option dhcp-server-identifier 192.168.1.254;
+  renew 1 2026/10/19 09:00:00;
+  rebind 1 2026/10/19 18:00:00;
+  expire 1 2026/10/19 21:00:00;
+}
+lease {
+  interface "eth0";
+  fixed-address 192.168.1.23;
+  option subnet-mask 255.255.255.0;
+  option routers 192.168.1.1;
+  option dhcp-lease-time 86400;
+  option dhcp-message-type 5;
+  option domain-name-servers 192.168.1.1,9.9.9.9;
+  option dhcp-server-identifier 192.168.1.1;
+  option domain-name "example.org";
+  renew 2 2026/10/20 09:00:00;
+  rebind 2 2026/10/20 18:00:00;
+  expire 2 2026/10/20 21:00:00;
+}
+lease {
+  interface "wlan0";
+  fixed-address 10.20.30.40;
+  option subnet-mask 255.255.0.0;
+  option routers 10.20.0.1;
+  option domain-name-servers 10.20.0.1;
+  renew 0 2026/10/18 10:00:00;
+  expire epoch 1760824800; # Sat Oct 18 22:00:00 2025
+}
 `),
 
-	freeBSDNoRoute: []byte(`
-Routing tables
+	dhcpcdLease: []byte(`
+020106003c1d8a550000000000000000c0a80864c0a80801000000000242ac11
+0002000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+000000000000000000000000638253633501053604c0a8080133040000a8c001
+04ffffff000304c0a808010608c0a80801090909090000ff
+`),
 
-Internet:
-Destination        Gateway            Flags      Netif Expire
-10.88.88.0/24      link#1             U          ena0
-10.88.88.148       link#1             UHS         lo0
-127.0.0.1          link#2             UH          lo0
+	dhcpcdLeaseWireless: []byte(`
+020106003c1d8a5500000000000000000a0005170a000501000000000242ac11
+0002000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000000000000000000000000000000000000000000000
+0000000000000000000000006382536335010536040a00050133040000a8c001
+04ffffff0003040a00050106080a000501090909090000ff
 `),
 
 	freeBSD: []byte(`
@@ -101,15 +238,32 @@ Destination                       Gateway                       Flags     Netif
 ::1                               link#2                        UHS         lo0
 ::ffff:0.0.0.0/96                 ::1                           UGRS        lo0
 fe80::/10                         ::1                           UGRS        lo0
-fe80::%%ena0/64                    link#1                        U          ena0
-fe80::4fc:21ff:feeb:60c5%%ena0     link#1                        UHS         lo0
-fe80::%%lo0/64                     link#2                        U           lo0
-fe80::1%%lo0                       link#2                        UHS         lo0
+fe80::%ena0/64                    link#1                        U          ena0
+fe80::4fc:21ff:feeb:60c5%ena0     link#1                        UHS         lo0
+fe80::%lo0/64                     link#2                        U           lo0
+fe80::1%lo0                       link#2                        UHS         lo0
 ff02::/16                         ::1                           UGRS        lo0
 `),
 
-	linuxNoRoute: []byte(`
-Iface	Destination	Gateway	Flags	RefCnt	Use	Metric	Mask	MTU	Window	IRTT
+	freeBSDBadRoute: []byte(`
+Routing tables
+
+Internet:
+Destination        Gateway            Flags      Netif Expire
+default            foo                UGS        ena0
+10.88.88.0/24      link#1             U          ena0
+10.88.88.148       link#1             UHS         lo0
+127.0.0.1          link#2             UH          lo0
+`),
+
+	freeBSDNoRoute: []byte(`
+Routing tables
+
+Internet:
+Destination        Gateway            Flags      Netif Expire
+10.88.88.0/24      link#1             U          ena0
+10.88.88.148       link#1             UHS         lo0
+127.0.0.1          link#2             UH          lo0
 `),
 
 	linux: []byte(`
@@ -121,26 +275,270 @@ wlp4s0	0008A8C0	00000000	0001	0	0	600	00FFFFFF	0	0	0
 wlp4s0	00000000	0108A8C0	0003	0	0	600	00000000	0	0	0
 `),
 
-	netBSDBadRoute: []byte(`
-Routing tables
+	linuxBigEndian: []byte(`
+Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+enc1	00000000	C0A80101	0003	0	0	0	00000000	0	0	0
+enc1	C0A80100	00000000	0001	0	0	0	FFFFFF00	0	0	0
+enc2	0A000000	00000000	0001	0	0	0	FF000000	0	0	0
+`),
 
-Internet:
-Destination        Gateway            Flags    Refs      Use    Mtu Interface
-default            foo                UG          -        -   9001  ena0
-127/8              127.0.0.1          UGRS        -        -  33624  lo0
-127.0.0.1          lo0                UHl         -        -  33624  lo0
+	linuxIPRoute6JSON: []byte(`
+[{"dst":"2001:db8:1::/64","dev":"eth0","protocol":"ra","metric":100,"expires":86379,"flags":[],"pref":"medium"},{"dst":"fe80::/64","dev":"eth0","protocol":"kernel","metric":256,"flags":[],"pref":"medium"},{"dst":"default","protocol":"ra","metric":100,"expires":1779,"flags":[],"nexthops":[{"gateway":"fe80::1","dev":"eth0","weight":1,"flags":[]},{"gateway":"fe80::2","dev":"eth0","weight":1,"flags":[]}],"pref":"medium"},{"dst":"default","gateway":"fe80::a","dev":"wlan0","protocol":"ra","metric":600,"flags":[],"metrics":[{"mtu":1280,"lock":["mtu"]}],"pref":"high"},{"type":"unreachable","dst":"default","dev":"lo","table":"unreachable","protocol":"kernel","metric":4294967295,"flags":[],"error":-101,"pref":"medium"},{"type":"local","dst":"::1","table":"local","dev":"lo","protocol":"kernel","metric":0,"flags":[],"pref":"medium"},{"type":"multicast","dst":"ff00::/8","table":"local","dev":"eth0","protocol":"kernel","metric":256,"flags":[],"pref":"medium"}]
 `),
 
-	netBSDNoRoute: []byte(`
-Routing tables
+	linuxIPRoute6Text: []byte(`
+2001:db8:1::/64 dev eth0 proto ra metric 100 expires 86379sec pref medium
+fe80::/64 dev eth0 proto kernel metric 256 pref medium
+default proto ra metric 100 expires 1779sec pref medium
+	nexthop via fe80::1 dev eth0 weight 1 
+	nexthop via fe80::2 dev eth0 weight 1 
+default via fe80::a dev wlan0 proto ra metric 600 mtu lock 1280 pref high
+unreachable default dev lo table unreachable proto kernel metric 4294967295 error -101 pref medium
+local ::1 dev lo table local proto kernel metric 0 pref medium
+multicast ff00::/8 dev eth0 table local proto kernel metric 256 pref medium
+`),
 
-Internet:
-Destination        Gateway            Flags    Refs      Use    Mtu Interface
-127/8              127.0.0.1          UGRS        -        -  33624  lo0
-127.0.0.1          lo0                UHl         -        -  33624  lo0
-172.31.16/20       link#1             UC          -        -   9001  ena0
-172.31.22.254      link#1             UHl         -        -      -  lo0
-172.31.16.1        06:fd:6a:57:a9:12  UHL         -        -      -  ena0
+	linuxIPRouteContainers: []byte(`
+default via 192.168.122.1 dev virbr0 proto static metric 10
+default via 10.88.0.1 dev cni-podman0 proto static metric 20
+default via 10.244.0.1 dev flannel.1 onlink
+default via 192.168.1.1 dev eth0 proto dhcp src 192.168.1.10 metric 100
+10.88.0.0/16 dev cni-podman0 proto kernel scope link src 10.88.0.1
+10.244.0.0/24 dev flannel.1 proto kernel scope link src 10.244.0.0
+172.17.0.0/16 dev docker0 proto kernel scope link src 172.17.0.1 linkdown
+192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.10 metric 100
+192.168.122.0/24 dev virbr0 proto kernel scope link src 192.168.122.1 linkdown
+`),
+
+	linuxIPRouteJSON: []byte(`
+[{"dst":"default","gateway":"192.168.1.1","dev":"eth0","protocol":"dhcp","prefsrc":"192.168.1.10","metric":100,"flags":[]},{"dst":"default","gateway":"10.0.0.1","dev":"wlan0","protocol":"dhcp","prefsrc":"10.0.0.23","metric":600,"flags":["linkdown"]},{"dst":"default","gateway":"172.16.5.1","dev":"eth1","table":"100","protocol":"static","flags":[]},{"dst":"10.0.0.0/24","dev":"wlan0","protocol":"kernel","scope":"link","prefsrc":"10.0.0.23","metric":600,"flags":["linkdown"]},{"dst":"10.9.0.0/16","protocol":"static","metric":20,"flags":[],"nexthops":[{"gateway":"192.168.1.254","dev":"eth0","weight":1,"flags":[]},{"gateway":"10.0.0.254","dev":"wlan0","weight":2,"flags":["dead","linkdown"]}]},{"dst":"172.16.5.0/24","dev":"eth1","protocol":"kernel","scope":"link","prefsrc":"172.16.5.7","flags":[]},{"dst":"192.168.1.0/24","dev":"eth0","protocol":"kernel","scope":"link","prefsrc":"192.168.1.10","metric":100,"flags":[]},{"dst":"192.168.2.0/24","via":{"family":"inet6","host":"fe80::1"},"dev":"eth0","protocol":"bird","flags":[]},{"type":"blackhole","dst":"198.51.100.0/24","protocol":"static","flags":[]},{"type":"local","dst":"127.0.0.0/8","table":"local","dev":"lo","protocol":"kernel","scope":"host","prefsrc":"127.0.0.1","flags":[]},{"type":"local","dst":"127.0.0.1","table":"local","dev":"lo","protocol":"kernel","scope":"host","prefsrc":"127.0.0.1","flags":[]},{"type":"broadcast","dst":"192.168.1.255","table":"local","dev":"eth0","protocol":"kernel","scope":"link","prefsrc":"192.168.1.10","flags":[]},{"type":"local","dst":"192.168.1.10","table":"local","dev":"eth0","protocol":"kernel","scope":"host","prefsrc":"192.168.1.10","flags":[]}]
+`),
+
+	linuxIPRouteLinkDown: []byte(`
+default via 10.0.0.1 dev eth1 proto static metric 50 linkdown
+default via 192.168.1.1 dev wlan0 proto dhcp src 192.168.1.23 metric 600
+10.0.0.0/24 dev eth1 proto kernel scope link src 10.0.0.2 metric 50 linkdown
+192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23 metric 600
+`),
+
+	linuxIPRouteMultiTenant: []byte(`
+default via 192.168.1.1 dev eth0 proto dhcp src 192.168.1.20 metric 100
+10.0.0.0/8 via 192.168.1.254 dev eth0
+10.0.1.0/24 dev eth1 proto kernel scope link src 10.0.1.5
+10.0.2.0/24 dev eth2 proto kernel scope link src 10.0.2.5
+192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.20 metric 100
+default via 10.0.1.1 dev eth1 table tenant1
+default via 10.0.2.1 dev eth2 table 102
+throw 10.0.0.0/8 table 102
+198.51.100.0/25 via 10.0.2.254 dev eth2 table 150
+default via 10.0.9.1 dev eth1 table 250
+local 192.168.1.20 dev eth0 table local proto kernel scope host src 192.168.1.20
+`),
+
+	linuxIPRouteSplitPartial: []byte(`
+default via 192.168.1.1 dev eth0 proto dhcp metric 100
+0.0.0.0/1 via 10.8.0.5 dev tun0
+10.8.0.0/24 dev tun0 proto kernel scope link src 10.8.0.6
+192.168.1.0/24 dev eth0 proto kernel scope link metric 100
+`),
+
+	linuxIPRouteTableAll: []byte(`
+default via 192.168.1.1 dev eth0 proto dhcp src 192.168.1.20 metric 100
+default via 10.10.0.1 dev eth1 table mgmt proto static
+default via 10.20.0.1 dev eth2 table 200 metric 50
+default via 10.20.0.254 dev eth2 table 201 metric 10
+blackhole default table 300
+10.10.0.0/24 dev eth1 proto kernel scope link src 10.10.0.5
+192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.20 metric 100
+local 10.10.0.5 dev eth1 table local proto kernel scope host src 10.10.0.5
+broadcast 192.168.1.255 dev eth0 table local proto kernel scope link src 192.168.1.20
+`),
+
+	linuxIPRouteText: []byte(`
+default via 192.168.1.1 dev eth0 proto dhcp src 192.168.1.10 metric 100 
+default via 10.0.0.1 dev wlan0 proto dhcp src 10.0.0.23 metric 600 linkdown 
+default via 172.16.5.1 dev eth1 table 100 proto static 
+10.0.0.0/24 dev wlan0 proto kernel scope link src 10.0.0.23 metric 600 linkdown 
+10.9.0.0/16 proto static metric 20 
+	nexthop via 192.168.1.254 dev eth0 weight 1 
+	nexthop via 10.0.0.254 dev wlan0 weight 2 dead linkdown 
+172.16.5.0/24 dev eth1 proto kernel scope link src 172.16.5.7 
+192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.10 metric 100 
+192.168.2.0/24 via inet6 fe80::1 dev eth0 proto bird 
+blackhole 198.51.100.0/24 proto static 
+local 127.0.0.0/8 dev lo table local proto kernel scope host src 127.0.0.1 
+local 127.0.0.1 dev lo table local proto kernel scope host src 127.0.0.1 
+broadcast 192.168.1.255 dev eth0 table local proto kernel scope link src 192.168.1.10 
+local 192.168.1.10 dev eth0 table local proto kernel scope host src 192.168.1.10 
+`),
+
+	linuxIPRouteWireGuard: []byte(`
+default via 192.168.1.1 dev eth0 proto dhcp src 192.168.1.20 metric 100
+192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.20 metric 100
+default dev wg0 table 51820 scope link
+`),
+
+	linuxIPRouteWireGuardSplit: []byte(`
+default via 192.168.1.1 dev wlan0 proto dhcp src 192.168.1.23 metric 600
+0.0.0.0/2 dev wg0 scope link
+64.0.0.0/2 dev wg0 scope link
+128.0.0.0/1 dev wg0 scope link
+10.64.0.0/16 dev wg0 proto kernel scope link src 10.64.3.7
+192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23 metric 600
+198.51.100.7 via 192.168.1.1 dev wlan0
+`),
+
+	linuxIPRuleMultiTenant: []byte(`
+0:	from all lookup local
+100:	from 10.0.1.5 lookup tenant1
+101:	from 10.0.2.0/24 lookup 102
+150:	from all to 198.51.100.0/24 lookup 150
+200:	from 10.0.3.5 unreachable
+250:	from all fwmark 0x1 lookup 250
+260:	from all ipproto tcp dport 443 lookup 250
+300:	from 10.0.4.5 goto 32766
+301:	from 10.0.4.5 lookup tenant1
+1000:	from all lookup [l3mdev-table]
+32766:	from all lookup main
+32767:	from all lookup default
+`),
+
+	linuxIPRuleWireGuard: []byte(`
+[{"priority":0,"src":"all","table":"local"},{"priority":32764,"src":"all","table":"main","suppress_prefixlen":0},{"priority":32765,"not":null,"src":"all","fwmark":"0xca6c","table":"51820"},{"priority":32766,"src":"all","table":"main"},{"priority":32767,"src":"all","table":"default"}]
+`),
+
+	linuxIPv6: []byte(`
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000242acfffe110003 00000064 00000000 00000000 00000003 eth0
+00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000002 00000001 00200001 lo
+20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
+fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
+`),
+
+	linuxIPv6MultiHomed: []byte(`
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000000 00000000 00000003 eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000500 00000000 00000000 00000003 eth1
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 20010db8000000000000000000000001 00000600 00000000 00000000 00000003 eth2
+20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth2
+fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
+fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth1
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200 lo
+`),
+
+	linuxIPv6NoRoute: []byte(`
+00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000002 00000001 00200001 lo
+20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
+`),
+
+	linuxIPv6RouteLocal: []byte(`
+fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0
+fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000002 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fd000000000000000000000000000001 00000400 00000001 00000000 00000003     eth0
+00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000003 00000000 80200001       lo
+fd000000000000000000000000000002 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001     eth0
+fe8000000000000000fc00fffe000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000003 00000000 80200001     eth0
+ff000000000000000000000000000000 08 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000004 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo
+`),
+
+	linuxMIPSBigEndian: []byte(`
+Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+wan	00000000	C0A80801	0003	0	0	0	00000000	0	0	0
+br-lan	C0A80100	00000000	0001	0	0	0	FFFFFF00	0	0	0
+wan	C0A80800	00000000	0001	0	0	0	FFFFFF00	0	0	0
+`),
+
+	linuxNetlinkVRFLinks: []byte(`
+3000000010000200010000009210000000000403010000004900010000000000
+070003006c6f000008000400dc05000034000000100002000100000092100000
+0000010002000000431001000000000009000300657468300000000008000400
+dc0500003c000000100002000100000092100000000001000300000043100100
+0000000009000300657468310000000008000400dc05000008000a0004000000
+4c00000010000200010000009210000000000100040000004104010000000000
+090003006d676d740000000008000400dc050000180012800800010076726600
+0c000280080001000a0000003c00000010000200010000009210000000000100
+05000000431001000000000009000300657468320000000008000400dc050000
+08000a00060000004c0000001000020001000000921000000000010006000000
+410401000000000009000300646174610000000008000400dc05000018001280
+08000100767266000c00028008000100fc030000440000001000020001000000
+92100000000001000700000043100100000000000a0003007665746830000000
+08000400dc050000100012800900010076657468000000001400000003000200
+010000009210000000000000
+`),
+
+	linuxNetlinkVRFRoutes: []byte(`
+4400000018000200020000009210000002000000fe1000010000000008000f00
+fe000000080006006400000008000700c0a8011408000500c0a8010108000400
+020000004400000018000200020000009210000002180000fe02fd0100000000
+08000f00fe00000008000100c0a80100080006006400000008000700c0a80114
+080004000200000034000000180002000200000092100000020000000a040001
+0000000008000f000a000000080005000a0a000108000400030000002c000000
+180002000200000092100000020000000a0400070000000008000f000a000000
+08000600002000ff3c000000180002000200000092100000021800000a02fd01
+0000000008000f000a000000080001000a0a0000080007000a0a000508000400
+030000004800000018000200020000009210000002000000fc04000100000000
+08000f00fc03000024000900100000000500000008000500ac10000110000000
+0500000008000500ac1001013c00000018000200020000009210000002170000
+fc02fd010000000008000f00fc03000008000100ac10000008000700ac100005
+08000400050000003c00000018000200020000009210000002200000ff02fe02
+0000000008000f00ff00000008000100c0a8011408000700c0a8011408000400
+02000000480000001800020002000000921000000a0000000a04000100000000
+08000f000a000000080006000004000014000500fe8000000000000000000000
+000000010800040003000000480000001800020002000000921000000a400000
+fe0200010000000008000f00fe0000001400010020010db80000000000000000
+0000000008000600000100000800040002000000540000001800020002000000
+921000000a800000fe0000010002000008000f00fe0000001400010020010db8
+00ff0000000000000000000114000500fe800000000000000000000000000099
+0800040002000000480000001800020002000000921000000a000000fe090001
+0000000008000f00fe000000080006000004000014000500fe80000000000000
+00000000000000fe080004000200000014000000030002000200000092100000
+00000000
+`),
+
+	linuxNetstatRn: []byte(`
+Kernel IP routing table
+Destination     Gateway         Genmask         Flags   MSS Window  irtt Iface
+0.0.0.0         172.17.0.1      0.0.0.0         UG        0 0          0 eth0
+172.17.0.0      0.0.0.0         255.255.0.0     U         0 0          0 eth0
+`),
+
+	linuxNoRoute: []byte(`
+Iface	Destination	Gateway	Flags	RefCnt	Use	Metric	Mask	MTU	Window	IRTT
+`),
+
+	linuxOpenVPNDef1: []byte(`
+Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+tun0	00000000	0500080A	0003	0	0	0	00000080	0	0	0
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+tun0	0100080A	0500080A	0003	0	0	0	FFFFFFFF	0	0	0
+tun0	0500080A	00000000	0005	0	0	0	FFFFFFFF	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+eth0	0A7100CB	0101A8C0	0007	0	0	0	FFFFFFFF	0	0	0
+tun0	00000080	0500080A	0003	0	0	0	00000080	0	0	0
+`),
+
+	linuxRouteN: []byte(`
+Kernel IP routing table
+Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
+0.0.0.0         192.168.1.1     0.0.0.0         UG    100    0        0 eth0
+0.0.0.0         10.0.0.1        0.0.0.0         UG    600    0        0 wlan0
+10.0.0.0        0.0.0.0         255.255.255.0   U     600    0        0 wlan0
+169.254.0.0     0.0.0.0         255.255.0.0     U     1000   0        0 eth0
+192.168.1.0     0.0.0.0         255.255.255.0   U     100    0        0 eth0
+192.168.7.0     192.168.1.254   255.255.255.0   UG    0      0        0 eth0
+198.51.100.0    -               255.255.255.0   !     0      -        0 -
+`),
+
+	linuxRouteNIPv6: []byte(`
+Kernel IPv6 routing table
+Destination                    Next Hop                   Flag Met Ref Use If
+::1/128                        ::                         U    256 2     0 lo
+2001:db8:1::/64                ::                         U    100 1     0 eth0
+fe80::/64                      ::                         U    256 1     0 eth0
+::/0                           fe80::1                    UGDAe 1024 2     0 eth0
+::1/128                        ::                         Un   0   4     0 lo
+2001:db8:1::10/128             ::                         Un   0   2     0 eth0
+ff00::/8                       ::                         U    256 3     0 eth0
+::/0                           ::                         !n   -1  1     0 lo
 `),
 
 	netBSD: []byte(`
@@ -170,20 +568,136 @@ Destination                             Gateway                        Flags
 2002:e000::/20                          ::1                            UGRS        -        -  33624  lo0
 2002:ff00::/24                          ::1                            UGRS        -        -  33624  lo0
 fe80::/10                               ::1                            UGRS        -        -  33624  lo0
-fe80::%%ena0/64                          link#1                         UC          -        -      -  ena0
+fe80::%ena0/64                          link#1                         UC          -        -      -  ena0
 fe80::9508:280a:c38e:4e4a               link#1                         UHl         -        -      -  lo0
-fe80::%%lo0/64                           fe80::1                        U           -        -      -  lo0
+fe80::%lo0/64                           fe80::1                        U           -        -      -  lo0
 fe80::1                                 lo0                            UHl         -        -      -  lo0
 ff01:1::/32                             link#1                         UC          -        -      -  ena0
 ff01:2::/32                             ::1                            UC          -        -  33624  lo0
-ff02::%%ena0/32                          link#1                         UC          -        -      -  ena0
-ff02::%%lo0/32                           ::1                            UC          -        -  33624  lo0`),
+ff02::%ena0/32                          link#1                         UC          -        -      -  ena0
+ff02::%lo0/32                           ::1                            UC          -        -  33624  lo0`),
+
+	netBSDBadRoute: []byte(`
+Routing tables
+
+Internet:
+Destination        Gateway            Flags    Refs      Use    Mtu Interface
+default            foo                UG          -        -   9001  ena0
+127/8              127.0.0.1          UGRS        -        -  33624  lo0
+127.0.0.1          lo0                UHl         -        -  33624  lo0
+`),
+
+	netBSDNoRoute: []byte(`
+Routing tables
+
+Internet:
+Destination        Gateway            Flags    Refs      Use    Mtu Interface
+127/8              127.0.0.1          UGRS        -        -  33624  lo0
+127.0.0.1          lo0                UHl         -        -  33624  lo0
+172.31.16/20       link#1             UC          -        -   9001  ena0
+172.31.22.254      link#1             UHl         -        -      -  lo0
+172.31.16.1        06:fd:6a:57:a9:12  UHL         -        -      -  ena0
+`),
+
+	networkManagerLease: []byte(`
+# This is private data. Do not parse.
+ADDRESS=192.168.50.77
+NETMASK=255.255.255.0
+ROUTER=192.168.50.1
+SERVER_ADDRESS=192.168.50.1
+T1=21600
+T2=37800
+LIFETIME=43200
+DNS=192.168.50.1
+`),
+
+	networkdLease: []byte(`
+# This is private data. Do not parse.
+ADDRESS=172.16.4.10
+NETMASK=255.255.252.0
+ROUTER=172.16.4.1
+SERVER_ADDRESS=172.16.4.1
+NEXT_SERVER=0.0.0.0
+T1=1800
+T2=3150
+LIFETIME=3600
+DNS=172.16.4.1 1.1.1.1
+DOMAINNAME=lan
+CLIENTID=ff3b8c1a5f00020000ab11e2a1c8f3b2d3a1c9
+`),
 
 	randomData: []byte(`
 test
 Lorem ipsum dolor sit amet, consectetur adipiscing elit,
 sed do eiusmod tempor incididunt ut labore et dolore magna
 aliqua. Ut enim ad minim veniam, quis nostrud exercitation
+`),
+
+	resolvConf: []byte(`
+# Generated by NetworkManager
+search corp.example.com example.com
+nameserver 192.168.1.1
+nameserver 2001:db8:1::53
+nameserver fe80::1%eth0
+options edns0 trust-ad
+`),
+
+	resolvConfResolved: []byte(`
+# This is /run/systemd/resolve/resolv.conf managed by man:systemd-resolved(8).
+# Do not edit.
+#
+# This file might be symlinked as /etc/resolv.conf. If you're looking at
+# /etc/resolv.conf and seeing this text, you have followed the symlink.
+#
+# This is a dynamic resolv.conf file for connecting local clients directly to
+# all known uplink DNS servers. This file lists all configured search domains.
+
+nameserver 192.168.1.1
+nameserver fd00::1
+domain lan
+`),
+
+	resolvConfStub: []byte(`
+# This is /run/systemd/resolve/stub-resolv.conf managed by man:systemd-resolved(8).
+# Do not edit.
+#
+# This file might be symlinked as /etc/resolv.conf. If you're looking at
+# /etc/resolv.conf and seeing this text, you have followed the symlink.
+#
+# Run "resolvectl status" to see details about the uplink DNS servers
+# currently in use.
+
+nameserver 127.0.0.53
+options edns0 trust-ad
+search lan
+`),
+
+	routerAdvertisement: []byte(`
+86 00 5a 3c 40 48 07 08 00 00 00 00 00 00 00 00
+01 01 52 54 00 12 34 56
+05 01 00 00 00 00 05 dc
+03 04 40 c0 00 27 8d 00 00 09 3a 80 00 00 00 00
+20 01 0d b8 00 01 00 00 00 00 00 00 00 00 00 00
+18 02 30 18 00 00 0e 10 20 01 0d b8 ff 00 00 00
+19 03 00 00 00 00 07 08 20 01 0d b8 00 01 00 00
+00 00 00 00 00 00 00 53
+26 02 07 08 00 64 ff 9b 00 00 00 00 00 00 00 00
+`),
+
+	solaris: []byte(`
+Routing Table: IPv4
+	Destination           Gateway           Flags  Ref     Use     Interface
+-------------------- -------------------- ----- ----- ---------- ---------
+default              172.16.32.1          UG        2      76419 net0
+127.0.0.1            127.0.0.1            UH        2         36 lo0
+172.16.32.0          172.16.32.17         U         4       8100 net0
+
+Routing Table: IPv6
+	Destination/Mask            Gateway                   Flags Ref   Use    If
+--------------------------- --------------------------- ----- --- ------- -----
+::1                         ::1                         UH      3   75382 lo0
+2001:470:deeb:32::/64       2001:470:deeb:32::17        U       3    2744 net0
+fe80::/10                   fe80::6082:52ff:fedc:7df0   U       3    8430 net0
 `),
 
 	solarisBadRoute: []byte(`
@@ -202,6 +716,25 @@ Routing Table: IPv6
 fe80::/10                   fe80::6082:52ff:fedc:7df0   U       3    8430 net0
 `),
 
+	solarisIPv6MultiHomed: []byte(`
+Routing Table: IPv6
+  Destination/Mask            Gateway                   Flags Ref   Use    If
+--------------------------- --------------------------- ----- --- ------- -----
+::1                         ::1                         UH      2     966 lo0
+default                     fe80::1                     UG      3 4092447 net0
+default                     fe80::1                     UG      2   12034 net1
+default                     2001:db8::1                 UG      1     102 net2
+fe80::/10                   fe80::aabb:ccdd:1234:2      U       5   77620 net0
+`),
+
+	solarisIPv6WithInterface: []byte(`
+Routing Table: IPv6
+  Destination/Mask            Gateway                   Flags Ref   Use    If
+--------------------------- --------------------------- ----- --- ------- -----
+::1                         ::1                         UH      2     966 lo0
+fe80::/10                   fe80::aabb:ccdd:1234:2      U       5   77620 net0
+default                     fe80::aabb:ccdd:1234:1      UG      3 4092447 net0`),
+
 	solarisNoInterface: []byte(`
 Routing Table: IPv4
   Destination            Gateway          Flags  Ref     Use     Interface
@@ -232,20 +765,34 @@ Routing Table: IPv6
 fe80::/10                   fe80::6082:52ff:fedc:7df0   U       3    8430 net0
 `),
 
-	solaris: []byte(`
-Routing Table: IPv4
-	Destination           Gateway           Flags  Ref     Use     Interface
--------------------- -------------------- ----- ----- ---------- ---------
-default              172.16.32.1          UG        2      76419 net0
-127.0.0.1            127.0.0.1            UH        2         36 lo0
-172.16.32.0          172.16.32.17         U         4       8100 net0
+	stunBindingResponse: []byte(`
+01 01 00 3c 21 12 a4 42 b7 e7 a7 01 bc 34 d6 86
+fa 87 df ae 80 22 00 0b 74 65 73 74 20 76 65 63
+74 6f 72 20 00 20 00 08 00 01 a1 47 e1 12 a6 43
+00 08 00 14 2b 91 f5 99 fd 9e 90 c3 8c 74 89 f9
+2a f9 ba 53 f0 6b e7 d7 80 28 00 04 c0 7d 4c 96
+`),
 
-Routing Table: IPv6
-	Destination/Mask            Gateway                   Flags Ref   Use    If
---------------------------- --------------------------- ----- --- ------- -----
-::1                         ::1                         UH      3   75382 lo0
-2001:470:deeb:32::/64       2001:470:deeb:32::17        U       3    2744 net0
-fe80::/10                   fe80::6082:52ff:fedc:7df0   U       3    8430 net0
+	windows: []byte(`
+===========================================================================
+Interface List
+  8 ...00 12 3f a7 17 ba ...... Intel(R) PRO/100 VE Network Connection
+  1 ........................... Software Loopback Interface 1
+===========================================================================
+IPv4 Route Table
+===========================================================================
+Active Routes:
+Network Destination        Netmask          Gateway       Interface  Metric
+          0.0.0.0          0.0.0.0       10.88.88.2     10.88.88.149     10
+        127.0.0.0        255.0.0.0         On-link         127.0.0.1    331
+        127.0.0.1  255.255.255.255         On-link         127.0.0.1    331
+  127.255.255.255  255.255.255.255         On-link         127.0.0.1    331
+===========================================================================
+Persistent Routes:
+  Network Address          Netmask  Gateway Address  Metric
+          0.0.0.0          0.0.0.0       10.88.88.2  Default
+    192.168.1.255  255.255.255.255       10.88.88.2       1
+--------------------------------------------------------------------------- 
 `),
 
 	windowsBadRoute1: []byte(`
@@ -276,35 +823,490 @@ Network Destination        Netmask          Gateway       Interface  Metric
 Persistent Routes:
 `),
 
-	windowsLocalized2: []byte(`
+	windowsChinese: []byte(`
 ===========================================================================
-ILista de interfaces
-29...........................SGNAutobahn Tunnel
-11...........................SGN Tunnel
-18...01 02 03 04 05 60 ......Microsoft Wi-Fi Direct Virtual Adapter
- 4...01 02 03 04 05 61 ......Microsoft Wi-Fi Direct Virtual Adapter #2
-10...01 02 03 04 05 62 ......MediaTek Wi-Fi 6E MT7902 Wireless LAN Card
-14...01 02 03 04 05 63 ......Bluetooth Device (Personal Area Network)
- 1...........................Software Loopback Interface 1
+接口列表
+  9...00 e0 4c 68 01 23 ......Realtek PCIe GbE Family Controller
+  1...........................Software Loopback Interface 1
 ===========================================================================
- 
-IPv4 Tabla de enrutamiento
+
+IPv4 路由表
 ===========================================================================
-Rutas activas:
-Destino de red        Máscara de red   Puerta de enlace   Interfaz  Métrica
-          0.0.0.0          0.0.0.0    192.168.100.1   192.168.100.80     35
-          0.0.0.0        192.0.0.0      En vínculo       123.45.0.10    250
+活动路由:
+网络目标            网络掩码          网关            接口   跃点数
+          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.105     25
+        127.0.0.0        255.0.0.0            在链路上         127.0.0.1    331
+      192.168.1.0    255.255.255.0            在链路上     192.168.1.105    281
 ===========================================================================
-Rutas persistentes:
-  Ninguno
- 
-IPv6 Tabla de enrutamiento
+永久路由:
+  无
+
+IPv6 路由表
 ===========================================================================
-Rutas activas:
-  Ninguno
-Rutas persistentes:
-  Ninguno
+活动路由:
+ 接口跃点数网络目标                网关
+  9    281 ::/0                     fe80::5a41:20ff:fe01:2
+  1    331 ::1/128                  在链路上
+  9    281 fe80::/64                在链路上
+===========================================================================
+永久路由:
+  无
+`),
+
+	windowsDualStack: []byte(`
+===========================================================================
+Interface List
+ 12...00 15 5d 01 02 03 ......Intel(R) Ethernet Connection I219-LM
+  1...........................Software Loopback Interface 1
+ 14...00 00 00 00 00 00 00 e0 Teredo Tunneling Pseudo-Interface
+===========================================================================
+
+IPv4 Route Table
+===========================================================================
+Active Routes:
+Network Destination        Netmask          Gateway       Interface  Metric
+          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.100     25
+        127.0.0.0        255.0.0.0         On-link         127.0.0.1    331
+      192.168.1.0    255.255.255.0         On-link     192.168.1.100    281
+    192.168.1.100  255.255.255.255         On-link     192.168.1.100    281
+===========================================================================
+Persistent Routes:
+  Network Address          Netmask  Gateway Address  Metric
+          0.0.0.0          0.0.0.0      192.168.1.1  Default
+===========================================================================
+
+IPv6 Route Table
+===========================================================================
+Active Routes:
+ If Metric Network Destination      Gateway
+ 12    281 ::/0                     fe80::1
+  1    331 ::1/128                  On-link
+ 12    281 2001:db8:1234:5678::/64  On-link
+ 12    281 2001:db8:1234:5678:a1b2:c3d4:e5f6:1234/128
+                                    On-link
+ 12    281 fe80::/64                On-link
+===========================================================================
+Persistent Routes:
+ If Metric Network Destination      Gateway
+  0 4294967295 ::/0                 fe80::2
+===========================================================================
+`),
+
+	windowsFrench: []byte(`
+===========================================================================
+Liste d'Interfaces
+ 17...00 28 f8 39 61 6b ......Intel(R) Wi-Fi 6 AX201 160MHz
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+IPv4 Table de routage
+===========================================================================
+Itinéraires actifs :
+Destination réseau    Masque réseau  Adr. passerelle   Adr. interface Métrique
+          0.0.0.0          0.0.0.0      192.168.1.254    192.168.1.42     35
+        127.0.0.0        255.0.0.0         On-link         127.0.0.1    331
+      192.168.1.0    255.255.255.0         On-link      192.168.1.42    291
+===========================================================================
+Itinéraires persistants :
+  Aucun
+
+IPv6 Table de routage
+===========================================================================
+Itinéraires actifs :
+ If Métrique Destination réseau     Passerelle
+ 17    291 ::/0                     fe80::224:d4ff:fea1:b2c3
+  1    331 ::1/128                  On-link
+ 17    291 fe80::/64                On-link
+===========================================================================
+Itinéraires persistants :
+  Aucun
+`),
+
+	windowsGerman: []byte(`
+===========================================================================
+Schnittstellenliste
+ 12...00 15 5d 01 02 03 ......Intel(R) Ethernet Connection (7) I219-LM
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+IPv4-Routentabelle
+===========================================================================
+Aktive Routen:
+     Netzwerkziel    Netzwerkmaske          Gateway    Schnittstelle Metrik
+          0.0.0.0          0.0.0.0    192.168.178.1   192.168.178.20     25
+        127.0.0.0        255.0.0.0   Auf Verbindung         127.0.0.1    331
+    192.168.178.0    255.255.255.0   Auf Verbindung    192.168.178.20    281
+===========================================================================
+Ständige Routen:
+  Keine
+
+IPv6-Routentabelle
+===========================================================================
+Aktive Routen:
+ If Metrik Netzwerkziel             Gateway
+ 12    281 ::/0                     fe80::3a10:d5ff:fe12:3456
+  1    331 ::1/128                  Auf Verbindung
+ 12    281 fe80::/64                Auf Verbindung
+===========================================================================
+Ständige Routen:
+ If Metrik Netzwerkziel             Gateway
+  0 4294967295 ::/0                 fe80::99
+===========================================================================
+`),
+
+	windowsGermanPersistent: []byte(`
+===========================================================================
+Schnittstellenliste
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+IPv4-Routentabelle
+===========================================================================
+Aktive Routen:
+     Netzwerkziel    Netzwerkmaske          Gateway    Schnittstelle Metrik
+        127.0.0.0        255.0.0.0   Auf Verbindung         127.0.0.1    331
+        127.0.0.1  255.255.255.255   Auf Verbindung         127.0.0.1    331
+===========================================================================
+Ständige Routen:
+  Netzwerkadresse          Netzmaske  Gatewayadresse  Metrik
+          0.0.0.0          0.0.0.0       172.16.0.1  Standard
+===========================================================================
+
+IPv6-Routentabelle
+===========================================================================
+Aktive Routen:
+ If Metrik Netzwerkziel             Gateway
+  1    331 ::1/128                  Auf Verbindung
+===========================================================================
+Ständige Routen:
+  Keine
+`),
+
+	windowsGetNetIPInterface: []byte(`
+[
+    {
+        "InterfaceIndex":  23,
+        "InterfaceAlias":  "Corporate VPN",
+        "AddressFamily":  2,
+        "InterfaceMetric":  1,
+        "AutomaticMetric":  0
+    },
+    {
+        "InterfaceIndex":  17,
+        "InterfaceAlias":  "Wi-Fi",
+        "AddressFamily":  2,
+        "InterfaceMetric":  35,
+        "AutomaticMetric":  1
+    },
+    {
+        "InterfaceIndex":  12,
+        "InterfaceAlias":  "Ethernet",
+        "AddressFamily":  2,
+        "InterfaceMetric":  25,
+        "AutomaticMetric":  1
+    },
+    {
+        "InterfaceIndex":  12,
+        "InterfaceAlias":  "Ethernet",
+        "AddressFamily":  23,
+        "InterfaceMetric":  15,
+        "AutomaticMetric":  1
+    },
+    {
+        "InterfaceIndex":  1,
+        "InterfaceAlias":  "Loopback Pseudo-Interface 1",
+        "AddressFamily":  2,
+        "InterfaceMetric":  75,
+        "AutomaticMetric":  1
+    }
+]
+`),
+
+	windowsGetNetRoute: []byte(`
+[
+    {
+        "DestinationPrefix":  "0.0.0.0/0",
+        "NextHop":  "192.168.1.1",
+        "InterfaceAlias":  "Ethernet",
+        "InterfaceIndex":  12,
+        "RouteMetric":  0,
+        "InterfaceMetric":  25,
+        "Protocol":  3,
+        "Store":  1
+    },
+    {
+        "DestinationPrefix":  "0.0.0.0/0",
+        "NextHop":  "10.0.0.1",
+        "InterfaceAlias":  "Wi-Fi",
+        "InterfaceIndex":  17,
+        "RouteMetric":  0,
+        "InterfaceMetric":  35,
+        "Protocol":  19,
+        "Store":  1
+    },
+    {
+        "DestinationPrefix":  "192.168.1.0/24",
+        "NextHop":  "0.0.0.0",
+        "InterfaceAlias":  "Ethernet",
+        "InterfaceIndex":  12,
+        "RouteMetric":  256,
+        "InterfaceMetric":  25,
+        "Protocol":  2,
+        "Store":  1
+    },
+    {
+        "DestinationPrefix":  "127.0.0.0/8",
+        "NextHop":  "0.0.0.0",
+        "InterfaceAlias":  "Loopback Pseudo-Interface 1",
+        "InterfaceIndex":  1,
+        "RouteMetric":  256,
+        "InterfaceMetric":  75,
+        "Protocol":  2,
+        "Store":  1
+    },
+    {
+        "DestinationPrefix":  "::/0",
+        "NextHop":  "fe80::1",
+        "InterfaceAlias":  "Ethernet",
+        "InterfaceIndex":  12,
+        "RouteMetric":  256,
+        "InterfaceMetric":  25,
+        "Protocol":  4,
+        "Store":  1
+    },
+    {
+        "DestinationPrefix":  "fe80::/64",
+        "NextHop":  "::",
+        "InterfaceAlias":  "Ethernet",
+        "InterfaceIndex":  12,
+        "RouteMetric":  256,
+        "InterfaceMetric":  25,
+        "Protocol":  2,
+        "Store":  1
+    },
+    {
+        "DestinationPrefix":  "0.0.0.0/0",
+        "NextHop":  "192.168.1.1",
+        "InterfaceAlias":  "Ethernet",
+        "InterfaceIndex":  12,
+        "RouteMetric":  0,
+        "InterfaceMetric":  25,
+        "Protocol":  3,
+        "Store":  0
+    },
+    {
+        "DestinationPrefix":  "0.0.0.0/0",
+        "NextHop":  "172.16.0.1",
+        "InterfaceAlias":  "Ethernet 2",
+        "InterfaceIndex":  21,
+        "RouteMetric":  0,
+        "InterfaceMetric":  5,
+        "Protocol":  3,
+        "Store":  0
+    }
+]
+`),
+
+	windowsGetNetRouteSingle: []byte(`
+{
+  "DestinationPrefix": "0.0.0.0/0",
+  "NextHop": "192.168.0.1",
+  "InterfaceAlias": "Ethernet",
+  "InterfaceIndex": 6,
+  "RouteMetric": 0,
+  "InterfaceMetric": 25,
+  "Protocol": "Dhcp",
+  "Store": "ActiveStore"
+}
+`),
+
+	windowsIPConfigAll: []byte(`
+
+Windows IP Configuration
+
+   Host Name . . . . . . . . . . . . : DESKTOP-4J2K9QF
+   Primary Dns Suffix  . . . . . . . : corp.example.com
+   Node Type . . . . . . . . . . . . : Hybrid
+   IP Routing Enabled. . . . . . . . : No
+   WINS Proxy Enabled. . . . . . . . : No
+   DNS Suffix Search List. . . . . . : corp.example.com
+                                       example.com
+
+Ethernet adapter Ethernet:
+
+   Connection-specific DNS Suffix  . : lan
+   Description . . . . . . . . . . . : Intel(R) Ethernet Connection (7) I219-LM
+   Physical Address. . . . . . . . . : 00-1A-2B-3C-4D-5E
+   DHCP Enabled. . . . . . . . . . . : Yes
+   Autoconfiguration Enabled . . . . : Yes
+   IPv6 Address. . . . . . . . . . . : fd00::8c3a:21ff:fe4b:1c2d(Preferred)
+   Link-local IPv6 Address . . . . . : fe80::8c3a:21ff:fe4b:1c2d%12(Preferred)
+   IPv4 Address. . . . . . . . . . . : 192.168.1.23(Preferred)
+   Subnet Mask . . . . . . . . . . . : 255.255.255.0
+   Lease Obtained. . . . . . . . . . : Monday, October 19, 2026 8:00:12 AM
+   Lease Expires . . . . . . . . . . : Tuesday, October 20, 2026 8:00:12 AM
+   Default Gateway . . . . . . . . . : fe80::1%12
+                                       192.168.1.1
+   DHCP Server . . . . . . . . . . . : 192.168.1.1
+   DHCPv6 IAID . . . . . . . . . . . : 100670507
+   DNS Servers . . . . . . . . . . . : 192.168.1.1
+                                       fd00::1
+   NetBIOS over Tcpip. . . . . . . . : Enabled
+
+Wireless LAN adapter Wi-Fi:
+
+   Media State . . . . . . . . . . . : Media disconnected
+   Connection-specific DNS Suffix  . :
+   Description . . . . . . . . . . . : Intel(R) Wi-Fi 6 AX201 160MHz
+   Physical Address. . . . . . . . . : 5C-87-9C-11-22-33
+   DHCP Enabled. . . . . . . . . . . : Yes
+   Autoconfiguration Enabled . . . . : Yes
+
+Unknown adapter OpenVPN Wintun:
 
+   Connection-specific DNS Suffix  . : vpn.example.com
+   Description . . . . . . . . . . . : Wintun Userspace Tunnel
+   IPv4 Address. . . . . . . . . . . : 10.8.0.6(Preferred)
+   Subnet Mask . . . . . . . . . . . : 255.255.255.0
+   Default Gateway . . . . . . . . . :
+   DNS Servers . . . . . . . . . . . : 10.8.0.1
+`),
+
+	windowsIPConfigAllFrench: []byte(`
+Configuration IP de Windows
+
+   Nom de l'hôte . . . . . . . . . . : PC-BUREAU
+   Suffixe DNS principal . . . . . . :
+   Type de noeud. . . . . . . . . .  : Hybride
+   Routage IP activé . . . . . . . . : Non
+   Proxy WINS activé . . . . . . . . : Non
+   Liste de recherche du suffixe DNS.: home
+
+Carte Ethernet Ethernet :
+
+   Suffixe DNS propre à la connexion. . . : home
+   Description. . . . . . . . . . . . . . : Realtek PCIe GbE Family Controller
+   Adresse physique . . . . . . . . . . . : 00-E0-4C-68-0A-0B
+   DHCP activé. . . . . . . . . . . . . . : Oui
+   Configuration automatique activée. . . : Oui
+   Adresse IPv4. . . . . . . . . . . . . .: 192.168.1.42(préféré)
+   Masque de sous-réseau. . . . . . . . . : 255.255.255.0
+   Passerelle par défaut. . . . . . . . . : 192.168.1.254
+   Serveurs DNS. . .  . . . . . . . . . . : 192.168.1.254
+                                       2a01:cb00::1
+   NetBIOS sur Tcpip. . . . . . . . . . . : Activé
+
+Carte réseau sans fil Wi-Fi :
+
+   Statut du média. . . . . . . . . . . . : Média déconnecté
+   Suffixe DNS propre à la connexion. . . :
+   Description. . . . . . . . . . . . . . : Intel(R) Wi-Fi 6 AX201 160MHz
+   Adresse physique . . . . . . . . . . . : 3C-58-C2-01-02-03
+   DHCP activé. . . . . . . . . . . . . . : Oui
+   Configuration automatique activée. . . : Oui
+
+Carte Tunnel Teredo Tunneling Pseudo-Interface :
+
+   Statut du média. . . . . . . . . . . . : Média déconnecté
+   Suffixe DNS propre à la connexion. . . :
+   Description. . . . . . . . . . . . . . : Microsoft Teredo Tunneling Adapter
+`),
+
+	windowsIPConfigAllGerman: []byte(`
+
+Windows-IP-Konfiguration
+
+   Hostname  . . . . . . . . . . . . : PC-BUERO
+   Primäres DNS-Suffix . . . . . . . :
+   Knotentyp . . . . . . . . . . . . : Hybrid
+   IP-Routing aktiviert  . . . . . . : Nein
+   WINS-Proxy aktiviert  . . . . . . : Nein
+   DNS-Suffixsuchliste . . . . . . . : fritz.box
+
+Ethernet-Adapter Ethernet:
+
+   Verbindungsspezifisches DNS-Suffix: fritz.box
+   Beschreibung. . . . . . . . . . . : Realtek PCIe GbE Family Controller
+   Physische Adresse . . . . . . . . : 00-E0-4C-68-01-02
+   DHCP aktiviert. . . . . . . . . . : Ja
+   IPv4-Adresse  . . . . . . . . . . : 192.168.178.20(Bevorzugt)
+   Subnetzmaske  . . . . . . . . . . : 255.255.255.0
+   Standardgateway . . . . . . . . . : 192.168.178.1
+   DNS-Server  . . . . . . . . . . . : 192.168.178.1
+   NetBIOS über TCP/IP . . . . . . . : Aktiviert
+`),
+
+	windowsIPv6: []byte(`
+===========================================================================
+IPv6 Route Table
+===========================================================================
+Active Routes:
+ If Metric Network Destination      Gateway
+ 12    281  ::/0                    fe80::1
+ 12    281  ::1/128                 On-link
+ 12    281  2001:db8::/32           On-link
+  1    306  ff00::/8                On-link
+===========================================================================
+Persistent Routes:
+  None
+`),
+
+	windowsIPv6MultiHomed: []byte(`
+===========================================================================
+IPv6 Route Table
+===========================================================================
+Active Routes:
+ If Metric Network Destination      Gateway
+ 12    281 ::/0                     fe80::1
+ 17    291 ::/0                     fe80::1
+ 12    281 fe80::/64                On-link
+ 17    291 fe80::/64                On-link
+===========================================================================
+Persistent Routes:
+  None
+`),
+
+	windowsIPv6NoRoute: []byte(`
+===========================================================================
+IPv6 Route Table
+===========================================================================
+Active Routes:
+ If Metric Network Destination      Gateway
+ 12    281  ::1/128                 On-link
+  1    306  ff00::/8                On-link
+===========================================================================
+Persistent Routes:
+  None
+`),
+
+	windowsJapanese: []byte(`
+===========================================================================
+インターフェイス一覧
+  7...00 15 5d 0a 0b 0c ......Realtek PCIe GbE Family Controller
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+IPv4 ルート テーブル
+===========================================================================
+アクティブ ルート:
+ネットワーク宛先        ネットマスク          ゲートウェイ       インターフェイス  メトリック
+          0.0.0.0          0.0.0.0      192.168.0.1     192.168.0.10     25
+        127.0.0.0        255.0.0.0            リンク上         127.0.0.1    331
+      192.168.0.0    255.255.255.0            リンク上      192.168.0.10    281
+===========================================================================
+固定ルート:
+  なし
+
+IPv6 ルート テーブル
+===========================================================================
+アクティブ ルート:
+ If メトリック ネットワーク宛先      ゲートウェイ
+  7    281 ::/0                     fe80::1
+  1    331 ::1/128                  リンク上
+  7    281 fe80::/64                リンク上
+===========================================================================
+固定ルート:
+  なし
 `),
 
 	windowsLocalized: []byte(`
@@ -322,6 +1324,37 @@ Destination réseau    Masque réseau  Adr. passerelle   Adr. interface Métriqu
 Itinéraires persistants :
   Aucun`),
 
+	windowsLocalized2: []byte(`
+===========================================================================
+ILista de interfaces
+29...........................SGNAutobahn Tunnel
+11...........................SGN Tunnel
+18...01 02 03 04 05 60 ......Microsoft Wi-Fi Direct Virtual Adapter
+ 4...01 02 03 04 05 61 ......Microsoft Wi-Fi Direct Virtual Adapter #2
+10...01 02 03 04 05 62 ......MediaTek Wi-Fi 6E MT7902 Wireless LAN Card
+14...01 02 03 04 05 63 ......Bluetooth Device (Personal Area Network)
+ 1...........................Software Loopback Interface 1
+===========================================================================
+ 
+IPv4 Tabla de enrutamiento
+===========================================================================
+Rutas activas:
+Destino de red        Máscara de red   Puerta de enlace   Interfaz  Métrica
+          0.0.0.0          0.0.0.0    192.168.100.1   192.168.100.80     35
+          0.0.0.0        192.0.0.0      En vínculo       123.45.0.10    250
+===========================================================================
+Rutas persistentes:
+  Ninguno
+ 
+IPv6 Tabla de enrutamiento
+===========================================================================
+Rutas activas:
+  Ninguno
+Rutas persistentes:
+  Ninguno
+
+`),
+
 	windowsMultipleGateways: []byte(`
 ===========================================================================
 Interface List
@@ -351,6 +1384,65 @@ Active Routes:
 Persistent Routes:
   None`),
 
+	windowsNetshGerman: []byte(`
+
+Veröffentlichen  Typ       Met  Präfix                    Idx  Gateway/Schnittstellenname
+---------------  --------  ---  ------------------------  ---  ------------------------
+Nein             Manuell   0    0.0.0.0/0                   7  192.168.178.1
+Nein             System    256  127.0.0.0/8                 1  Loopback Pseudo-Interface 1
+Nein             System    256  192.168.178.0/24            7  WLAN
+
+`),
+
+	windowsNetshIPv4: []byte(`
+
+Publish  Type      Met  Prefix                    Idx  Gateway/Interface Name
+-------  --------  ---  ------------------------  ---  ------------------------
+No       Manual    0    0.0.0.0/0                  12  192.168.1.1
+No       System    256  127.0.0.0/8                 1  Loopback Pseudo-Interface 1
+No       System    256  127.0.0.1/32                1  Loopback Pseudo-Interface 1
+No       System    256  192.168.1.0/24             12  Ethernet
+No       System    256  192.168.1.100/32           12  Ethernet
+No       System    256  224.0.0.0/4                 1  Loopback Pseudo-Interface 1
+
+`),
+
+	windowsNetshIPv6: []byte(`
+
+Publish  Type      Met  Prefix                    Idx  Gateway/Interface Name
+-------  --------  ---  ------------------------  ---  ------------------------
+No       Manual    256  ::/0                       12  fe80::1
+No       System    256  ::1/128                     1  Loopback Pseudo-Interface 1
+No       System    256  2001:db8:1234:5678::/64    12  Ethernet
+No       System    256  fe80::/64                  12  Ethernet
+
+`),
+
+	windowsNetshInterfaces: []byte(`
+
+Idx     Met         MTU          State                Name
+---  ----------  ----------  ------------  ---------------------------
+  1          75  4294967295  connected     Loopback Pseudo-Interface 1
+ 12          25        1500  connected     Ethernet
+ 17          35        1500  connected     Wi-Fi
+ 23           1        1400  connected     Corporate VPN
+
+`),
+
+	windowsNetshVPN: []byte(`
+
+Publish  Type      Met  Prefix                    Idx  Gateway/Interface Name
+-------  --------  ---  ------------------------  ---  ------------------------
+No       Manual    0    0.0.0.0/0                  12  192.168.1.1
+No       Manual    0    0.0.0.0/0                  17  192.168.50.1
+No       Manual    0    0.0.0.0/0                  23  10.8.0.1
+No       System    256  10.8.0.0/24                23  Corporate VPN
+No       System    256  127.0.0.0/8                 1  Loopback Pseudo-Interface 1
+No       System    256  192.168.1.0/24             12  Ethernet
+No       System    256  192.168.50.0/24            17  Wi-Fi
+
+`),
+
 	windowsNoDefaultRoute: []byte(`
 ===========================================================================
 Interface List
@@ -379,71 +1471,138 @@ IPv4 Route Table
 Active Routes:
 `),
 
-	windows: []byte(`
+	windowsPortuguese: []byte(`
+===========================================================================
+Lista de interfaces
+ 11...00 1a 2b 3c 4d 5e ......Intel(R) Ethernet Connection I217-V
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+Tabela de rotas IPv4
+===========================================================================
+Rotas ativas:
+Endereço de rede          Máscara     Ender. gateway   Interface  Custo
+          0.0.0.0          0.0.0.0      192.168.0.1     192.168.0.15     35
+        127.0.0.0        255.0.0.0         No vínculo         127.0.0.1    331
+      192.168.0.0    255.255.255.0         No vínculo      192.168.0.15    291
+===========================================================================
+Rotas persistentes:
+  Nenhum
+
+Tabela de rotas IPv6
+===========================================================================
+Rotas ativas:
+ Se Custo Destino de rede           Gateway
+ 11    291 ::/0                     fe80::1
+  1    331 ::1/128                  No vínculo
+ 11    291 fe80::/64                No vínculo
+===========================================================================
+Rotas persistentes:
+  Nenhum
+`),
+
+	windowsRoutePrintVPN: []byte(`
 ===========================================================================
 Interface List
-  8 ...00 12 3f a7 17 ba ...... Intel(R) PRO/100 VE Network Connection
-  1 ........................... Software Loopback Interface 1
+ 12...3c 52 82 6b 1f 0a ......Intel(R) Ethernet Connection (4) I219-LM
+ 18...00 ff 5e 1d 9c 3b ......TAP-Windows Adapter V9
+  1...........................Software Loopback Interface 1
 ===========================================================================
+
 IPv4 Route Table
 ===========================================================================
 Active Routes:
 Network Destination        Netmask          Gateway       Interface  Metric
-          0.0.0.0          0.0.0.0       10.88.88.2     10.88.88.149     10
+          0.0.0.0          0.0.0.0      192.168.1.1     192.168.1.20     35
+          0.0.0.0          0.0.0.0         10.8.0.1         10.8.0.6     26
+         10.8.0.0    255.255.255.0         On-link          10.8.0.6    281
+         10.8.0.6  255.255.255.255         On-link          10.8.0.6    281
+       10.8.0.255  255.255.255.255         On-link          10.8.0.6    281
         127.0.0.0        255.0.0.0         On-link         127.0.0.1    331
         127.0.0.1  255.255.255.255         On-link         127.0.0.1    331
-  127.255.255.255  255.255.255.255         On-link         127.0.0.1    331
+      192.168.1.0    255.255.255.0         On-link      192.168.1.20    291
+     192.168.1.20  255.255.255.255         On-link      192.168.1.20    291
 ===========================================================================
 Persistent Routes:
-  Network Address          Netmask  Gateway Address  Metric
-          0.0.0.0          0.0.0.0       10.88.88.2  Default
-    192.168.1.255  255.255.255.255       10.88.88.2       1
---------------------------------------------------------------------------- 
-`),
-
-	linuxIPv6: []byte(`00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000242acfffe110003 00000064 00000000 00000000 00000003 eth0
-00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000002 00000001 00200001 lo
-20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
-fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
-`),
-
-	linuxIPv6NoRoute: []byte(`00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000002 00000001 00200001 lo
-20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000000 00000001 00000001 eth0
-`),
+  None
 
-	windowsIPv6: []byte(`
-===========================================================================
 IPv6 Route Table
 ===========================================================================
 Active Routes:
  If Metric Network Destination      Gateway
- 12    281  ::/0                    fe80::1
- 12    281  ::1/128                 On-link
- 12    281  2001:db8::/32           On-link
-  1    306  ff00::/8                On-link
+  1    331 ::1/128                  On-link
+ 12    291 fe80::/64                On-link
+ 18    281 fe80::/64                On-link
 ===========================================================================
 Persistent Routes:
   None
 `),
 
-	windowsIPv6NoRoute: []byte(`
+	windowsRussian: []byte(`
 ===========================================================================
-IPv6 Route Table
+Список интерфейсов
+  4...00 50 56 c0 00 08 ......Intel(R) Ethernet Connection I219-V
+  1...........................Software Loopback Interface 1
 ===========================================================================
-Active Routes:
- If Metric Network Destination      Gateway
- 12    281  ::1/128                 On-link
-  1    306  ff00::/8                On-link
+
+IPv4 таблица маршрута
 ===========================================================================
-Persistent Routes:
-  None
+Активные маршруты:
+Сетевой адрес           Маска сети      Адрес шлюза       Интерфейс  Метрика
+          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.34     25
+        127.0.0.0        255.0.0.0         On-link         127.0.0.1    331
+      192.168.1.0    255.255.255.0         On-link      192.168.1.34    281
+===========================================================================
+Постоянные маршруты:
+  Отсутствует
+
+IPv6 таблица маршрута
+===========================================================================
+Активные маршруты:
+ Метрика   Сетевой адрес            Шлюз
+  4    281 ::/0                     fe80::1
+  1    331 ::1/128                  On-link
+  4    281 fe80::/64                On-link
+===========================================================================
+Постоянные маршруты:
+  Отсутствует
 `),
 
-	solarisIPv6WithInterface: []byte(`
-Routing Table: IPv6
-  Destination/Mask            Gateway                   Flags Ref   Use    If
---------------------------- --------------------------- ----- --- ------- -----
-::1                         ::1                         UH      2     966 lo0
-fe80::/10                   fe80::aabb:ccdd:1234:2      U       5   77620 net0
-default                     fe80::aabb:ccdd:1234:1      UG      3 4092447 net0`),
+	windowsSpanishPersistent: []byte(`
+===========================================================================
+Lista de interfaces
+ 15...00 1c 42 9a 7b 01 ......Intel(R) Ethernet Connection I219-V
+ 22...00 1c 42 9a 7b 02 ......Realtek USB GbE Family Controller
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+IPv4 Tabla de enrutamiento
+===========================================================================
+Rutas activas:
+Destino de red        Máscara de red   Puerta de enlace   Interfaz  Métrica
+          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.50     25
+        127.0.0.0        255.0.0.0       En vínculo         127.0.0.1    331
+      192.168.1.0    255.255.255.0       En vínculo      192.168.1.50    281
+===========================================================================
+Rutas persistentes:
+  Dirección de red  Máscara de red  Dirección de puerta de enlace  Métrica
+          0.0.0.0          0.0.0.0      192.168.1.1  Predeterminado
+          0.0.0.0          0.0.0.0        10.20.0.1      10
+        10.50.0.0      255.255.0.0        10.20.0.1  Predeterminado
+===========================================================================
+
+IPv6 Tabla de enrutamiento
+===========================================================================
+Rutas activas:
+ Cuando destino de red métrica      Puerta de enlace
+ 15    281 ::/0                     fe80::1
+  1    331 ::1/128                  En vínculo
+ 15    281 fe80::/64                En vínculo
+===========================================================================
+Rutas persistentes:
+ Cuando destino de red métrica      Puerta de enlace
+  0 4294967295 ::/0                 fe80::1
+ 22    256 ::/0                     fe80::20:1
+===========================================================================
+`),
 }
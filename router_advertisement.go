@@ -0,0 +1,366 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// ICMPv6 types of Neighbor Discovery, see RFC 4861.
+const (
+	icmpv6RouterSolicitation  = 133
+	icmpv6RouterAdvertisement = 134
+)
+
+// Neighbor Discovery options.
+const (
+	ndOptionSourceLinkAddr = 1
+	ndOptionPrefixInfo     = 3
+	ndOptionMTU            = 5
+	ndOptionRouteInfo      = 24 // RFC 4191
+	ndOptionRDNSS          = 25 // RFC 8106
+	ndOptionPREF64         = 38 // RFC 8781
+	ndOptionUnit           = 8  // option lengths count 8 octets
+	routerAdvertisementLen = 16
+	routerSolicitationLen  = 8
+	infiniteLifetime       = 0xffffffff
+	raFlagManaged          = 0x80
+	raFlagOtherConfig      = 0x40
+	raPreferenceShift      = 3
+	prefixFlagOnLink       = 0x80
+	prefixFlagAutonomous   = 0x40
+	pref64LifetimeScale    = 8 * time.Second
+	pref64PrefixBytes      = 12
+)
+
+// RouterPreference is the preference of a router or of a route, see
+// RFC 4191.
+type RouterPreference int8
+
+const (
+	// RouterPreferenceLow is preferred over no router.
+	RouterPreferenceLow RouterPreference = -1
+
+	// RouterPreferenceMedium is the default preference, that of routers
+	// that don't announce one.
+	RouterPreferenceMedium RouterPreference = 0
+
+	// RouterPreferenceHigh is preferred over the other routers.
+	RouterPreferenceHigh RouterPreference = 1
+)
+
+func (p RouterPreference) String() string {
+	switch p {
+	case RouterPreferenceLow:
+		return "low"
+	case RouterPreferenceHigh:
+		return "high"
+	default:
+		return "medium"
+	}
+}
+
+// decodeRouterPreference decodes the two bits of a preference. The
+// reserved value 10 counts as medium.
+func decodeRouterPreference(bits byte) RouterPreference {
+	switch bits & 0x3 {
+	case 0x1:
+		return RouterPreferenceHigh
+	case 0x3:
+		return RouterPreferenceLow
+	default:
+		return RouterPreferenceMedium
+	}
+}
+
+func (p RouterPreference) bits() byte {
+	switch p {
+	case RouterPreferenceHigh:
+		return 0x1
+	case RouterPreferenceLow:
+		return 0x3
+	default:
+		return 0x0
+	}
+}
+
+// RouterAdvertisement is an IPv6 Router Advertisement (RFC 4861) with the
+// options that matter for finding the gateway and configuring a host.
+type RouterAdvertisement struct {
+	// Router is the link-local address of the router, zoned with the
+	// interface it was received on. It is set by DiscoverRouters, not
+	// carried in the message.
+	Router netip.Addr
+
+	// HopLimit is the hop limit the router suggests, or zero.
+	HopLimit uint8
+
+	// Managed and OtherConfig are the M and O flags: addresses or other
+	// configuration are available from DHCPv6.
+	Managed, OtherConfig bool
+
+	// Preference is the default router preference of RFC 4191.
+	Preference RouterPreference
+
+	// Lifetime is how long the router may be used as default router.
+	// A router with a zero lifetime is not a default router.
+	Lifetime time.Duration
+
+	// ReachableTime and RetransTimer are the Neighbor Discovery timers,
+	// or zero if unspecified.
+	ReachableTime, RetransTimer time.Duration
+
+	// SourceLinkAddr is the hardware address of the router, if sent.
+	SourceLinkAddr net.HardwareAddr
+
+	// MTU is the MTU of the link, if sent.
+	MTU int
+
+	// Prefixes holds the Prefix Information options.
+	Prefixes []RAPrefix
+
+	// Routes holds the Route Information options of RFC 4191.
+	Routes []RARoute
+
+	// RDNSS holds the recursive DNS servers of RFC 8106, valid for
+	// RDNSSLifetime.
+	RDNSS         []netip.Addr
+	RDNSSLifetime time.Duration
+
+	// PREF64 is the NAT64 prefix of RFC 8781, valid for
+	// PREF64Lifetime, or the zero Prefix.
+	PREF64         netip.Prefix
+	PREF64Lifetime time.Duration
+}
+
+// RAPrefix is a Prefix Information option.
+type RAPrefix struct {
+	Prefix                           netip.Prefix
+	OnLink, Autonomous               bool
+	ValidLifetime, PreferredLifetime time.Duration
+}
+
+// RARoute is a Route Information option.
+type RARoute struct {
+	Prefix     netip.Prefix
+	Preference RouterPreference
+	Lifetime   time.Duration
+}
+
+// seconds converts a lifetime of a message to a Duration.
+func seconds(s uint32) time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// lifetime converts d to seconds of a message, rounding down.
+func lifetime(d time.Duration) uint32 {
+	if d >= seconds(infiniteLifetime) {
+		return infiniteLifetime
+	}
+	return uint32(d / time.Second)
+}
+
+// ParseRouterAdvertisement decodes an ICMPv6 Router Advertisement,
+// starting with the ICMPv6 header as read from a raw ICMPv6 socket.
+// Unknown options are skipped and the checksum is left to the kernel.
+// Malformed messages yield ErrCantParse.
+func ParseRouterAdvertisement(b []byte) (RouterAdvertisement, error) {
+	if len(b) < routerAdvertisementLen || b[0] != icmpv6RouterAdvertisement || b[1] != 0 {
+		return RouterAdvertisement{}, &ErrCantParse{}
+	}
+	ra := RouterAdvertisement{
+		HopLimit:      b[4],
+		Managed:       b[5]&raFlagManaged != 0,
+		OtherConfig:   b[5]&raFlagOtherConfig != 0,
+		Preference:    decodeRouterPreference(b[5] >> raPreferenceShift),
+		Lifetime:      seconds(uint32(binary.BigEndian.Uint16(b[6:8]))),
+		ReachableTime: time.Duration(binary.BigEndian.Uint32(b[8:12])) * time.Millisecond,
+		RetransTimer:  time.Duration(binary.BigEndian.Uint32(b[12:16])) * time.Millisecond,
+	}
+
+	options := b[routerAdvertisementLen:]
+	for len(options) > 0 {
+		if len(options) < 2 || options[1] == 0 || len(options) < int(options[1])*ndOptionUnit {
+			return RouterAdvertisement{}, &ErrCantParse{}
+		}
+		option := options[:int(options[1])*ndOptionUnit]
+		options = options[len(option):]
+
+		switch option[0] {
+		case ndOptionSourceLinkAddr:
+			// Ethernet addresses fill the option; other links pad it.
+			ra.SourceLinkAddr = net.HardwareAddr(append([]byte(nil), option[2:8]...))
+		case ndOptionPrefixInfo:
+			if len(option) != 32 || option[2] > 128 {
+				return RouterAdvertisement{}, &ErrCantParse{}
+			}
+			ra.Prefixes = append(ra.Prefixes, RAPrefix{
+				Prefix:            netip.PrefixFrom(netip.AddrFrom16([16]byte(option[16:32])), int(option[2])).Masked(),
+				OnLink:            option[3]&prefixFlagOnLink != 0,
+				Autonomous:        option[3]&prefixFlagAutonomous != 0,
+				ValidLifetime:     seconds(binary.BigEndian.Uint32(option[4:8])),
+				PreferredLifetime: seconds(binary.BigEndian.Uint32(option[8:12])),
+			})
+		case ndOptionMTU:
+			ra.MTU = int(binary.BigEndian.Uint32(option[4:8]))
+		case ndOptionRouteInfo:
+			// The prefix is shortened to the octets its length needs.
+			bits := int(option[2])
+			if bits > 128 || len(option)-8 < (bits+7)/8 {
+				return RouterAdvertisement{}, &ErrCantParse{}
+			}
+			var prefix [16]byte
+			copy(prefix[:], option[8:])
+			ra.Routes = append(ra.Routes, RARoute{
+				Prefix:     netip.PrefixFrom(netip.AddrFrom16(prefix), bits).Masked(),
+				Preference: decodeRouterPreference(option[3] >> raPreferenceShift),
+				Lifetime:   seconds(binary.BigEndian.Uint32(option[4:8])),
+			})
+		case ndOptionRDNSS:
+			if len(option) < 24 {
+				return RouterAdvertisement{}, &ErrCantParse{}
+			}
+			ra.RDNSSLifetime = seconds(binary.BigEndian.Uint32(option[4:8]))
+			for a := option[8:]; len(a) >= 16; a = a[16:] {
+				ra.RDNSS = append(ra.RDNSS, netip.AddrFrom16([16]byte(a[:16])))
+			}
+		case ndOptionPREF64:
+			if len(option) != 16 {
+				return RouterAdvertisement{}, &ErrCantParse{}
+			}
+			field := binary.BigEndian.Uint16(option[2:4])
+			bits, ok := pref64Lengths[field&0x7]
+			if !ok {
+				continue
+			}
+			var prefix [16]byte
+			copy(prefix[:], option[4:4+pref64PrefixBytes])
+			ra.PREF64 = netip.PrefixFrom(netip.AddrFrom16(prefix), bits).Masked()
+			ra.PREF64Lifetime = time.Duration(field>>3) * pref64LifetimeScale
+		}
+	}
+	return ra, nil
+}
+
+// pref64Lengths maps the Prefix Length Codes of PREF64 options to prefix
+// lengths.
+var pref64Lengths = map[uint16]int{0: 96, 1: 64, 2: 56, 3: 48, 4: 40, 5: 32}
+
+// Marshal encodes ra as an ICMPv6 message with a zero checksum, which the
+// kernel fills in for raw ICMPv6 sockets. Router is not encoded.
+func (ra RouterAdvertisement) Marshal() ([]byte, error) {
+	b := make([]byte, routerAdvertisementLen, 128)
+	b[0] = icmpv6RouterAdvertisement
+	b[4] = ra.HopLimit
+	if ra.Managed {
+		b[5] |= raFlagManaged
+	}
+	if ra.OtherConfig {
+		b[5] |= raFlagOtherConfig
+	}
+	b[5] |= ra.Preference.bits() << raPreferenceShift
+	routerLifetime := ra.Lifetime / time.Second
+	if routerLifetime < 0 || routerLifetime > 0xffff {
+		return nil, fmt.Errorf("router lifetime %v out of range", ra.Lifetime)
+	}
+	binary.BigEndian.PutUint16(b[6:8], uint16(routerLifetime))
+	binary.BigEndian.PutUint32(b[8:12], uint32(ra.ReachableTime/time.Millisecond))
+	binary.BigEndian.PutUint32(b[12:16], uint32(ra.RetransTimer/time.Millisecond))
+
+	if len(ra.SourceLinkAddr) > 0 {
+		b = appendNDOption(b, ndOptionSourceLinkAddr, ra.SourceLinkAddr)
+	}
+	if ra.MTU != 0 {
+		option := make([]byte, 6)
+		binary.BigEndian.PutUint32(option[2:], uint32(ra.MTU))
+		b = appendNDOption(b, ndOptionMTU, option)
+	}
+	for _, p := range ra.Prefixes {
+		if !p.Prefix.Addr().Is6() {
+			return nil, fmt.Errorf("prefix %v isn't an IPv6 prefix", p.Prefix)
+		}
+		option := make([]byte, 30)
+		option[0] = byte(p.Prefix.Bits())
+		if p.OnLink {
+			option[1] |= prefixFlagOnLink
+		}
+		if p.Autonomous {
+			option[1] |= prefixFlagAutonomous
+		}
+		binary.BigEndian.PutUint32(option[2:6], lifetime(p.ValidLifetime))
+		binary.BigEndian.PutUint32(option[6:10], lifetime(p.PreferredLifetime))
+		addr := p.Prefix.Masked().Addr().As16()
+		copy(option[14:], addr[:])
+		b = appendNDOption(b, ndOptionPrefixInfo, option)
+	}
+	for _, r := range ra.Routes {
+		if !r.Prefix.Addr().Is6() {
+			return nil, fmt.Errorf("route prefix %v isn't an IPv6 prefix", r.Prefix)
+		}
+		// The prefix takes 0, 8 or 16 octets, as its length needs.
+		prefixLen := (r.Prefix.Bits() + 63) / 64 * 8
+		option := make([]byte, 6+prefixLen)
+		option[0] = byte(r.Prefix.Bits())
+		option[1] = r.Preference.bits() << raPreferenceShift
+		binary.BigEndian.PutUint32(option[2:6], lifetime(r.Lifetime))
+		addr := r.Prefix.Masked().Addr().As16()
+		copy(option[6:], addr[:prefixLen])
+		b = appendNDOption(b, ndOptionRouteInfo, option)
+	}
+	if len(ra.RDNSS) > 0 {
+		option := make([]byte, 6, 6+16*len(ra.RDNSS))
+		binary.BigEndian.PutUint32(option[2:6], lifetime(ra.RDNSSLifetime))
+		for _, addr := range ra.RDNSS {
+			a := addr.As16()
+			option = append(option, a[:]...)
+		}
+		b = appendNDOption(b, ndOptionRDNSS, option)
+	}
+	if ra.PREF64.IsValid() {
+		var code uint16
+		found := false
+		for c, bits := range pref64Lengths {
+			if bits == ra.PREF64.Bits() {
+				code, found = c, true
+			}
+		}
+		scaled := ra.PREF64Lifetime / pref64LifetimeScale
+		switch {
+		case !found || !ra.PREF64.Addr().Is6():
+			return nil, fmt.Errorf("PREF64 %v isn't a NAT64 prefix", ra.PREF64)
+		case scaled < 0 || scaled > 0x1fff:
+			return nil, fmt.Errorf("PREF64 lifetime %v out of range", ra.PREF64Lifetime)
+		}
+		option := make([]byte, 14)
+		binary.BigEndian.PutUint16(option[0:2], uint16(scaled)<<3|code)
+		addr := ra.PREF64.Masked().Addr().As16()
+		copy(option[2:], addr[:pref64PrefixBytes])
+		b = appendNDOption(b, ndOptionPREF64, option)
+	}
+	return b, nil
+}
+
+// appendNDOption appends an option of the given type with value, padded
+// to a multiple of 8 octets.
+func appendNDOption(b []byte, optionType byte, value []byte) []byte {
+	length := (2 + len(value) + ndOptionUnit - 1) / ndOptionUnit
+	b = append(b, optionType, byte(length))
+	b = append(b, value...)
+	for i := 2 + len(value); i < length*ndOptionUnit; i++ {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// marshalRouterSolicitation encodes a Router Solicitation with the
+// hardware address of the sending interface, if it has one.
+func marshalRouterSolicitation(hardwareAddr net.HardwareAddr) []byte {
+	b := make([]byte, routerSolicitationLen)
+	b[0] = icmpv6RouterSolicitation
+	if len(hardwareAddr) > 0 {
+		b = appendNDOption(b, ndOptionSourceLinkAddr, hardwareAddr)
+	}
+	return b
+}
@@ -0,0 +1,351 @@
+package gateway
+
+import (
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+func routerAdvertisementBytes(t *testing.T) []byte {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(string(routeTables[routerAdvertisement])), ""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return data
+}
+
+var testRouterAdvertisement = RouterAdvertisement{
+	HopLimit:       64,
+	OtherConfig:    true,
+	Preference:     RouterPreferenceHigh,
+	Lifetime:       30 * time.Minute,
+	SourceLinkAddr: net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56},
+	MTU:            1500,
+	Prefixes: []RAPrefix{{
+		Prefix:            netip.MustParsePrefix("2001:db8:1::/64"),
+		OnLink:            true,
+		Autonomous:        true,
+		ValidLifetime:     30 * 24 * time.Hour,
+		PreferredLifetime: 7 * 24 * time.Hour,
+	}},
+	Routes: []RARoute{{
+		Prefix:     netip.MustParsePrefix("2001:db8:ff00::/48"),
+		Preference: RouterPreferenceLow,
+		Lifetime:   time.Hour,
+	}},
+	RDNSS:          []netip.Addr{netip.MustParseAddr("2001:db8:1::53")},
+	RDNSSLifetime:  30 * time.Minute,
+	PREF64:         netip.MustParsePrefix("64:ff9b::/96"),
+	PREF64Lifetime: 30 * time.Minute,
+}
+
+func TestParseRouterAdvertisement(t *testing.T) {
+	ra, err := ParseRouterAdvertisement(routerAdvertisementBytes(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ra, testRouterAdvertisement) {
+		t.Errorf("Expected %+v, got %+v", testRouterAdvertisement, ra)
+	}
+
+	marshaled, err := ra.Marshal()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The capture carries the checksum the kernel filled in.
+	want := routerAdvertisementBytes(t)
+	want[2], want[3] = 0, 0
+	if !reflect.DeepEqual(marshaled, want) {
+		t.Errorf("Expected\n%x, got\n%x", want, marshaled)
+	}
+}
+
+func TestRouterAdvertisementRoundTrip(t *testing.T) {
+	testcases := []RouterAdvertisement{
+		{},
+		{Managed: true, Preference: RouterPreferenceLow, Lifetime: 9000 * time.Second, ReachableTime: 30 * time.Second, RetransTimer: time.Second},
+		{Routes: []RARoute{
+			{Prefix: netip.MustParsePrefix("::/0"), Preference: RouterPreferenceHigh, Lifetime: time.Minute},
+			{Prefix: netip.MustParsePrefix("2001:db8:aa::/64"), Lifetime: time.Minute},
+			{Prefix: netip.MustParsePrefix("2001:db8:aa:bb:cc::/80"), Lifetime: time.Minute},
+		}},
+		{PREF64: netip.MustParsePrefix("2001:db8:64::/48"), PREF64Lifetime: 600 * time.Second},
+		{Prefixes: []RAPrefix{{Prefix: netip.MustParsePrefix("2001:db8::/64"), ValidLifetime: seconds(infiniteLifetime), PreferredLifetime: seconds(infiniteLifetime)}}},
+		{RDNSS: []netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("2001:db8::2")}, RDNSSLifetime: time.Hour},
+	}
+	for _, ra := range testcases {
+		b, err := ra.Marshal()
+		if err != nil {
+			t.Fatalf("Unexpected error for %+v: %v", ra, err)
+		}
+		if len(b)%ndOptionUnit != 0 {
+			t.Errorf("Expected a multiple of 8 octets, got %d", len(b))
+		}
+		got, err := ParseRouterAdvertisement(b)
+		if err != nil {
+			t.Fatalf("Unexpected error for %+v: %v", ra, err)
+		}
+		if !reflect.DeepEqual(got, ra) {
+			t.Errorf("Expected %+v, got %+v", ra, got)
+		}
+	}
+}
+
+func TestMarshalRouterAdvertisementInvalid(t *testing.T) {
+	testcases := []RouterAdvertisement{
+		{Lifetime: 0x10000 * time.Second},
+		{Prefixes: []RAPrefix{{Prefix: netip.MustParsePrefix("192.0.2.0/24")}}},
+		{PREF64: netip.MustParsePrefix("64:ff9b::/80")},
+		{PREF64: netip.MustParsePrefix("64:ff9b::/96"), PREF64Lifetime: 24 * time.Hour},
+	}
+	for _, ra := range testcases {
+		if _, err := ra.Marshal(); err == nil {
+			t.Errorf("Expected an error for %+v", ra)
+		}
+	}
+}
+
+func TestParseRouterAdvertisementInvalid(t *testing.T) {
+	valid := routerAdvertisementBytes(t)
+	testcases := map[string][]byte{
+		"empty":             nil,
+		"short header":      valid[:12],
+		"solicitation":      marshalRouterSolicitation(nil),
+		"zero length":       append(valid[:16:16], 1, 0, 0, 0, 0, 0, 0, 0),
+		"truncated option":  valid[:len(valid)-4],
+		"short prefix info": append(valid[:16:16], 3, 1, 64, 0, 0, 0, 0, 0),
+		"long prefix":       append(valid[:16:16], 24, 1, 129, 0, 0, 0, 0, 0),
+	}
+	for name, b := range testcases {
+		_, err := ParseRouterAdvertisement(b)
+		var cantParse *ErrCantParse
+		if !errors.As(err, &cantParse) {
+			t.Errorf("%s: Expected ErrCantParse, got %v", name, err)
+		}
+	}
+
+	// Options of unknown types and PREF64 with a reserved prefix length
+	// code are skipped.
+	b := append(valid[:16:16], 200, 1, 0, 0, 0, 0, 0, 0, 38, 2, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	ra, err := ParseRouterAdvertisement(b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ra.PREF64.IsValid() {
+		t.Errorf("Expected no PREF64, got %v", ra.PREF64)
+	}
+}
+
+func TestMarshalRouterSolicitation(t *testing.T) {
+	got := marshalRouterSolicitation(net.HardwareAddr{0x02, 0, 0, 0, 0, 1})
+	want := []byte{133, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0x02, 0, 0, 0, 0, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %x, got %x", want, got)
+	}
+	if got := marshalRouterSolicitation(nil); len(got) != routerSolicitationLen {
+		t.Errorf("Expected a bare solicitation, got %x", got)
+	}
+}
+
+// udpRAConn stands in for an ICMPv6 socket: it exchanges the messages
+// with fake routers over UDP on the loopback interface, which needs no
+// privileges. Each fake router is reported with a link-local address.
+type udpRAConn struct {
+	*net.UDPConn
+	routers map[netip.AddrPort]netip.Addr
+}
+
+func (c *udpRAConn) SolicitRouters(msg []byte) error {
+	for addrPort := range c.routers {
+		if _, err := c.WriteToUDPAddrPort(msg, addrPort); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *udpRAConn) ReadAdvertisement(b []byte) (int, netip.Addr, error) {
+	for {
+		n, addrPort, err := c.ReadFromUDPAddrPort(b)
+		if err != nil {
+			return 0, netip.Addr{}, err
+		}
+		if router, ok := c.routers[addrPort]; ok {
+			return n, router, nil
+		}
+	}
+}
+
+// fakeRouter answers each Router Solicitation with its advertisements.
+func fakeRouter(t *testing.T, advertisements ...RouterAdvertisement) netip.AddrPort {
+	return udpStandIn(t, "udp6", func(req []byte) [][]byte {
+		if len(req) < routerSolicitationLen || req[0] != icmpv6RouterSolicitation {
+			return nil
+		}
+		var msgs [][]byte
+		for _, ra := range advertisements {
+			msg, err := ra.Marshal()
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return nil
+			}
+			msgs = append(msgs, msg)
+		}
+		return msgs
+	})
+}
+
+func newUDPRAConn(t *testing.T, routers map[netip.AddrPort]netip.Addr) *udpRAConn {
+	return &udpRAConn{UDPConn: listenLoopback(t, "udp6"), routers: routers}
+}
+
+func TestDiscoverRouters(t *testing.T) {
+	primary := netip.MustParseAddr("fe80::1%veth0")
+	backup := netip.MustParseAddr("fe80::2%veth0")
+	nonDefault := netip.MustParseAddr("fe80::3%veth0")
+
+	stale := testRouterAdvertisement
+	stale.MTU = 1280
+	conn := newUDPRAConn(t, map[netip.AddrPort]netip.Addr{
+		fakeRouter(t, RouterAdvertisement{Preference: RouterPreferenceHigh, Routes: []RARoute{{Prefix: netip.MustParsePrefix("2001:db8:ff00::/48")}}}): nonDefault,
+		fakeRouter(t, RouterAdvertisement{Preference: RouterPreferenceLow, Lifetime: time.Hour}):                                                       backup,
+		fakeRouter(t, stale, testRouterAdvertisement):                                                                                                  primary,
+	})
+	ras, err := discoverRouters(conn, nil, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var routers []netip.Addr
+	for _, ra := range ras {
+		routers = append(routers, ra.Router)
+	}
+	if !reflect.DeepEqual(routers, []netip.Addr{primary, backup, nonDefault}) {
+		t.Fatalf("Expected routers ordered by preference, got %v", routers)
+	}
+	if ras[0].MTU != 1500 {
+		t.Errorf("Expected the latest advertisement of %v, got MTU %d", primary, ras[0].MTU)
+	}
+
+	route, ok := ras[0].DefaultRoute()
+	want := Route{
+		Destination: netip.MustParsePrefix("::/0"),
+		Gateway:     primary,
+		Interface:   "veth0",
+		Protocol:    "ra",
+	}
+	if !ok || !reflect.DeepEqual(route, want) {
+		t.Errorf("Expected %+v, got %+v", want, route)
+	}
+}
+
+func TestDiscoverRoutersNoRouter(t *testing.T) {
+	conn := newUDPRAConn(t, map[netip.AddrPort]netip.Addr{
+		fakeRouter(t): netip.MustParseAddr("fe80::1%veth0"),
+	})
+	_, err := discoverRouters(conn, nil, 100*time.Millisecond)
+	var noGateway *ErrNoGateway
+	if !errors.As(err, &noGateway) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+}
+
+func TestRouterAdvertisementDefaultRoute(t *testing.T) {
+	ra := RouterAdvertisement{Router: netip.MustParseAddr("fe80::1%eth0")}
+	if _, ok := ra.DefaultRoute(); ok {
+		t.Errorf("Expected no default route for a zero router lifetime")
+	}
+}
+
+// linkLocalInterface returns an interface that is up and its link-local
+// address.
+func linkLocalInterface(t *testing.T) (*net.Interface, netip.Addr) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("No interfaces: %v", err)
+	}
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok {
+				if addr, ok := ipToAddr(ipNet.IP); ok && addr.Is6() && addr.IsLinkLocalUnicast() {
+					return iface, addr
+				}
+			}
+		}
+	}
+	t.Skip("No interface with a link-local address")
+	return nil, netip.Addr{}
+}
+
+// TestICMPRAConn exercises the raw socket of DiscoverRouters: the host
+// advertises to itself on a link-local address. It needs CAP_NET_RAW or
+// the like.
+func TestICMPRAConn(t *testing.T) {
+	iface, local := linkLocalInterface(t)
+	conn, err := listenRouterAdvertisements(iface)
+	if err != nil {
+		t.Skipf("No raw ICMPv6 socket: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SolicitRouters(marshalRouterSolicitation(iface.HardwareAddr)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	router, err := icmp.ListenPacket("ip6:ipv6-icmp", local.WithZone(iface.Name).String())
+	if err != nil {
+		t.Skipf("No raw ICMPv6 socket: %v", err)
+	}
+	defer router.Close()
+	dst := &net.IPAddr{IP: addrToIP(local), Zone: iface.Name}
+	send := func(ra RouterAdvertisement, hopLimit int) {
+		msg, err := ra.Marshal()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := router.IPv6PacketConn().SetHopLimit(hopLimit); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := router.WriteTo(msg, dst); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// Forwarded advertisements are dropped.
+	send(RouterAdvertisement{MTU: 1280}, 64)
+	send(RouterAdvertisement{MTU: 1500, Lifetime: time.Minute}, ndHopLimit)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, 1500)
+	for {
+		n, src, err := conn.ReadAdvertisement(b)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ra, err := ParseRouterAdvertisement(b[:n])
+		if err != nil {
+			// Other advertisements of the link are not the host's.
+			continue
+		}
+		if src != local.WithZone(iface.Name) {
+			continue
+		}
+		if ra.MTU != 1500 {
+			t.Errorf("Expected the advertisement with hop limit %d, got MTU %d", ndHopLimit, ra.MTU)
+		}
+		return
+	}
+}
@@ -0,0 +1,190 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+// ndHopLimit is the hop limit of Neighbor Discovery messages. Messages
+// with another hop limit were forwarded and must be dropped.
+const ndHopLimit = 255
+
+// allRouters is the link-local multicast group of all routers.
+var allRouters = netip.MustParseAddr("ff02::2")
+
+// raConn is the socket DiscoverRouters solicits and receives Router
+// Advertisements through. It is an ICMPv6 socket bound to one interface,
+// or a stand-in in tests.
+type raConn interface {
+	// SolicitRouters sends msg to all routers on the link.
+	SolicitRouters(msg []byte) error
+
+	// ReadAdvertisement reads the next ICMPv6 message together with the
+	// address of its sender. Messages that can't be Router
+	// Advertisements, such as forwarded ones, are skipped.
+	ReadAdvertisement(b []byte) (n int, src netip.Addr, err error)
+
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+// icmpRAConn is a raw ICMPv6 socket that only receives Router
+// Advertisements of one interface.
+type icmpRAConn struct {
+	conn  *icmp.PacketConn
+	pc    *ipv6.PacketConn
+	iface *net.Interface
+}
+
+// listenRouterAdvertisements opens a raw ICMPv6 socket for iface, which
+// needs privileges on most operating systems (CAP_NET_RAW on Linux).
+func listenRouterAdvertisements(iface *net.Interface) (*icmpRAConn, error) {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return nil, err
+	}
+	pc := conn.IPv6PacketConn()
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+	for _, setup := range []func() error{
+		func() error { return pc.SetICMPFilter(&filter) },
+		func() error { return pc.SetMulticastHopLimit(ndHopLimit) },
+		func() error { return pc.SetHopLimit(ndHopLimit) },
+		func() error { return pc.SetMulticastInterface(iface) },
+		func() error { return pc.SetControlMessage(ipv6.FlagHopLimit|ipv6.FlagInterface, true) },
+	} {
+		if err := setup(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &icmpRAConn{conn: conn, pc: pc, iface: iface}, nil
+}
+
+func (c *icmpRAConn) SolicitRouters(msg []byte) error {
+	dst := &net.IPAddr{IP: addrToIP(allRouters), Zone: c.iface.Name}
+	_, err := c.pc.WriteTo(msg, &ipv6.ControlMessage{HopLimit: ndHopLimit, IfIndex: c.iface.Index}, dst)
+	return err
+}
+
+func (c *icmpRAConn) ReadAdvertisement(b []byte) (int, netip.Addr, error) {
+	for {
+		n, cm, src, err := c.pc.ReadFrom(b)
+		if err != nil {
+			return 0, netip.Addr{}, err
+		}
+		ipAddr, ok := src.(*net.IPAddr)
+		if !ok {
+			continue
+		}
+		addr, ok := ipToAddr(ipAddr.IP)
+		// Routers send from their link-local address (RFC 4861 6.1.2).
+		if !ok || !addr.IsLinkLocalUnicast() {
+			continue
+		}
+		if cm != nil && (cm.HopLimit != ndHopLimit || cm.IfIndex != 0 && cm.IfIndex != c.iface.Index) {
+			continue
+		}
+		return n, addr.WithZone(c.iface.Name), nil
+	}
+}
+
+func (c *icmpRAConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *icmpRAConn) Close() error {
+	return c.conn.Close()
+}
+
+// DiscoverRouters sends a Router Solicitation on the named interface and
+// returns the Router Advertisements received within timeout, one per
+// router, default routers first and ordered by preference. It finds the
+// IPv6 routers of a link even where the routing table has no default
+// route yet, for example right after boot.
+//
+// Raw ICMPv6 sockets need privileges; without them the error of opening
+// the socket is returned. ErrNoGateway is returned if no router answers.
+func DiscoverRouters(ifaceName string, timeout time.Duration) ([]RouterAdvertisement, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := listenRouterAdvertisements(iface)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return discoverRouters(conn, iface.HardwareAddr, timeout)
+}
+
+func discoverRouters(conn raConn, hardwareAddr net.HardwareAddr, timeout time.Duration) ([]RouterAdvertisement, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if err := conn.SolicitRouters(marshalRouterSolicitation(hardwareAddr)); err != nil {
+		return nil, err
+	}
+
+	var result []RouterAdvertisement
+	b := make([]byte, 1500)
+	for {
+		n, src, err := conn.ReadAdvertisement(b)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ra, err := ParseRouterAdvertisement(b[:n])
+		if err != nil {
+			continue
+		}
+		ra.Router = src
+		// A later advertisement of the same router supersedes the earlier.
+		if i := slices.IndexFunc(result, func(r RouterAdvertisement) bool { return r.Router == src }); i >= 0 {
+			result[i] = ra
+		} else {
+			result = append(result, ra)
+		}
+	}
+	if len(result) == 0 {
+		return nil, &ErrNoGateway{}
+	}
+
+	isDefault := func(ra RouterAdvertisement) int {
+		if ra.Lifetime > 0 {
+			return 0
+		}
+		return 1
+	}
+	slices.SortStableFunc(result, func(a, b RouterAdvertisement) int {
+		if c := isDefault(a) - isDefault(b); c != 0 {
+			return c
+		}
+		return int(b.Preference) - int(a.Preference)
+	})
+	return result, nil
+}
+
+// DefaultRoute returns the default route through the router of ra, or
+// false if the router isn't a default router.
+func (ra RouterAdvertisement) DefaultRoute() (Route, bool) {
+	if ra.Lifetime <= 0 || !ra.Router.IsValid() {
+		return Route{}, false
+	}
+	return Route{
+		Destination: netip.PrefixFrom(netip.IPv6Unspecified(), 0),
+		Gateway:     ra.Router,
+		Interface:   ra.Router.Zone(),
+		Protocol:    "ra",
+	}, true
+}
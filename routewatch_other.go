@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package gateway
+
+import "io"
+
+func watchRouteChanges(onChange func()) (io.Closer, error) {
+	return nil, &ErrNotImplemented{}
+}
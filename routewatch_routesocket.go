@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd netbsd openbsd solaris
+
+package gateway
+
+import (
+	"io"
+	"syscall"
+)
+
+func watchRouteChanges(onChange func()) (io.Closer, error) {
+	// A routing socket receives a copy of every change to the routing
+	// table and to interface addresses, see route(4).
+	fd, err := syscall.Socket(syscall.AF_ROUTE, syscall.SOCK_RAW, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	syscall.CloseOnExec(fd)
+
+	return watchSocket(fd, "route", onChange)
+}
@@ -0,0 +1,41 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package gateway
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// watchSocket reads routing messages from fd and calls onChange for each
+// one. The messages themselves are not decoded: any change to routes,
+// links or addresses may change the answers of the Discover* functions.
+func watchSocket(fd int, name string, onChange func()) (io.Closer, error) {
+	// A non-blocking descriptor lets os.File use the runtime poller, so
+	// that Close unblocks the pending Read.
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), name)
+
+	go func() {
+		buf := make([]byte, os.Getpagesize())
+		for {
+			_, err := f.Read(buf)
+			if errors.Is(err, syscall.ENOBUFS) {
+				// The kernel dropped messages; we can't know what changed.
+				onChange()
+				continue
+			}
+			if err != nil {
+				return
+			}
+			onChange()
+		}
+	}()
+	return f, nil
+}
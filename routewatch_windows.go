@@ -0,0 +1,98 @@
+//go:build windows
+// +build windows
+
+package gateway
+
+import (
+	"io"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	iphlpapi                         = syscall.NewLazyDLL("iphlpapi.dll")
+	procNotifyRouteChange2           = iphlpapi.NewProc("NotifyRouteChange2")
+	procNotifyUnicastIpAddressChange = iphlpapi.NewProc("NotifyUnicastIpAddressChange")
+	procCancelMibChangeNotify2       = iphlpapi.NewProc("CancelMibChangeNotify2")
+)
+
+var (
+	// syscall.NewCallback never releases its callbacks, so a single one
+	// is shared by all watchers and dispatches on the caller context.
+	routeChangeCallbackOnce sync.Once
+	routeChangeCallback     uintptr
+
+	routeWatchersMu  sync.Mutex
+	routeWatchers    = make(map[uintptr]func())
+	nextRouteWatcher uintptr
+)
+
+// routeChanged is the PIPFORWARD_CHANGE_CALLBACK passed to NotifyRouteChange2
+// and, having the same parameters, the PUNICAST_IPADDRESS_CHANGE_CALLBACK
+// passed to NotifyUnicastIpAddressChange.
+func routeChanged(callerContext, row, notificationType uintptr) uintptr {
+	routeWatchersMu.Lock()
+	onChange := routeWatchers[callerContext]
+	routeWatchersMu.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+	return 0
+}
+
+type routeChangeWatcher struct {
+	id uintptr
+
+	// handles are those of the route and of the address notifications.
+	handles []syscall.Handle
+}
+
+func watchRouteChanges(onChange func()) (io.Closer, error) {
+	if err := procNotifyRouteChange2.Find(); err != nil {
+		return nil, err
+	}
+	if err := procNotifyUnicastIpAddressChange.Find(); err != nil {
+		return nil, err
+	}
+	routeChangeCallbackOnce.Do(func() {
+		routeChangeCallback = syscall.NewCallback(routeChanged)
+	})
+
+	routeWatchersMu.Lock()
+	nextRouteWatcher++
+	id := nextRouteWatcher
+	routeWatchers[id] = onChange
+	routeWatchersMu.Unlock()
+
+	// DiscoverInterface answers with an address of an interface, which can
+	// change while the routes stay the same.
+	w := &routeChangeWatcher{id: id}
+	const afUnspec = 0
+	for _, notify := range []*syscall.LazyProc{procNotifyRouteChange2, procNotifyUnicastIpAddressChange} {
+		var handle syscall.Handle
+		r, _, _ := notify.Call(afUnspec, routeChangeCallback, id, 0, uintptr(unsafe.Pointer(&handle)))
+		if r != 0 {
+			w.Close()
+			return nil, syscall.Errno(r)
+		}
+		w.handles = append(w.handles, handle)
+	}
+	return w, nil
+}
+
+func (w *routeChangeWatcher) Close() error {
+	var err error
+	for _, handle := range w.handles {
+		if r, _, _ := procCancelMibChangeNotify2.Call(uintptr(handle)); r != 0 && err == nil {
+			err = syscall.Errno(r)
+		}
+	}
+
+	routeWatchersMu.Lock()
+	delete(routeWatchers, w.id)
+	routeWatchersMu.Unlock()
+
+	return err
+}
@@ -0,0 +1,293 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+	"slices"
+)
+
+// Snapshot is a consistent view of the IPv4 and IPv6 routing tables,
+// captured by a single query of the operating system.
+//
+// The individual Discover* functions each read the routing table again, so
+// a gateway and an interface fetched one after the other can disagree while
+// the network changes. All answers of a Snapshot come from the same view.
+type Snapshot struct {
+	// Routes holds the routes of both address families. The IPv4 and
+	// then the IPv6 default routes come first, each ordered by preference.
+	Routes []Route
+
+	// Persistent holds the routes configured to survive restarts, on
+	// operating systems that keep them apart from the routing table
+	// (Windows). Routes that are configured but not in effect are
+	// flagged Inactive and appear only here.
+	Persistent []Route
+
+	// addrs holds the addresses of the interfaces used by default
+	// routes, captured together with the routes.
+	addrs map[string][]net.Addr
+
+	// ifaceGetter looks up the addresses of other interfaces.
+	ifaceGetter interfaceGetter
+}
+
+// DiscoverSnapshot is the OS independent function to capture the IPv4 and
+// IPv6 routing tables in one go.
+func DiscoverSnapshot() (Snapshot, error) {
+	return discoverSnapshotOSSpecific()
+}
+
+// Snapshot captures the routing tables of the operating system.
+func (osDiscoverer) Snapshot() (Snapshot, error) {
+	return discoverSnapshotOSSpecific()
+}
+
+// newSnapshot moves the default routes to the front of routes, ordered by
+// family and effective metric, and records the addresses of the interfaces they use.
+// Routes whose Kind isn't known yet are classified by interface name.
+// Default routes on interfaces that are down or have no carrier come
+// after the others of their family.
+func newSnapshot(routes []Route, ifaceGetter interfaceGetter) Snapshot {
+	s := Snapshot{
+		Routes:      routes,
+		addrs:       make(map[string][]net.Addr),
+		ifaceGetter: ifaceGetter,
+	}
+	for i := range s.Routes {
+		r := &s.Routes[i]
+		if !r.forwardsByDefault() {
+			continue
+		}
+
+		var iface *net.Interface
+		var err error
+		switch {
+		case r.Interface != "":
+			iface, err = ifaceGetter.InterfaceByName(r.Interface)
+		case r.InterfaceIndex != 0:
+			iface, err = ifaceGetter.InterfaceByIndex(r.InterfaceIndex)
+		default:
+			continue
+		}
+		if err != nil {
+			// The interface went away since the routes were read;
+			// queries that need its addresses will fail.
+			continue
+		}
+		if r.Interface == "" {
+			r.Interface = iface.Name
+		}
+		if r.InterfaceIndex == 0 {
+			r.InterfaceIndex = iface.Index
+		}
+		if r.HardwareAddr == nil && len(iface.HardwareAddr) > 0 {
+			r.HardwareAddr = slices.Clone(iface.HardwareAddr)
+		}
+		if r.LinkState == LinkStateUnknown {
+			r.LinkState = interfaceLinkState(iface)
+		}
+		if _, ok := s.addrs[r.Interface]; ok {
+			continue
+		}
+		if addrs, err := ifaceGetter.Addrs(iface); err == nil {
+			s.addrs[r.Interface] = addrs
+		}
+	}
+
+	// Operating systems that can't tell the kind or state of an
+	// interface leave it to its name and the route's flags.
+	for i := range s.Routes {
+		r := &s.Routes[i]
+		if r.Kind == InterfaceKindUnknown {
+			r.Kind = classifyInterfaceName(r.Interface, r.InterfaceDescription)
+		}
+		if r.LinkState == LinkStateUnknown {
+			r.LinkState = flagsLinkState(*r)
+		}
+	}
+
+	rank := func(r Route) int {
+		switch {
+		case !r.forwardsByDefault():
+			return 4
+		case r.Is6():
+			if r.LinkState.dead() {
+				return 3
+			}
+			return 2
+		case r.LinkState.dead():
+			return 1
+		default:
+			return 0
+		}
+	}
+	slices.SortStableFunc(s.Routes, func(a, b Route) int {
+		if c := rank(a) - rank(b); c != 0 || rank(a) == 4 {
+			return c
+		}
+		return a.EffectiveMetric() - b.EffectiveMetric()
+	})
+	return s
+}
+
+// DefaultRoutes returns the default routes of the given family in order of
+// preference. Default routes that don't forward packets, such as blackhole
+// routes, those of other tables than the main one and those scoped to
+// their interface (Darwin) are left out.
+func (s Snapshot) DefaultRoutes(ipv6 bool) []Route {
+	var result []Route
+	for _, r := range s.Routes {
+		if r.forwardsByDefault() && r.Is6() == ipv6 {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Gateways returns the IPv4 default gateways of the snapshot, the same
+// answer DiscoverGateways gives. Default routes without a next hop are
+// skipped. If err is nil, then ips is guaranteed to have at least one
+// element.
+func (s Snapshot) Gateways() (ips []net.IP, err error) {
+	return s.gateways(false)
+}
+
+// GatewaysIPv6 returns the IPv6 default gateways of the snapshot, the same
+// answer DiscoverGatewaysIPv6 gives. If err is nil, then ips is guaranteed
+// to have at least one element.
+func (s Snapshot) GatewaysIPv6() (ips []net.IP, err error) {
+	return s.gateways(true)
+}
+
+// Interface returns the IPv4 address of the interface used by the
+// preferred default route, the same answer DiscoverInterface gives.
+func (s Snapshot) Interface() (ip net.IP, err error) {
+	return s.interfaceIP(false)
+}
+
+// InterfaceIPv6 returns the IPv6 address of the interface used by the
+// preferred default route, the same answer DiscoverInterfaceIPv6 gives.
+func (s Snapshot) InterfaceIPv6() (ip net.IP, err error) {
+	return s.interfaceIP(true)
+}
+
+// PhysicalGateway returns the gateway of the preferred default route of
+// the given family that doesn't go through a tunnel, such as the gateway
+// of the local network while a VPN is connected. Routes whose interface
+// couldn't be classified count as physical.
+func (s Snapshot) PhysicalGateway(ipv6 bool) (ip net.IP, err error) {
+	for _, r := range s.DefaultRoutes(ipv6) {
+		if r.Kind == InterfaceKindTunnel || r.Kind == InterfaceKindLoopback {
+			continue
+		}
+		if gws := r.gateways(); len(gws) > 0 {
+			return addrToIP(gws[0]), nil
+		}
+	}
+	return nil, &ErrNoGateway{}
+}
+
+func (s Snapshot) gateways(ipv6 bool) ([]net.IP, error) {
+	seen := make(map[string]bool)
+	var result []net.IP
+	for _, r := range s.DefaultRoutes(ipv6) {
+		for _, gw := range r.gateways() {
+			ip := addrToIP(gw)
+			key := ip.String()
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, ip)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, &ErrNoGateway{}
+	}
+	return result, nil
+}
+
+// gatewayAddrsIPv6 returns the IPv6 default gateways with the zones of
+// link-local addresses, the same answer DiscoverGatewaysIPv6Addrs gives.
+func (s Snapshot) gatewayAddrsIPv6() ([]net.IPAddr, error) {
+	seen := make(map[string]bool)
+	var result []net.IPAddr
+	for _, r := range s.DefaultRoutes(true) {
+		for _, gw := range r.gateways() {
+			addr := net.IPAddr{IP: addrToIP(gw), Zone: gw.Zone()}
+			key := addr.String()
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, addr)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, &ErrNoGateway{}
+	}
+	return result, nil
+}
+
+// preferredDefaultRoute returns the default route whose interface answers
+// the questions about "the" interface of the host.
+func (s Snapshot) preferredDefaultRoute(ipv6 bool) (Route, bool) {
+	routes := s.DefaultRoutes(ipv6)
+	if len(routes) == 0 {
+		return Route{}, false
+	}
+
+	// Prefer the interface of a route through a gateway over one of an
+	// on-link default route, such as a VPN's point-to-point link.
+	for _, r := range routes {
+		if r.Gateway.IsValid() {
+			return r, true
+		}
+	}
+	return routes[0], true
+}
+
+func (s Snapshot) interfaceIP(ipv6 bool) (net.IP, error) {
+	r, ok := s.preferredDefaultRoute(ipv6)
+	if !ok {
+		return nil, &ErrNoGateway{}
+	}
+	if r.Source.IsValid() {
+		return addrToIP(r.Source), nil
+	}
+
+	var ip net.IP
+	if ipv6 {
+		ip = pickIP6(s.addrs[r.Interface])
+	} else {
+		ip = pickIP4(s.addrs[r.Interface])
+	}
+	if ip == nil {
+		family := "IPv4"
+		if ipv6 {
+			family = "IPv6"
+		}
+		return nil, fmt.Errorf("no %s address found for interface %v", family, r.Interface)
+	}
+	return ip, nil
+}
+
+// cloneSnapshot returns a copy of s whose routes can be modified without
+// affecting s.
+func cloneSnapshot(s Snapshot) Snapshot {
+	s.Routes = cloneRoutes(s.Routes)
+	s.Persistent = cloneRoutes(s.Persistent)
+	return s
+}
+
+func cloneRoutes(routes []Route) []Route {
+	routes = slices.Clone(routes)
+	for i := range routes {
+		r := &routes[i]
+		r.HardwareAddr = slices.Clone(r.HardwareAddr)
+		r.Flags = slices.Clone(r.Flags)
+		r.Nexthops = slices.Clone(r.Nexthops)
+		for j := range r.Nexthops {
+			r.Nexthops[j].Flags = slices.Clone(r.Nexthops[j].Flags)
+		}
+	}
+	return routes
+}
@@ -0,0 +1,292 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// defaultRoutes returns the default routes of routes in table order.
+func defaultRoutes(routes []Route) []Route {
+	var result []Route
+	for _, r := range routes {
+		if r.IsDefault() {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func TestParseLinuxRoutes(t *testing.T) {
+	routes, err := parseLinuxRoutes(routeTables[linux], binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(routes) != 5 {
+		t.Errorf("Expected 5 routes, got %d", len(routes))
+	}
+
+	want := Route{
+		Destination: netip.MustParsePrefix("0.0.0.0/0"),
+		Gateway:     netip.MustParseAddr("192.168.8.1"),
+		Interface:   "wlp4s0",
+		Metric:      600,
+	}
+	if got := defaultRoutes(routes); len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Errorf("Unexpected default routes %+v", got)
+	}
+
+	if routes[0].Destination != netip.MustParsePrefix("169.254.0.0/16") || routes[0].Gateway.IsValid() {
+		t.Errorf("Unexpected on-link route %+v", routes[0])
+	}
+
+	var formatErr *ErrInvalidRouteFileFormat
+	if _, err := parseLinuxRoutes([]byte("Iface\tDestination\nfoo\tbar\n"), binary.LittleEndian); !errors.As(err, &formatErr) {
+		t.Errorf("Expected ErrInvalidRouteFileFormat, got %v", err)
+	}
+}
+
+func TestParseLinuxProcNetRouteBigEndian(t *testing.T) {
+	routes, err := ParseLinuxProcNetRoute(routeTables[linuxBigEndian], binary.BigEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []Route{
+		{
+			Destination: netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:     netip.MustParseAddr("192.168.1.1"),
+			Interface:   "enc1",
+		},
+		{
+			Destination: netip.MustParsePrefix("192.168.1.0/24"),
+			Interface:   "enc1",
+		},
+		{
+			Destination: netip.MustParsePrefix("10.0.0.0/8"),
+			Interface:   "enc2",
+		},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("Unexpected routes %+v", routes)
+	}
+
+	// Read in the wrong byte order, the addresses come out reversed.
+	routes, err = ParseLinuxProcNetRoute(routeTables[linuxBigEndian], binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if routes[0].Gateway != netip.MustParseAddr("1.1.168.192") {
+		t.Errorf("Unexpected gateway %v", routes[0].Gateway)
+	}
+}
+
+func TestParseLinuxIPv6Routes(t *testing.T) {
+	routes, err := parseLinuxIPv6Routes(routeTables[linuxIPv6])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(routes) != 4 {
+		t.Errorf("Expected 4 routes, got %d", len(routes))
+	}
+
+	want := Route{
+		Destination: netip.MustParsePrefix("::/0"),
+		Gateway:     netip.MustParseAddr("fe80::242:acff:fe11:3%eth0"),
+		Interface:   "eth0",
+		Metric:      100,
+	}
+	if got := defaultRoutes(routes); len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Errorf("Unexpected default routes %+v", got)
+	}
+	if routes[2].Destination != netip.MustParsePrefix("2001:db8::/64") {
+		t.Errorf("Unexpected destination %v", routes[2].Destination)
+	}
+}
+
+func TestParseNetstatRoutes(t *testing.T) {
+	type testcase struct {
+		tableName string
+		want      []Route
+	}
+
+	testcases := []testcase{
+		{freeBSD, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("10.88.88.2"), Interface: "ena0"},
+		}},
+		{netBSD, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("172.31.16.1"), Interface: "ena0"},
+		}},
+		{solaris, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("172.16.32.1"), Interface: "net0"},
+		}},
+		{solarisNoInterface, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("172.16.32.1")},
+			{Destination: netip.MustParsePrefix("::/0"), Gateway: netip.MustParseAddr("fe80::aabb:ccdd:1234:1")},
+		}},
+		{darwin, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Interface: "utun3"},
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.254"), Interface: "en0", Flags: []string{ifscopeFlag}},
+		}},
+		{freeBSDNoRoute, nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			routes, err := parseNetstatRoutes(routeTables[tc.tableName])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := defaultRoutes(routes)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Unexpected default routes %+v", got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tc.want[i]) {
+					t.Errorf("Unexpected default route %+v != %+v", got[i], tc.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("netBSD abbreviated destinations", func(t *testing.T) {
+		routes, err := parseNetstatRoutes(routeTables[netBSD])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if routes[1].Destination != netip.MustParsePrefix("127.0.0.0/8") {
+			t.Errorf("Unexpected destination %v", routes[1].Destination)
+		}
+		if routes[3].Destination != netip.MustParsePrefix("172.31.16.0/20") {
+			t.Errorf("Unexpected destination %v", routes[3].Destination)
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := parseNetstatRoutes(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestParseWindowsRoutes(t *testing.T) {
+	routes, err := parseWindowsRoutes(routeTables[windowsDualStack])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(routes) != 9 {
+		t.Errorf("Expected 9 active routes, got %d: %+v", len(routes), routes)
+	}
+
+	want := []Route{
+		{
+			Destination: netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:     netip.MustParseAddr("192.168.1.1"),
+			Source:      netip.MustParseAddr("192.168.1.100"),
+			Metric:      25,
+			Persistent:  true,
+		},
+		{
+			Destination:          netip.MustParsePrefix("::/0"),
+			Gateway:              netip.MustParseAddr("fe80::1%12"),
+			InterfaceIndex:       12,
+			InterfaceDescription: "Intel(R) Ethernet Connection I219-LM",
+			HardwareAddr:         net.HardwareAddr{0x00, 0x15, 0x5d, 0x01, 0x02, 0x03},
+			Metric:               281,
+		},
+	}
+	got := defaultRoutes(routes)
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected default routes %+v", got)
+	}
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("Unexpected default route %+v != %+v", got[i], want[i])
+		}
+	}
+
+	// The gateway of a long destination is on the following line.
+	wrapped := routes[7]
+	if wrapped.Destination != netip.MustParsePrefix("2001:db8:1234:5678:a1b2:c3d4:e5f6:1234/128") || wrapped.Gateway.IsValid() {
+		t.Errorf("Unexpected wrapped route %+v", wrapped)
+	}
+
+	t.Run(windowsLocalized2, func(t *testing.T) {
+		routes, err := parseWindowsRoutes(routeTables[windowsLocalized2])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(routes) != 2 || routes[0].Gateway != netip.MustParseAddr("192.168.100.1") || routes[1].Gateway.IsValid() {
+			t.Errorf("Unexpected routes %+v", routes)
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := parseWindowsRoutes(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	routes, err := parseLinuxRoutes(routeTables[linux], binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes6, err := parseLinuxIPv6Routes(routeTables[linuxIPv6])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes = append(routes, routes6...)
+
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByName", "wlp4s0").Return(&net.Interface{Name: "wlp4s0"}, nil).Once()
+	mockGetter.On("InterfaceByName", "eth0").Return(&net.Interface{Name: "eth0"}, nil).Once()
+	mockGetter.On("Addrs", mock.MatchedBy(func(iface *net.Interface) bool { return iface.Name == "wlp4s0" })).Return([]net.Addr{
+		&net.IPNet{IP: net.ParseIP("192.168.8.238"), Mask: net.CIDRMask(24, 32)},
+	}, nil).Once()
+	mockGetter.On("Addrs", mock.MatchedBy(func(iface *net.Interface) bool { return iface.Name == "eth0" })).Return([]net.Addr{
+		&net.IPNet{IP: net.ParseIP("fe80::42:acff:fe11:2"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("2001:db8::2"), Mask: net.CIDRMask(64, 128)},
+	}, nil).Once()
+
+	s := newSnapshot(routes, mockGetter)
+
+	if !s.Routes[0].IsDefault() || s.Routes[0].Is6() || !s.Routes[1].IsDefault() || !s.Routes[1].Is6() {
+		t.Errorf("Expected default routes first, got %+v", s.Routes[:2])
+	}
+
+	checks := []struct {
+		name string
+		fn   func() ([]net.IP, error)
+		want string
+	}{
+		{"Gateways", s.Gateways, "192.168.8.1"},
+		{"GatewaysIPv6", s.GatewaysIPv6, "fe80::242:acff:fe11:3"},
+		{"Interface", func() ([]net.IP, error) { ip, err := s.Interface(); return []net.IP{ip}, err }, "192.168.8.238"},
+		{"InterfaceIPv6", func() ([]net.IP, error) { ip, err := s.InterfaceIPv6(); return []net.IP{ip}, err }, "2001:db8::2"},
+	}
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			ips, err := check.fn()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ips[0].String() != check.want {
+				t.Errorf("Unexpected address %v != %s", ips[0], check.want)
+			}
+		})
+	}
+
+	empty := newSnapshot(nil, mockGetter)
+	if _, err := empty.Gateways(); !errors.Is(err, &ErrNoGateway{}) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+	if _, err := empty.InterfaceIPv6(); !errors.Is(err, &ErrNoGateway{}) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+}
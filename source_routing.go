@@ -0,0 +1,223 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// strongHost tells whether the OS routes packets from a local address only
+// through the interface that has the address: Windows sends by the strong
+// host model, and Darwin scopes the routes of bound sockets to their
+// interface. Linux follows the weak host model, where policy rules select
+// routes by source.
+var strongHost = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// mainRules is the only rule where policy rules aren't known.
+var mainRules = []PolicyRule{{Priority: 32766, Action: "lookup", Table: "main", SuppressPrefixLength: -1}}
+
+// RouteFrom returns the default route the OS would choose for packets to
+// the Internet from the local address src, such as those of a socket
+// bound to src. Hosts with several addresses may send them through
+// another gateway than DiscoverGateway reports: policy rules select
+// routes by source on Linux, and Windows and Darwin send them out of the
+// interface that has src.
+func RouteFrom(src netip.Addr) (Route, error) {
+	return routeToFrom(netip.Addr{}, src)
+}
+
+// RouteToFrom returns the route the OS would choose for packets from the
+// local address src to dst; see RouteFrom. Its Gateway is the zero Addr
+// if dst is on-link.
+//
+// Rules that select packets by firewall mark, bound interface or other
+// properties than addresses are taken not to match. ErrNoGateway is
+// returned if no route matches, or if the packets would be dropped.
+func RouteToFrom(dst, src netip.Addr) (Route, error) {
+	if !dst.IsValid() {
+		return Route{}, fmt.Errorf("invalid destination address: %v", dst)
+	}
+	return routeToFrom(dst, src)
+}
+
+func routeToFrom(dst, src netip.Addr) (Route, error) {
+	if !src.IsValid() {
+		return Route{}, fmt.Errorf("invalid source address: %v", src)
+	}
+	s, err := discoverSnapshotOSSpecific()
+	if err != nil {
+		return Route{}, err
+	}
+	routes := s.Routes
+	if policy, err := discoverPolicyRoutesOSSpecific(); err == nil {
+		routes = append(slices.Clone(routes), policy...)
+	}
+	rules, _ := discoverPolicyRulesOSSpecific(src.Unmap().Is6())
+	resolver := routeResolver{
+		routes:     routes,
+		rules:      rules,
+		strongHost: strongHost,
+		interfaceOf: func(addr netip.Addr) string {
+			if iface, err := interfaceWithIP(addrToIP(addr)); err == nil {
+				return iface.Name
+			}
+			return ""
+		},
+	}
+	return resolver.resolve(dst, src)
+}
+
+// routeResolver chooses routes for packets like the OS does.
+type routeResolver struct {
+	routes []Route
+
+	// rules are the policy rules of the family of the packets, in
+	// order of priority, or nil for the main table only.
+	rules []PolicyRule
+
+	strongHost bool
+
+	// interfaceOf returns the name of the interface that has addr, or
+	// empty.
+	interfaceOf func(addr netip.Addr) string
+}
+
+// errNextRule continues the evaluation of the rules after a lookup that
+// found no route, a throw route or a suppressed one.
+var errNextRule = errors.New("next rule")
+
+// resolve returns the route for packets from src to dst, or to the
+// Internet if dst is the zero Addr.
+func (r routeResolver) resolve(dst, src netip.Addr) (Route, error) {
+	src = src.Unmap().WithZone("")
+	dst = dst.Unmap().WithZone("")
+	if dst.IsValid() && dst.Is6() != src.Is6() {
+		return Route{}, &ErrNoGateway{}
+	}
+
+	var candidates []Route
+	owner := ""
+	if r.strongHost {
+		owner = r.interfaceOf(src)
+	}
+	for _, route := range r.routes {
+		if route.Is6() != src.Is6() {
+			continue
+		}
+		scoped := slices.Contains(route.Flags, ifscopeFlag)
+		switch {
+		case owner != "" && route.Interface != owner && route.Source != src:
+			continue
+		case owner == "" && scoped:
+			continue
+		}
+		candidates = append(candidates, route)
+	}
+
+	rules := r.rules
+	if len(rules) == 0 {
+		rules = mainRules
+	}
+	for i := 0; i < len(rules); i++ {
+		rule := rules[i]
+		if !rule.matches(dst, src) {
+			continue
+		}
+		switch rule.Action {
+		case "lookup":
+			route, err := lookupTable(candidates, rule.Table, dst, rule.SuppressPrefixLength)
+			if err != errNextRule {
+				return route, err
+			}
+		case "goto":
+			// Continue at the first rule of at least the target
+			// priority.
+			next := slices.IndexFunc(rules, func(r PolicyRule) bool { return r.Priority >= rule.Goto })
+			if next <= i {
+				return Route{}, &ErrNoGateway{}
+			}
+			i = next - 1
+		case "blackhole", "unreachable", "prohibit":
+			return Route{}, &ErrNoGateway{}
+		}
+	}
+	return Route{}, &ErrNoGateway{}
+}
+
+// matches reports whether rule selects locally generated, unmarked
+// packets from src to dst, of a socket not bound to an interface.
+func (rule PolicyRule) matches(dst, src netip.Addr) bool {
+	// Selectors that depend on the socket can't be evaluated; such
+	// rules don't match, whether inverted or not.
+	if rule.OIF != "" || rule.L3mdev || len(rule.Selectors) > 0 {
+		return false
+	}
+	match := (!rule.From.IsValid() || rule.From.Bits() == 0 || rule.From.Contains(src)) &&
+		(!rule.To.IsValid() || rule.To.Bits() == 0 || dst.IsValid() && rule.To.Contains(dst)) &&
+		(rule.IIF == "" || rule.IIF == "lo") &&
+		(rule.FWMark == "" || fwmarkMatchesZero(rule.FWMark))
+	return match != rule.Not
+}
+
+// fwmarkMatchesZero reports whether the mark selector ("0x1" or
+// "0x1/0xff") matches unmarked packets.
+func fwmarkMatchesZero(selector string) bool {
+	value, mask, found := strings.Cut(selector, "/")
+	mark, err := strconv.ParseUint(value, 0, 32)
+	if err != nil {
+		return false
+	}
+	if found {
+		m, err := strconv.ParseUint(mask, 0, 32)
+		if err != nil {
+			return false
+		}
+		mark &= m
+	}
+	return mark == 0
+}
+
+// lookupTable returns the most specific route of the table to dst, or the
+// preferred default route if dst is the zero Addr. It returns errNextRule
+// if the rules are to continue.
+func lookupTable(routes []Route, table string, dst netip.Addr, suppressPrefixLength int) (Route, error) {
+	var best *Route
+	for i := range routes {
+		route := &routes[i]
+		if route.Table != table && !(table == "main" && route.Table == "") {
+			continue
+		}
+		if dst.IsValid() && !route.Destination.Contains(dst) || !dst.IsValid() && !route.IsDefault() {
+			continue
+		}
+		switch {
+		case best == nil,
+			route.Destination.Bits() > best.Destination.Bits(),
+			route.Destination.Bits() == best.Destination.Bits() && preferRoute(*route, *best):
+			best = route
+		}
+	}
+	if best == nil || best.Destination.Bits() <= suppressPrefixLength {
+		return Route{}, errNextRule
+	}
+	switch best.Type {
+	case "throw":
+		return Route{}, errNextRule
+	case "blackhole", "unreachable", "prohibit":
+		return Route{}, &ErrNoGateway{}
+	}
+	return *best, nil
+}
+
+// preferRoute reports whether a is preferred over b for the same
+// destination: routes on working links first, then by metric.
+func preferRoute(a, b Route) bool {
+	if a.LinkState.dead() != b.LinkState.dead() {
+		return b.LinkState.dead()
+	}
+	return a.EffectiveMetric() < b.EffectiveMetric()
+}
@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func newTestResolver(t *testing.T, rules, routes string) routeResolver {
+	r := routeResolver{interfaceOf: func(netip.Addr) string { return "" }}
+	if rules != "" {
+		var err error
+		if r.rules, err = ParseIPRule(routeTables[rules]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	var err error
+	if r.routes, err = parseIPRoute(routeTables[routes], false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return r
+}
+
+type routeToFromTestcase struct {
+	name      string
+	dst, src  string
+	gateway   string
+	iface     string
+	routeType string
+}
+
+func checkRouteToFrom(t *testing.T, r routeResolver, testcases []routeToFromTestcase) {
+	t.Helper()
+	for _, tc := range testcases {
+		var dst netip.Addr
+		if tc.dst != "" {
+			dst = netip.MustParseAddr(tc.dst)
+		}
+		route, err := r.resolve(dst, netip.MustParseAddr(tc.src))
+		if tc.iface == "" {
+			if !errors.Is(err, &ErrNoGateway{}) {
+				t.Errorf("%s: Expected ErrNoGateway, got %+v, %v", tc.name, route, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", tc.name, err)
+			continue
+		}
+		gateway := ""
+		if route.Gateway.IsValid() {
+			gateway = route.Gateway.WithZone("").String()
+		}
+		if gateway != tc.gateway || route.Interface != tc.iface {
+			t.Errorf("%s: Expected %q on %s, got %q on %s", tc.name, tc.gateway, tc.iface, gateway, route.Interface)
+		}
+		if tc.routeType != "" && route.Type != tc.routeType {
+			t.Errorf("%s: Expected type %s, got %s", tc.name, tc.routeType, route.Type)
+		}
+	}
+}
+
+func TestRouteToFromPolicyRules(t *testing.T) {
+	r := newTestResolver(t, linuxIPRuleMultiTenant, linuxIPRouteMultiTenant)
+	checkRouteToFrom(t, r, []routeToFromTestcase{
+		{name: "main", src: "192.168.1.20", gateway: "192.168.1.1", iface: "eth0"},
+		{name: "from host", src: "10.0.1.5", gateway: "10.0.1.1", iface: "eth1"},
+		{name: "from subnet", src: "10.0.2.5", gateway: "10.0.2.1", iface: "eth2"},
+		{name: "throw", dst: "10.9.9.9", src: "10.0.2.5", gateway: "192.168.1.254", iface: "eth0"},
+		{name: "to", dst: "198.51.100.7", src: "192.168.1.20", gateway: "10.0.2.254", iface: "eth2"},
+		{name: "to without route", dst: "198.51.100.200", src: "192.168.1.20", gateway: "192.168.1.1", iface: "eth0"},
+		{name: "on-link", dst: "192.168.1.50", src: "192.168.1.20", iface: "eth0"},
+		{name: "local", dst: "192.168.1.20", src: "10.0.1.5", iface: "eth0", routeType: "local"},
+		{name: "unreachable", src: "10.0.3.5"},
+		{name: "goto", src: "10.0.4.5", gateway: "192.168.1.1", iface: "eth0"},
+		{name: "family mismatch", dst: "2001:db8::1", src: "10.0.1.5"},
+	})
+}
+
+func TestRouteToFromWireGuard(t *testing.T) {
+	// wg-quick sends all unmarked traffic through the tunnel, except to
+	// the networks of the main table.
+	r := newTestResolver(t, linuxIPRuleWireGuard, linuxIPRouteWireGuard)
+	checkRouteToFrom(t, r, []routeToFromTestcase{
+		{name: "tunnel", src: "192.168.1.20", iface: "wg0"},
+		{name: "local network", dst: "192.168.1.50", src: "192.168.1.20", iface: "eth0"},
+	})
+
+	// Without the rules only the main table counts.
+	r.rules = nil
+	checkRouteToFrom(t, r, []routeToFromTestcase{
+		{name: "main only", src: "192.168.1.20", gateway: "192.168.1.1", iface: "eth0"},
+	})
+}
+
+func TestRouteToFromStrongHost(t *testing.T) {
+	routes, err := parseNetstatRoutes(routeTables[darwinScoped])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	owners := map[netip.Addr]string{
+		netip.MustParseAddr("192.168.1.20"): "en0",
+		netip.MustParseAddr("10.0.0.5"):     "en7",
+	}
+	r := routeResolver{
+		routes:      routes,
+		strongHost:  true,
+		interfaceOf: func(addr netip.Addr) string { return owners[addr] },
+	}
+	checkRouteToFrom(t, r, []routeToFromTestcase{
+		{name: "primary", src: "192.168.1.20", gateway: "192.168.1.1", iface: "en0"},
+		{name: "scoped", src: "10.0.0.5", gateway: "10.0.0.1", iface: "en7"},
+		{name: "scoped on-link", dst: "10.0.0.9", src: "10.0.0.5", iface: "en7"},
+		{name: "other interface's network", dst: "10.0.0.9", src: "192.168.1.20", gateway: "192.168.1.1", iface: "en0"},
+		{name: "unknown source", src: "172.16.0.1", gateway: "192.168.1.1", iface: "en0"},
+	})
+
+	// The weak host model of other systems ignores the scoped routes.
+	r.strongHost = false
+	checkRouteToFrom(t, r, []routeToFromTestcase{
+		{name: "weak host", src: "10.0.0.5", gateway: "192.168.1.1", iface: "en0"},
+		{name: "weak host on-link", dst: "10.0.0.9", src: "192.168.1.20", iface: "en7"},
+	})
+}
+
+func TestFWMarkMatchesZero(t *testing.T) {
+	testcases := map[string]bool{
+		"0x0":         true,
+		"0xca6c":      false,
+		"0x100/0xff":  true,
+		"0x101/0xff":  false,
+		"0x1/garbage": false,
+	}
+	for selector, want := range testcases {
+		if got := fwmarkMatchesZero(selector); got != want {
+			t.Errorf("%s: Expected %v, got %v", selector, want, got)
+		}
+	}
+}
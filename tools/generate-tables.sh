@@ -4,7 +4,7 @@ set -euo pipefail
 
 SCRIPT_DIR=$( cd -- "$( dirname -- "${BASH_SOURCE[0]}" )" &> /dev/null && pwd )
 
-FILE=${SCRIPT_DIR}/../test_route_tables.go
+FILE=${SCRIPT_DIR}/../route_tables_test.go
 TABLES=${SCRIPT_DIR}/../route-tables/*.txt
 
 consts=()
@@ -33,7 +33,7 @@ for rt in $(ls $TABLES)
 do
     name=$(echo $rt | awk 'BEGIN { FS = "/"} ; { print $NF }' | cut -d '.' -f 1)
     echo -e "\t${name}: []byte(\`" >> $FILE
-    cat $rt | sed 's/%/%%/g' >> $FILE
+    cat $rt >> $FILE
     echo -e "\`),\n" >> $FILE
 done
 echo "}" >> $FILE
@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+)
+
+// VRF is a Linux VRF (l3mdev) device, which binds the interfaces enslaved
+// to it to its own routing table.
+type VRF struct {
+	// Name is the name of the VRF device, such as "mgmt".
+	Name string
+
+	// Index is the interface index of the VRF device.
+	Index int
+
+	// Table is the routing table of the VRF.
+	Table int
+}
+
+// DiscoverVRFs lists the VRF devices of the host. Only Linux has them.
+func DiscoverVRFs() ([]VRF, error) {
+	vrfs, _, err := discoverVRFRoutesOSSpecific()
+	return vrfs, err
+}
+
+// DiscoverGatewaysInVRF is the function to get the IPv4 gateways of the
+// named Linux VRF, the default routes of its routing table by metric. These
+// are not in the main table, so DiscoverGateway doesn't see them.
+//
+// If err is nil, then ips is guaranteed to have at least one element.
+func DiscoverGatewaysInVRF(name string) (ips []net.IP, err error) {
+	return discoverGatewaysInVRF(name, false)
+}
+
+// DiscoverGatewaysIPv6InVRF is DiscoverGatewaysInVRF for IPv6.
+func DiscoverGatewaysIPv6InVRF(name string) (ips []net.IP, err error) {
+	return discoverGatewaysInVRF(name, true)
+}
+
+func discoverGatewaysInVRF(name string, ipv6 bool) ([]net.IP, error) {
+	vrfs, routes, err := discoverVRFRoutesOSSpecific()
+	if err != nil {
+		return nil, err
+	}
+	return gatewaysInVRF(vrfs, routes, name, ipv6)
+}
+
+// gatewaysInVRF returns the gateways of the default unicast routes of the
+// given family of the named VRF, by metric.
+func gatewaysInVRF(vrfs []VRF, routes []Route, name string, ipv6 bool) ([]net.IP, error) {
+	if !slices.ContainsFunc(vrfs, func(v VRF) bool { return v.Name == name }) {
+		return nil, fmt.Errorf("no VRF named %q", name)
+	}
+
+	var defaults []Route
+	for _, r := range routes {
+		if r.VRF == name && r.IsDefault() && r.Is6() == ipv6 && (r.Type == "" || r.Type == "unicast") {
+			defaults = append(defaults, r)
+		}
+	}
+	slices.SortStableFunc(defaults, func(a, b Route) int {
+		return a.EffectiveMetric() - b.EffectiveMetric()
+	})
+
+	seen := make(map[string]bool)
+	var result []net.IP
+	for _, r := range defaults {
+		for _, gateway := range r.gateways() {
+			if key := gateway.String(); !seen[key] {
+				seen[key] = true
+				result = append(result, addrToIP(gateway))
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, &ErrNoGateway{}
+	}
+	return result, nil
+}
+
+// tagVRFRoutes sets the VRF of the routes of the tables of vrfs.
+func tagVRFRoutes(routes []Route, vrfs []VRF) {
+	tables := make(map[string]string)
+	for _, v := range vrfs {
+		tables[strconv.Itoa(v.Table)] = v.Name
+	}
+	for i := range routes {
+		if name, ok := tables[routes[i].Table]; ok {
+			routes[i].VRF = name
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// netlinkFixture decodes a recorded rtnetlink dump of a little-endian
+// host.
+func netlinkFixture(t *testing.T, name string) []netlinkMessage {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(string(routeTables[name])), ""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	msgs, err := parseNetlinkMessages(data, binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return msgs
+}
+
+func vrfFixture(t *testing.T) ([]VRF, []Route) {
+	links, err := parseLinuxLinks(netlinkFixture(t, linuxNetlinkVRFLinks), binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes, err := parseLinuxRouteMessages(netlinkFixture(t, linuxNetlinkVRFRoutes), binary.LittleEndian, links)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return linuxVRFs(links), routes
+}
+
+func TestParseLinuxLinks(t *testing.T) {
+	links, err := parseLinuxLinks(netlinkFixture(t, linuxNetlinkVRFLinks), binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(links) != 7 {
+		t.Fatalf("Expected 7 links, got %+v", links)
+	}
+	// IFF_UP, IFF_BROADCAST, IFF_RUNNING, IFF_MULTICAST and IFF_LOWER_UP.
+	if want := (linuxLink{Index: 3, Name: "eth1", Master: 4, Flags: 0x11043}); links[2] != want {
+		t.Errorf("Unexpected link %+v", links[2])
+	}
+	if want := (linuxLink{Index: 7, Name: "veth0", Kind: "veth", Flags: 0x11043}); links[6] != want {
+		t.Errorf("Unexpected link %+v", links[6])
+	}
+	if state := linuxFlagsLinkState(linuxLinkFlags(links)["eth0"]); state != LinkStateUp {
+		t.Errorf("Unexpected state of eth0 %v", state)
+	}
+
+	want := []VRF{{Name: "mgmt", Index: 4, Table: 10}, {Name: "data", Index: 6, Table: 1020}}
+	if got := linuxVRFs(links); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected VRFs %+v", got)
+	}
+}
+
+func TestParseLinuxRouteMessages(t *testing.T) {
+	_, routes := vrfFixture(t)
+	// The cached route of the dump is left out.
+	if len(routes) != 11 {
+		t.Fatalf("Expected 11 routes, got %d", len(routes))
+	}
+
+	checks := []struct {
+		route Route
+		want  Route
+	}{
+		{routes[0], Route{
+			Destination:    netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:        netip.MustParseAddr("192.168.1.1"),
+			Interface:      "eth0",
+			InterfaceIndex: 2,
+			Source:         netip.MustParseAddr("192.168.1.20"),
+			Metric:         100,
+			Protocol:       "dhcp",
+			Table:          "main",
+			Type:           "unicast",
+		}},
+		{routes[2], Route{
+			Destination:    netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:        netip.MustParseAddr("10.10.0.1"),
+			Interface:      "eth1",
+			InterfaceIndex: 3,
+			Protocol:       "static",
+			Table:          "10",
+			VRF:            "mgmt",
+			Type:           "unicast",
+		}},
+		{routes[5], Route{
+			Destination: netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:     netip.MustParseAddr("172.16.0.1"),
+			Interface:   "eth2",
+			Protocol:    "static",
+			Table:       "1020",
+			VRF:         "data",
+			Type:        "unicast",
+			Nexthops: []Nexthop{
+				{Gateway: netip.MustParseAddr("172.16.0.1"), Interface: "eth2", Weight: 1},
+				{Gateway: netip.MustParseAddr("172.16.1.1"), Interface: "eth2", Weight: 1},
+			},
+		}},
+		{routes[8], Route{
+			Destination:    netip.MustParsePrefix("::/0"),
+			Gateway:        netip.MustParseAddr("fe80::1%eth1"),
+			Interface:      "eth1",
+			InterfaceIndex: 3,
+			Metric:         1024,
+			Protocol:       "static",
+			Table:          "10",
+			VRF:            "mgmt",
+			Type:           "unicast",
+		}},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.route, check.want) {
+			t.Errorf("Unexpected route %+v != %+v", check.route, check.want)
+		}
+	}
+	if r := routes[3]; r.Type != "unreachable" || r.VRF != "mgmt" || !r.IsDefault() {
+		t.Errorf("Unexpected unreachable route %+v", r)
+	}
+	if r := routes[7]; r.Type != "local" || r.Table != "local" || r.VRF != "" {
+		t.Errorf("Unexpected local route %+v", r)
+	}
+
+	// The defaults of VRF tables aren't those of the host.
+	var gateways []string
+	for _, r := range routes {
+		if r.forwardsByDefault() {
+			gateways = append(gateways, r.Gateway.String())
+		}
+	}
+	if want := []string{"192.168.1.1", "fe80::fe%eth0"}; !slices.Equal(gateways, want) {
+		t.Errorf("Unexpected default gateways %v", gateways)
+	}
+
+	if _, err := parseNetlinkMessages([]byte{0xff, 0, 0, 0, 24, 0}, binary.LittleEndian); !errors.Is(err, &ErrCantParse{}) {
+		t.Errorf("Expected ErrCantParse, got %v", err)
+	}
+}
+
+func TestGatewaysInVRF(t *testing.T) {
+	vrfs, routes := vrfFixture(t)
+
+	testcases := []struct {
+		name string
+		ipv6 bool
+		want []string
+	}{
+		{"mgmt", false, []string{"10.10.0.1"}},
+		{"mgmt", true, []string{"fe80::1"}},
+		{"data", false, []string{"172.16.0.1", "172.16.1.1"}},
+	}
+	for _, tc := range testcases {
+		got, err := gatewaysInVRF(vrfs, routes, tc.name, tc.ipv6)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !slices.Equal(ipStrings(got), tc.want) {
+			t.Errorf("Unexpected gateways of %s %v", tc.name, got)
+		}
+	}
+
+	if _, err := gatewaysInVRF(vrfs, routes, "data", true); !errors.Is(err, &ErrNoGateway{}) {
+		t.Errorf("Expected ErrNoGateway, got %v", err)
+	}
+	if _, err := gatewaysInVRF(vrfs, routes, "eth0", false); err == nil || errors.Is(err, &ErrNoGateway{}) {
+		t.Errorf("Expected an unknown VRF, got %v", err)
+	}
+}
+
+func TestSnapshotLeavesOutVRFRoutes(t *testing.T) {
+	_, routes := vrfFixture(t)
+	// The snapshot is built from the main table of the dump, so the
+	// defaults of mgmt and data aren't the host's.
+	s := Snapshot{Routes: mainRoutes(routes)}
+	if ips, err := s.Gateways(); err != nil || !slices.Equal(ipStrings(ips), []string{"192.168.1.1"}) {
+		t.Errorf("Unexpected gateways %v, %v", ips, err)
+	}
+	if ips, err := s.GatewaysIPv6(); err != nil || !slices.Equal(ipStrings(ips), []string{"fe80::fe"}) {
+		t.Errorf("Unexpected IPv6 gateways %v, %v", ips, err)
+	}
+	for _, r := range s.Routes {
+		if r.VRF != "" {
+			t.Errorf("Unexpected VRF route %+v", r)
+		}
+	}
+}
+
+func TestTagVRFRoutes(t *testing.T) {
+	routes, err := parseIPRoute([]byte("default via 10.10.0.1 dev eth1 table 10\ndefault via 192.168.1.1 dev eth0\n"), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tagVRFRoutes(routes, []VRF{{Name: "mgmt", Index: 4, Table: 10}})
+	if routes[0].VRF != "mgmt" || routes[1].VRF != "" {
+		t.Errorf("Unexpected VRFs %+v", routes)
+	}
+}
@@ -0,0 +1,182 @@
+package gateway
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// WindowsIPConfig is the DNS configuration listed by Windows' ipconfig /all.
+type WindowsIPConfig struct {
+	// SearchList holds the host's DNS Suffix Search List.
+	SearchList []string
+
+	// Adapters holds the network adapters in the order listed.
+	Adapters []WindowsAdapter
+}
+
+// WindowsAdapter is a network adapter of ipconfig /all.
+type WindowsAdapter struct {
+	// Name is the adapter's alias, such as "Ethernet" or "Wi-Fi", as
+	// used by the routes of Get-NetRoute and netsh.
+	Name string
+
+	// Description is the adapter's description, such as "Intel(R)
+	// Ethernet Connection I219-LM".
+	Description string
+
+	// DNSSuffix is the connection-specific DNS suffix.
+	DNSSuffix string
+
+	// DNSServers holds the adapter's DNS servers.
+	DNSServers []netip.Addr
+}
+
+// windowsIPConfigLabel identifies the values of ipconfig /all that are
+// read.
+type windowsIPConfigLabel int
+
+const (
+	windowsIPConfigOther windowsIPConfigLabel = iota
+	windowsIPConfigDescription
+	windowsIPConfigDNSSuffix
+	windowsIPConfigDNSServers
+	windowsIPConfigSearchList
+)
+
+// windowsIPConfigLabels are the localized labels of ipconfig /all.
+var windowsIPConfigLabels = map[string]windowsIPConfigLabel{
+	// English
+	"Description":                    windowsIPConfigDescription,
+	"Connection-specific DNS Suffix": windowsIPConfigDNSSuffix,
+	"DNS Servers":                    windowsIPConfigDNSServers,
+	"DNS Suffix Search List":         windowsIPConfigSearchList,
+	// German
+	"Beschreibung":                       windowsIPConfigDescription,
+	"Verbindungsspezifisches DNS-Suffix": windowsIPConfigDNSSuffix,
+	"DNS-Server":                         windowsIPConfigDNSServers,
+	"DNS-Suffixsuchliste":                windowsIPConfigSearchList,
+	// French
+	"Suffixe DNS propre à la connexion": windowsIPConfigDNSSuffix,
+	"Serveurs DNS":                      windowsIPConfigDNSServers,
+	"Liste de recherche du suffixe DNS": windowsIPConfigSearchList,
+}
+
+// windowsAdapterMarkers end the localized kind of adapter that precedes
+// the adapter's name in the headers of ipconfig /all, in lower case:
+// "Ethernet adapter Ethernet:", "Drahtlos-LAN-Adapter WLAN:" and
+// "Carte réseau sans fil Wi-Fi :".
+var windowsAdapterMarkers = []string{
+	// English and German
+	"adapter ",
+	// French
+	"carte ethernet ",
+	"carte réseau sans fil ",
+	"carte tunnel ",
+	"carte ppp ",
+	"carte inconnue ",
+}
+
+// ParseWindowsIPConfig parses the output of ipconfig /all, for example
+// collected from another machine. English, German and French labels are
+// understood.
+func ParseWindowsIPConfig(output []byte) (WindowsIPConfig, error) {
+	// Windows IP Configuration
+	//
+	//    Host Name . . . . . . . . . . . . : DESKTOP
+	//    DNS Suffix Search List. . . . . . : corp.example.com
+	//                                        example.com
+	//
+	// Ethernet adapter Ethernet:
+	//
+	//    Connection-specific DNS Suffix  . : lan
+	//    Description . . . . . . . . . . . : Intel(R) Ethernet Connection I219-LM
+	//    DNS Servers . . . . . . . . . . . : 192.168.1.1
+	//                                        fd00::1
+	//
+	// Values continue on the following lines, indented further, without
+	// a label.
+	var (
+		config  WindowsIPConfig
+		adapter *WindowsAdapter
+		label   windowsIPConfigLabel
+		found   bool
+	)
+	for _, line := range strings.Split(strings.ReplaceAll(string(output), "\r", ""), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") {
+			// A section: the host's, or that of an adapter.
+			label = windowsIPConfigOther
+			header := strings.TrimSpace(line)
+			if !strings.HasSuffix(header, ":") {
+				continue
+			}
+			header = strings.TrimSpace(strings.TrimSuffix(header, ":"))
+			lower := strings.ToLower(header)
+			for _, marker := range windowsAdapterMarkers {
+				if i := strings.Index(lower, marker); i >= 0 && len(lower) == len(header) {
+					header = header[i+len(marker):]
+					break
+				}
+			}
+			config.Adapters = append(config.Adapters, WindowsAdapter{Name: header})
+			adapter = &config.Adapters[len(config.Adapters)-1]
+			continue
+		}
+
+		// Long labels leave no room for the dots ("DNS-Suffix: lan"), and
+		// empty values leave the colon at the end of the line.
+		value := strings.TrimSpace(line)
+		name, v, ok := strings.Cut(value, ": ")
+		if !ok && strings.HasSuffix(value, ":") {
+			name, v, ok = strings.TrimSuffix(value, ":"), "", true
+		}
+		if ok {
+			label = windowsIPConfigLabels[strings.Trim(name, " .")]
+			value = strings.TrimSpace(v)
+			found = true
+		}
+		if value == "" {
+			continue
+		}
+
+		if adapter == nil {
+			if label == windowsIPConfigSearchList {
+				config.SearchList = append(config.SearchList, value)
+			}
+			continue
+		}
+		switch label {
+		case windowsIPConfigDescription:
+			adapter.Description = value
+		case windowsIPConfigDNSSuffix:
+			adapter.DNSSuffix = value
+		case windowsIPConfigDNSServers:
+			if addr, err := netip.ParseAddr(value); err == nil {
+				adapter.DNSServers = append(adapter.DNSServers, addr)
+			}
+		}
+	}
+	if !found {
+		return WindowsIPConfig{}, &ErrCantParse{}
+	}
+	return config, nil
+}
+
+// dnsConfigs returns the DNS configuration of c, by adapter name.
+func (c WindowsIPConfig) dnsConfigs() dnsConfigs {
+	result := dnsConfigs{
+		global:     DNSConfig{Search: c.SearchList},
+		interfaces: make(map[string]DNSConfig),
+	}
+	for _, a := range c.Adapters {
+		d := DNSConfig{Servers: a.DNSServers}
+		if a.DNSSuffix != "" {
+			d.Search = []string{a.DNSSuffix}
+		}
+		result.interfaces[a.Name] = d
+	}
+	return result
+}
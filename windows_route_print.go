@@ -0,0 +1,265 @@
+package gateway
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// windowsRoutePrintHeaders holds the localized headers that introduce the
+// active and persistent routes of each table printed by route print.
+//
+// The sections are found structurally even for languages missing from
+// this table: the first header after a table title introduces the active
+// routes and the next one the persistent routes. The table makes the
+// classification independent of that order and documents what the
+// parsers have been tested with. Please add your language if it is
+// missing, together with a fixture in route-tables/.
+var windowsRoutePrintHeaders = []struct {
+	language   string
+	active     string
+	persistent string
+}{
+	{"English", "Active Routes:", "Persistent Routes:"},
+	{"Spanish", "Rutas activas:", "Rutas persistentes:"},
+	{"French", "Itinéraires actifs :", "Itinéraires persistants :"},
+	{"German", "Aktive Routen:", "Ständige Routen:"},
+	{"Italian", "Route attive:", "Route persistenti:"},
+	{"Portuguese", "Rotas ativas:", "Rotas persistentes:"},
+	{"Russian", "Активные маршруты:", "Постоянные маршруты:"},
+	{"Polish", "Aktywne trasy:", "Trasy trwałe:"},
+	{"Japanese", "アクティブ ルート:", "固定ルート:"},
+	{"Chinese (Simplified)", "活动路由:", "永久路由:"},
+	{"Chinese (Traditional)", "使用中的路由:", "持續路由:"},
+	{"Korean", "활성 경로:", "영구 경로:"},
+}
+
+type windowsSection int
+
+const (
+	windowsNoSection windowsSection = iota
+	windowsInterfaceSection
+	windowsActiveRoutes
+	windowsPersistentRoutes
+)
+
+// windowsRoutePrintLine is a line of route print output together with
+// its place in the output.
+type windowsRoutePrintLine struct {
+	// 4 or 6 inside the IPv4 or IPv6 route table, 0 before them
+	family  int
+	section windowsSection
+
+	// The line without surrounding white space
+	text string
+}
+
+// splitWindowsRoutePrint classifies the lines of route print output by
+// table and section, without depending on the language of the output:
+//
+//	===========================================================================
+//	Interface List
+//	  8 ...00 12 3f a7 17 ba ...... Intel(R) PRO/100 VE Network Connection
+//	===========================================================================
+//	IPv4 Route Table
+//	===========================================================================
+//	Active Routes:
+//	Network Destination        Netmask          Gateway       Interface  Metric
+//	          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.100     20
+//	===========================================================================
+//	Persistent Routes:
+//	  None
+//
+//	IPv6 Route Table
+//	...
+//
+// Separators, table titles and section headers are not returned. The
+// number of separators is returned so that callers can tell garbage
+// apart from an empty table.
+func splitWindowsRoutePrint(output []byte) (lines []windowsRoutePrintLine, separators int) {
+	var (
+		family  int
+		section windowsSection
+		headers int // section headers seen since the table title
+	)
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "=======") {
+			separators++
+			switch {
+			case family == 0:
+				// The Interface List sits between the first two separators.
+				if separators == 1 {
+					section = windowsInterfaceSection
+				} else {
+					section = windowsNoSection
+				}
+			case section != windowsNoSection:
+				section = windowsNoSection
+			}
+			continue
+		}
+
+		text := strings.TrimSpace(line)
+		if title := windowsTableTitle(text); title != 0 {
+			family, section, headers = title, windowsNoSection, 0
+			continue
+		}
+		if family != 0 {
+			if s, ok := windowsSectionHeader(text); ok {
+				headers++
+				if s == windowsNoSection {
+					// Unknown language: go by position.
+					s = windowsPersistentRoutes
+					if headers == 1 {
+						s = windowsActiveRoutes
+					}
+				}
+				section = s
+				continue
+			}
+		}
+
+		lines = append(lines, windowsRoutePrintLine{
+			family:  family,
+			section: section,
+			text:    text,
+		})
+	}
+	return lines, separators
+}
+
+// windowsTableTitle returns 4 or 6 if line is the title of the IPv4 or
+// IPv6 route table, and 0 otherwise. The titles contain "IPv4" or "IPv6"
+// in every language.
+func windowsTableTitle(line string) int {
+	if line == "" || unicode.IsDigit(rune(line[0])) {
+		// Route rows and the Interface List start with a number.
+		return 0
+	}
+	switch {
+	case strings.Contains(line, "IPv4"):
+		return 4
+	case strings.Contains(line, "IPv6"):
+		return 6
+	}
+	return 0
+}
+
+// windowsSectionHeader reports whether line introduces a section of a
+// route table, and which one if the header is a known one.
+func windowsSectionHeader(line string) (windowsSection, bool) {
+	for _, h := range windowsRoutePrintHeaders {
+		switch line {
+		case h.active:
+			return windowsActiveRoutes, true
+		case h.persistent:
+			return windowsPersistentRoutes, true
+		}
+	}
+
+	// Headers end with a colon, possibly a full width one. Rows, which
+	// may end in an address such as "fe80::", start with a number.
+	if line == "" || unicode.IsDigit(rune(line[0])) {
+		return windowsNoSection, false
+	}
+	if strings.HasSuffix(line, ":") || strings.HasSuffix(line, "：") {
+		return windowsNoSection, true
+	}
+	return windowsNoSection, false
+}
+
+// WindowsInterface is an entry of the Interface List printed by route print.
+type WindowsInterface struct {
+	// Index is the interface index, as used by the "If" column of the
+	// IPv6 route table.
+	Index int
+
+	// HardwareAddr is nil for interfaces without one, such as the
+	// loopback interface.
+	HardwareAddr net.HardwareAddr
+
+	// Description is the adapter description, such as "Intel(R) Ethernet
+	// Connection I219-LM". It is not the friendly name ("Ethernet") that
+	// net.Interface reports.
+	Description string
+}
+
+// ParseWindowsInterfaceList parses the Interface List of the output of
+// route print, for example collected from another machine.
+func ParseWindowsInterfaceList(output []byte) ([]WindowsInterface, error) {
+	lines, separators := splitWindowsRoutePrint(output)
+	if separators == 0 {
+		// We saw no separator lines, so input must have been garbage.
+		return nil, &ErrCantParse{}
+	}
+	return windowsInterfaceList(lines), nil
+}
+
+// ParseWindowsRoutePrint parses the active IPv4 and IPv6 routes of the
+// output of route print, for example collected from another machine.
+// IPv6 routes are annotated with the description and hardware address of
+// their interface from the Interface List.
+func ParseWindowsRoutePrint(output []byte) ([]Route, error) {
+	return parseWindowsRoutes(output)
+}
+
+// ParseWindowsPersistentRoutes parses the IPv4 and IPv6 persistent routes
+// of the output of route print. Routes that aren't in the active tables
+// are flagged Inactive.
+func ParseWindowsPersistentRoutes(output []byte) ([]Route, error) {
+	_, persistent, err := parseWindowsRouteTables(output)
+	return persistent, err
+}
+
+func windowsInterfaceList(lines []windowsRoutePrintLine) []WindowsInterface {
+	var result []WindowsInterface
+	for _, line := range lines {
+		if line.section != windowsInterfaceSection {
+			continue
+		}
+		if iface, ok := parseWindowsInterfaceLine(line.text); ok {
+			result = append(result, iface)
+		}
+	}
+	return result
+}
+
+// parseWindowsInterfaceLine parses an entry of the Interface List:
+//
+//	12...00 15 5d 01 02 03 ......Intel(R) Ethernet Connection I219-LM
+//	1...........................Software Loopback Interface 1
+//	14...00 00 00 00 00 00 00 e0 Teredo Tunneling Pseudo-Interface
+//	8 ...00 12 3f a7 17 ba ...... Intel(R) PRO/100 VE Network Connection
+//	0x2 ...00 0c 29 b5 9d 4f ...... AMD PCNET Family PCI Ethernet Adapter
+//
+// The index is decimal, or hexadecimal on Windows XP. Three dots separate
+// it from the hardware address; interfaces without one are padded with
+// dots up to the description.
+func parseWindowsInterfaceLine(line string) (WindowsInterface, bool) {
+	end := strings.IndexAny(line, " .")
+	if end <= 0 {
+		return WindowsInterface{}, false
+	}
+	index, err := strconv.ParseInt(line[:end], 0, 0)
+	if err != nil {
+		return WindowsInterface{}, false
+	}
+	iface := WindowsInterface{Index: int(index)}
+
+	rest := strings.TrimLeft(line[end:], " ")
+	dots := len(rest) - len(strings.TrimLeft(rest, "."))
+	rest = rest[dots:]
+	if dots == 3 {
+		for len(rest) >= 2 {
+			b, err := strconv.ParseUint(rest[:2], 16, 8)
+			if err != nil || (len(rest) > 2 && rest[2] != ' ' && rest[2] != '.') {
+				break
+			}
+			iface.HardwareAddr = append(iface.HardwareAddr, byte(b))
+			rest = strings.TrimPrefix(rest[2:], " ")
+		}
+	}
+	iface.Description = strings.TrimSpace(strings.TrimLeft(rest, ". "))
+	return iface, true
+}
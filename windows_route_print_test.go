@@ -0,0 +1,237 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSplitWindowsRoutePrint(t *testing.T) {
+	for _, name := range []string{windowsDualStack, windowsGerman, windowsFrench, windowsJapanese, windowsChinese, windowsPortuguese, windowsRussian} {
+		t.Run(name, func(t *testing.T) {
+			lines, separators := splitWindowsRoutePrint(routeTables[name])
+			if separators == 0 {
+				t.Fatal("Expected separators")
+			}
+			counts := make(map[windowsSection]int)
+			loopback := false
+			for _, line := range lines {
+				if line.text != "" {
+					counts[line.section]++
+				}
+				if line.section == windowsInterfaceSection && strings.HasSuffix(line.text, "Software Loopback Interface 1") {
+					loopback = true
+				}
+			}
+			if !loopback {
+				t.Error("Expected the loopback interface in the Interface List")
+			}
+			if counts[windowsActiveRoutes] == 0 || counts[windowsPersistentRoutes] == 0 {
+				t.Errorf("Expected active and persistent routes, got %v", counts)
+			}
+		})
+	}
+
+	// Headers missing from windowsRoutePrintHeaders are classified by
+	// their position after the table title.
+	unknown := strings.NewReplacer(
+		"Aktive Routen:", "Actieve routes:",
+		"Ständige Routen:", "Permanente routes:",
+	).Replace(string(routeTables[windowsGerman]))
+	routes, err := parseWindowsRoutes([]byte(unknown))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := defaultRoutes(routes)
+	if len(got) != 2 {
+		t.Fatalf("Unexpected default routes %+v", got)
+	}
+	if got[0].Gateway != netip.MustParseAddr("192.168.178.1") {
+		t.Errorf("Unexpected IPv4 gateway %v", got[0].Gateway)
+	}
+	if got[1].Gateway != netip.MustParseAddr("fe80::3a10:d5ff:fe12:3456%12") {
+		t.Errorf("Unexpected IPv6 gateway %v", got[1].Gateway)
+	}
+}
+
+func TestParseWindowsInterfaceList(t *testing.T) {
+	mac := func(s string) net.HardwareAddr {
+		hw, err := net.ParseMAC(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hw
+	}
+
+	type testcase struct {
+		tableName string
+		want      []WindowsInterface
+	}
+	testcases := []testcase{
+		{windows, []WindowsInterface{
+			{8, mac("00:12:3f:a7:17:ba"), "Intel(R) PRO/100 VE Network Connection"},
+			{1, nil, "Software Loopback Interface 1"},
+		}},
+		{windowsDualStack, []WindowsInterface{
+			{12, mac("00:15:5d:01:02:03"), "Intel(R) Ethernet Connection I219-LM"},
+			{1, nil, "Software Loopback Interface 1"},
+			{14, mac("00:00:00:00:00:00:00:e0"), "Teredo Tunneling Pseudo-Interface"},
+		}},
+		{windowsJapanese, []WindowsInterface{
+			{7, mac("00:15:5d:0a:0b:0c"), "Realtek PCIe GbE Family Controller"},
+			{1, nil, "Software Loopback Interface 1"},
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			got, err := ParseWindowsInterfaceList(routeTables[tc.tableName])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Unexpected interfaces %+v != %+v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run(windowsLocalized2, func(t *testing.T) {
+		got, err := ParseWindowsInterfaceList(routeTables[windowsLocalized2])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(got) < 3 || got[0].Index != 29 || got[0].HardwareAddr != nil || got[0].Description != "SGNAutobahn Tunnel" {
+			t.Fatalf("Unexpected interfaces %+v", got)
+		}
+		if got[2].Index != 18 || got[2].HardwareAddr.String() != "01:02:03:04:05:60" {
+			t.Errorf("Unexpected interface %+v", got[2])
+		}
+	})
+
+	t.Run("Windows XP", func(t *testing.T) {
+		iface, ok := parseWindowsInterfaceLine("0x2 ...00 0c 29 b5 9d 4f ...... AMD PCNET Family PCI Ethernet Adapter")
+		if !ok || iface.Index != 2 || iface.HardwareAddr.String() != "00:0c:29:b5:9d:4f" || iface.Description != "AMD PCNET Family PCI Ethernet Adapter" {
+			t.Errorf("Unexpected interface %+v", iface)
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := ParseWindowsInterfaceList(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestParseWindowsPersistentRoutes(t *testing.T) {
+	type testcase struct {
+		tableName string
+		want      []Route
+	}
+	testcases := []testcase{
+		{windowsSpanishPersistent, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.1"), Persistent: true},
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("10.20.0.1"), Metric: 10, Persistent: true, Inactive: true},
+			{Destination: netip.MustParsePrefix("10.50.0.0/16"), Gateway: netip.MustParseAddr("10.20.0.1"), Persistent: true, Inactive: true},
+			{Destination: netip.MustParsePrefix("::/0"), Gateway: netip.MustParseAddr("fe80::1"), Metric: 4294967295, Persistent: true},
+			{
+				Destination:          netip.MustParsePrefix("::/0"),
+				Gateway:              netip.MustParseAddr("fe80::20:1%22"),
+				InterfaceIndex:       22,
+				InterfaceDescription: "Realtek USB GbE Family Controller",
+				HardwareAddr:         net.HardwareAddr{0x00, 0x1c, 0x42, 0x9a, 0x7b, 0x02},
+				Metric:               256,
+				Persistent:           true,
+				Inactive:             true,
+			},
+		}},
+		{windowsGermanPersistent, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("172.16.0.1"), Persistent: true, Inactive: true},
+		}},
+		{windowsDualStack, []Route{
+			{Destination: netip.MustParsePrefix("0.0.0.0/0"), Gateway: netip.MustParseAddr("192.168.1.1"), Persistent: true},
+			{Destination: netip.MustParsePrefix("::/0"), Gateway: netip.MustParseAddr("fe80::2"), Metric: 4294967295, Persistent: true, Inactive: true},
+		}},
+		{windowsLocalized2, nil},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			got, err := ParseWindowsPersistentRoutes(routeTables[tc.tableName])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Unexpected persistent routes %+v", got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tc.want[i]) {
+					t.Errorf("Unexpected persistent route %+v != %+v", got[i], tc.want[i])
+				}
+			}
+		})
+	}
+
+	// Active routes that are also persistent are flagged as such.
+	routes, err := ParseWindowsRoutePrint(routeTables[windowsSpanishPersistent])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := defaultRoutes(routes); len(got) != 2 || !got[0].Persistent || !got[1].Persistent || got[0].Inactive {
+		t.Errorf("Unexpected default routes %+v", got)
+	}
+}
+
+func TestParseWindowsRouteTablesInterfaces(t *testing.T) {
+	interfaces := []net.Interface{
+		{Index: 12, Name: "Ethernet", Flags: net.FlagUp | net.FlagRunning},
+		{Index: 18, Name: "Local Area Connection", Flags: net.FlagUp | net.FlagRunning},
+		{Index: 1, Name: "Loopback Pseudo-Interface 1", Flags: net.FlagUp | net.FlagRunning | net.FlagLoopback},
+	}
+	addrs := map[string][]net.Addr{
+		"Ethernet":                    {&net.IPNet{IP: net.ParseIP("192.168.1.20"), Mask: net.CIDRMask(24, 32)}},
+		"Local Area Connection":       {&net.IPNet{IP: net.ParseIP("10.8.0.6"), Mask: net.CIDRMask(24, 32)}},
+		"Loopback Pseudo-Interface 1": {&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)}},
+	}
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.EXPECT().Interfaces().Return(interfaces, nil)
+	mockGetter.EXPECT().Addrs(mock.Anything).RunAndReturn(func(iface *net.Interface) ([]net.Addr, error) {
+		return addrs[iface.Name], nil
+	})
+	mockGetter.EXPECT().InterfaceByName(mock.Anything).RunAndReturn(func(name string) (*net.Interface, error) {
+		for i := range interfaces {
+			if interfaces[i].Name == name {
+				return &interfaces[i], nil
+			}
+		}
+		return nil, errors.New("no such network interface")
+	})
+
+	routes, _, err := parseWindowsRouteTablesImpl(routeTables[windowsRoutePrintVPN], mockGetter)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The IPv4 rows name their interface by address only.
+	got := defaultRoutes(routes)
+	if len(got) != 2 || got[1].Interface != "Local Area Connection" || got[1].InterfaceIndex != 18 ||
+		got[1].InterfaceDescription != "TAP-Windows Adapter V9" {
+		t.Fatalf("Unexpected default routes %+v", got)
+	}
+	if r := routes[len(routes)-1]; r.Interface != "Local Area Connection" {
+		t.Errorf("Unexpected interface of IPv6 route %+v", r)
+	}
+
+	// The VPN's default route is preferred, but isn't physical.
+	s := newSnapshot(routes, mockGetter)
+	if ips, err := s.Gateways(); err != nil || ips[0].String() != "10.8.0.1" {
+		t.Errorf("Unexpected gateways %v, %v", ips, err)
+	}
+	if ip, err := s.PhysicalGateway(false); err != nil || ip.String() != "192.168.1.1" {
+		t.Errorf("Unexpected physical gateway %v, %v", ip, err)
+	}
+	if filtered := s.Filter(Options{IncludeInterfaces: []string{"Ethernet"}}); len(filtered.Routes) != 4 {
+		t.Errorf("Unexpected routes of Ethernet %+v", filtered.Routes)
+	}
+}
@@ -0,0 +1,283 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// getNetRouteScript lists the active and the persistent routes with the
+// PowerShell NetTCPIP module.
+const getNetRouteScript = "@(Get-NetRoute -PolicyStore ActiveStore -ErrorAction SilentlyContinue) + " +
+	"@(Get-NetRoute -PolicyStore PersistentStore -ErrorAction SilentlyContinue) | " +
+	"Select-Object DestinationPrefix,NextHop,InterfaceAlias,InterfaceIndex,RouteMetric,InterfaceMetric,Protocol,Store | " +
+	"ConvertTo-Json"
+
+// getNetRouteEntry is a route of Get-NetRoute | ConvertTo-Json. Fields
+// that don't matter here, such as the CimClass of unfiltered output, are
+// ignored.
+type getNetRouteEntry struct {
+	DestinationPrefix string
+	NextHop           string
+	InterfaceAlias    string
+	InterfaceIndex    int
+	RouteMetric       int
+	InterfaceMetric   int
+	IfMetric          int `json:"ifMetric"`
+	Protocol          jsonEnum
+	Store             jsonEnum
+}
+
+// jsonEnum is a CIM enumeration, which ConvertTo-Json writes as a number
+// unless asked for -EnumsAsStrings.
+type jsonEnum string
+
+func (e *jsonEnum) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*e = jsonEnum(strconv.Itoa(n))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*e = jsonEnum(s)
+	return nil
+}
+
+// getNetRouteProtocols names the values of MSFT_NetRoute.Protocol.
+var getNetRouteProtocols = map[jsonEnum]string{
+	"1": "Other", "2": "Local", "3": "NetMgmt", "4": "Icmp", "5": "Egp",
+	"6": "Ggp", "7": "Hello", "8": "Rip", "9": "IsIs", "10": "EsIs",
+	"11": "Igrp", "12": "Bbn", "13": "Ospf", "14": "Bgp", "15": "Idpr",
+	"16": "Eigrp", "17": "Dvmrp", "18": "Rpl", "19": "Dhcp",
+}
+
+// ParseWindowsGetNetRoute parses the output of
+//
+//	Get-NetRoute | ConvertTo-Json
+//
+// for example collected from another machine. Routes of the persistent
+// store are returned separately, flagged like those of route print.
+func ParseWindowsGetNetRoute(output []byte) (active, persistent []Route, err error) {
+	return parseGetNetRouteJSON(output)
+}
+
+func parseGetNetRouteJSON(output []byte) (active, persistent []Route, err error) {
+	// ConvertTo-Json writes a single route as an object rather than as an
+	// array of one.
+	output = bytes.TrimSpace(output)
+	if len(output) > 0 && output[0] == '{' {
+		output = append(append([]byte{'['}, output...), ']')
+	}
+	var entries []getNetRouteEntry
+	if err := json.Unmarshal(output, &entries); err != nil || len(entries) == 0 {
+		return nil, nil, &ErrCantParse{}
+	}
+
+	for _, e := range entries {
+		dest, err := netip.ParsePrefix(e.DestinationPrefix)
+		if err != nil {
+			continue
+		}
+		r := Route{
+			Destination:     dest,
+			Interface:       e.InterfaceAlias,
+			InterfaceIndex:  e.InterfaceIndex,
+			Metric:          e.RouteMetric,
+			InterfaceMetric: e.InterfaceMetric,
+			Protocol:        string(e.Protocol),
+		}
+		if r.InterfaceMetric == 0 {
+			r.InterfaceMetric = e.IfMetric
+		}
+		if name, ok := getNetRouteProtocols[e.Protocol]; ok {
+			r.Protocol = name
+		}
+		// On-link routes have the unspecified address as next hop.
+		if gw, err := netip.ParseAddr(e.NextHop); err == nil && !gw.IsUnspecified() {
+			r.Gateway = withZone(gw, windowsIndexZone(e.InterfaceIndex))
+		}
+
+		if e.Store == "0" || e.Store == "PersistentStore" {
+			persistent = append(persistent, r)
+		} else {
+			active = append(active, r)
+		}
+	}
+	markPersistentRoutes(active, persistent)
+	return active, persistent, nil
+}
+
+// ParseWindowsNetshRoutes parses the output of
+//
+//	netsh interface ipv4 show route
+//	netsh interface ipv6 show route
+//
+// for example collected from another machine.
+func ParseWindowsNetshRoutes(output []byte) ([]Route, error) {
+	return parseNetshRoutes(output)
+}
+
+func parseNetshRoutes(output []byte) ([]Route, error) {
+	// netsh output is localized, but the columns are the same:
+	//
+	// Publish  Type      Met  Prefix                    Idx  Gateway/Interface Name
+	// -------  --------  ---  ------------------------  ---  ------------------------
+	// No       Manual    0    0.0.0.0/0                  12  192.168.1.1
+	// No       System    256  127.0.0.0/8                 1  Loopback Pseudo-Interface 1
+	//
+	// The last column holds the gateway, or the interface alias for
+	// on-link routes. Aliases may contain spaces.
+	var (
+		result []Route
+		header bool
+	)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.Trim(fields[0], "-") == "" {
+			header = true
+			continue
+		}
+		if !header {
+			continue
+		}
+
+		p := -1
+		for i := 2; i < len(fields)-2; i++ {
+			if _, err := netip.ParsePrefix(fields[i]); err == nil {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			continue
+		}
+		dest, _ := netip.ParsePrefix(fields[p])
+		metric, err := strconv.Atoi(fields[p-1])
+		if err != nil {
+			continue
+		}
+		index, err := strconv.Atoi(fields[p+1])
+		if err != nil {
+			continue
+		}
+
+		r := Route{
+			Destination:    dest,
+			InterfaceIndex: index,
+			Metric:         metric,
+			Protocol:       fields[p-2],
+		}
+		last := strings.Join(fields[p+2:], " ")
+		if gw, err := netip.ParseAddr(last); err == nil {
+			r.Gateway = withZone(gw, windowsIndexZone(index))
+		} else {
+			r.Interface = last
+		}
+		result = append(result, r)
+	}
+
+	if !header {
+		return nil, &ErrCantParse{}
+	}
+	return result, nil
+}
+
+// getNetIPInterfaceScript lists the metrics of the IP interfaces, which
+// Windows adds to the route metrics.
+const getNetIPInterfaceScript = "Get-NetIPInterface | " +
+	"Select-Object InterfaceIndex,InterfaceAlias,AddressFamily,InterfaceMetric,AutomaticMetric | " +
+	"ConvertTo-Json"
+
+// windowsInterfaceKey identifies an IP interface. Windows keeps separate
+// IPv4 and IPv6 interfaces, with separate metrics, for each adapter.
+type windowsInterfaceKey struct {
+	index int
+	ipv6  bool
+}
+
+// getNetIPInterfaceEntry is an interface of Get-NetIPInterface |
+// ConvertTo-Json.
+type getNetIPInterfaceEntry struct {
+	InterfaceIndex  int
+	InterfaceMetric int
+	AddressFamily   jsonEnum
+}
+
+// parseGetNetIPInterfaceJSON parses the interface metrics of the output of
+// Get-NetIPInterface | ConvertTo-Json.
+func parseGetNetIPInterfaceJSON(output []byte) (map[windowsInterfaceKey]int, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) > 0 && output[0] == '{' {
+		output = append(append([]byte{'['}, output...), ']')
+	}
+	var entries []getNetIPInterfaceEntry
+	if err := json.Unmarshal(output, &entries); err != nil || len(entries) == 0 {
+		return nil, &ErrCantParse{}
+	}
+
+	metrics := make(map[windowsInterfaceKey]int)
+	for _, e := range entries {
+		// AddressFamily is 2 (IPv4) or 23 (IPv6), as in Winsock.
+		ipv6 := e.AddressFamily == "23" || e.AddressFamily == "IPv6"
+		metrics[windowsInterfaceKey{e.InterfaceIndex, ipv6}] = e.InterfaceMetric
+	}
+	return metrics, nil
+}
+
+// parseNetshInterfaceMetrics parses the interface metrics of the output of
+//
+//	netsh interface ipv4 show interfaces
+//	netsh interface ipv6 show interfaces
+//
+// which lists one address family, given by ipv6.
+func parseNetshInterfaceMetrics(output []byte, ipv6 bool) (map[windowsInterfaceKey]int, error) {
+	// Idx     Met         MTU          State                Name
+	// ---  ----------  ----------  ------------  ---------------------------
+	//   1          75  4294967295  connected     Loopback Pseudo-Interface 1
+	//  12          25        1500  connected     Ethernet
+	var (
+		metrics = make(map[windowsInterfaceKey]int)
+		header  bool
+	)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.Trim(fields[0], "-") == "" {
+			header = true
+			continue
+		}
+		if !header || len(fields) < 2 {
+			continue
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		metric, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		metrics[windowsInterfaceKey{index, ipv6}] = metric
+	}
+
+	if !header {
+		return nil, &ErrCantParse{}
+	}
+	return metrics, nil
+}
+
+// applyWindowsInterfaceMetrics sets the InterfaceMetric of the routes
+// that lack one.
+func applyWindowsInterfaceMetrics(routes []Route, metrics map[windowsInterfaceKey]int) {
+	for i := range routes {
+		r := &routes[i]
+		if r.InterfaceMetric != 0 || r.InterfaceIndex == 0 {
+			continue
+		}
+		r.InterfaceMetric = metrics[windowsInterfaceKey{r.InterfaceIndex, r.Is6()}]
+	}
+}
@@ -0,0 +1,210 @@
+package gateway
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseWindowsGetNetRoute(t *testing.T) {
+	active, persistent, err := ParseWindowsGetNetRoute(routeTables[windowsGetNetRoute])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(active) != 6 || len(persistent) != 2 {
+		t.Fatalf("Expected 6 active and 2 persistent routes, got %+v and %+v", active, persistent)
+	}
+
+	want := []Route{
+		{
+			Destination:     netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:         netip.MustParseAddr("192.168.1.1"),
+			Interface:       "Ethernet",
+			InterfaceIndex:  12,
+			InterfaceMetric: 25,
+			Protocol:        "NetMgmt",
+			Persistent:      true,
+		},
+		{
+			Destination:     netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:         netip.MustParseAddr("10.0.0.1"),
+			Interface:       "Wi-Fi",
+			InterfaceIndex:  17,
+			InterfaceMetric: 35,
+			Protocol:        "Dhcp",
+		},
+		{
+			Destination:     netip.MustParsePrefix("::/0"),
+			Gateway:         netip.MustParseAddr("fe80::1%12"),
+			Interface:       "Ethernet",
+			InterfaceIndex:  12,
+			Metric:          256,
+			InterfaceMetric: 25,
+			Protocol:        "Icmp",
+		},
+	}
+	got := defaultRoutes(active)
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected default routes %+v", got)
+	}
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("Unexpected default route %+v != %+v", got[i], want[i])
+		}
+	}
+
+	// On-link routes have the unspecified address as next hop.
+	if active[2].Gateway.IsValid() || active[5].Gateway.IsValid() {
+		t.Errorf("Unexpected on-link routes %+v %+v", active[2], active[5])
+	}
+
+	if persistent[0].Inactive || !persistent[1].Inactive || persistent[1].Gateway != netip.MustParseAddr("172.16.0.1") {
+		t.Errorf("Unexpected persistent routes %+v", persistent)
+	}
+
+	t.Run(windowsGetNetRouteSingle, func(t *testing.T) {
+		active, persistent, err := ParseWindowsGetNetRoute(routeTables[windowsGetNetRouteSingle])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := Route{
+			Destination:     netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:         netip.MustParseAddr("192.168.0.1"),
+			Interface:       "Ethernet",
+			InterfaceIndex:  6,
+			InterfaceMetric: 25,
+			Protocol:        "Dhcp",
+		}
+		if len(active) != 1 || len(persistent) != 0 || !reflect.DeepEqual(active[0], want) {
+			t.Errorf("Unexpected routes %+v %+v", active, persistent)
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, _, err := ParseWindowsGetNetRoute(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestParseWindowsNetshRoutes(t *testing.T) {
+	type testcase struct {
+		tableName string
+		routes    int
+		want      Route
+	}
+	testcases := []testcase{
+		{windowsNetshIPv4, 6, Route{
+			Destination:    netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:        netip.MustParseAddr("192.168.1.1"),
+			InterfaceIndex: 12,
+			Protocol:       "Manual",
+		}},
+		{windowsNetshIPv6, 4, Route{
+			Destination:    netip.MustParsePrefix("::/0"),
+			Gateway:        netip.MustParseAddr("fe80::1%12"),
+			InterfaceIndex: 12,
+			Metric:         256,
+			Protocol:       "Manual",
+		}},
+		{windowsNetshGerman, 3, Route{
+			Destination:    netip.MustParsePrefix("0.0.0.0/0"),
+			Gateway:        netip.MustParseAddr("192.168.178.1"),
+			InterfaceIndex: 7,
+			Protocol:       "Manuell",
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.tableName, func(t *testing.T) {
+			routes, err := ParseWindowsNetshRoutes(routeTables[tc.tableName])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(routes) != tc.routes {
+				t.Errorf("Expected %d routes, got %d", tc.routes, len(routes))
+			}
+			if got := defaultRoutes(routes); len(got) != 1 || !reflect.DeepEqual(got[0], tc.want) {
+				t.Errorf("Unexpected default routes %+v", got)
+			}
+		})
+	}
+
+	t.Run("interface names", func(t *testing.T) {
+		routes, err := ParseWindowsNetshRoutes(routeTables[windowsNetshIPv4])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if routes[1].Interface != "Loopback Pseudo-Interface 1" || routes[1].Gateway.IsValid() {
+			t.Errorf("Unexpected on-link route %+v", routes[1])
+		}
+	})
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := ParseWindowsNetshRoutes(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
+
+func TestWindowsEffectiveMetric(t *testing.T) {
+	routes, err := ParseWindowsNetshRoutes(routeTables[windowsNetshVPN])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	netshMetrics, err := parseNetshInterfaceMetrics(routeTables[windowsNetshInterfaces], false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	psMetrics, err := parseGetNetIPInterfaceJSON(routeTables[windowsGetNetIPInterface])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Both sources agree on the IPv4 metrics; Get-NetIPInterface reports
+	// the IPv6 interfaces too.
+	for key, metric := range netshMetrics {
+		if psMetrics[key] != metric {
+			t.Errorf("Unexpected metric for %+v: %d != %d", key, psMetrics[key], metric)
+		}
+	}
+	if m := psMetrics[windowsInterfaceKey{12, true}]; m != 15 {
+		t.Errorf("Unexpected IPv6 metric %d", m)
+	}
+
+	applyWindowsInterfaceMetrics(routes, netshMetrics)
+
+	// The route metrics tie, so the interface metrics decide: Windows
+	// prefers the VPN.
+	mockGetter := newMockinterfaceGetter(t)
+	mockGetter.On("InterfaceByIndex", mock.Anything).Return(nil, errors.New("no such interface"))
+	s := newSnapshot(routes, mockGetter)
+
+	want := []string{"10.8.0.1", "192.168.1.1", "192.168.50.1"}
+	ips, err := s.Gateways()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ips) != len(want) {
+		t.Fatalf("Unexpected gateways %v", ips)
+	}
+	for i := range ips {
+		if ips[i].String() != want[i] {
+			t.Errorf("Unexpected gateway %v != %s", ips[i], want[i])
+		}
+	}
+	if m := s.Routes[0].EffectiveMetric(); m != 1 {
+		t.Errorf("Unexpected effective metric %d", m)
+	}
+
+	t.Run(randomData, func(t *testing.T) {
+		if _, err := parseNetshInterfaceMetrics(routeTables[randomData], false); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+		if _, err := parseGetNetIPInterfaceJSON(routeTables[randomData]); !errors.Is(err, &ErrCantParse{}) {
+			t.Errorf("Expected ErrCantParse, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// needsZone reports whether ip is ambiguous without an interface zone.
+func needsZone(ip net.IP) bool {
+	return ip.To4() == nil && (ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+// zonedIPAddr returns ip with zone attached if ip is a link-local address.
+// Global addresses are returned without a zone.
+func zonedIPAddr(ip net.IP, zone string) net.IPAddr {
+	if !needsZone(ip) {
+		zone = ""
+	}
+	return net.IPAddr{IP: ip, Zone: zone}
+}
+
+// withZone returns addr with zone attached if addr is a link-local address
+// that doesn't have a zone yet.
+func withZone(addr netip.Addr, zone string) netip.Addr {
+	if addr.Zone() != "" || !needsZone(addr.AsSlice()) {
+		return addr
+	}
+	return addr.WithZone(zone)
+}
+
+// parseZonedIP parses an address such as "fe80::1%en0" as printed by netstat.
+// It returns nil if s is not an address.
+func parseZonedIP(s string) *net.IPAddr {
+	host, zone, _ := strings.Cut(s, "%")
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	return &net.IPAddr{IP: ip, Zone: zone}
+}
+
+// ipAddrsToIPs drops the zones of addrs, removing the duplicates that
+// result when the same link-local gateway is reachable on several links.
+func ipAddrsToIPs(addrs []net.IPAddr) []net.IP {
+	seen := make(map[string]bool)
+	result := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		key := addr.IP.String()
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, addr.IP)
+		}
+	}
+	return result
+}
+
+// normalizeKAMEAddr converts an IPv6 address of a BSD routing message to
+// a usable address.
+//
+// KAME derived IPv6 stacks (the BSDs and Darwin) store scoped addresses in
+// their kernel-internal form, with the interface index embedded in bytes 2
+// and 3: fe80:4::1 is fe80::1 on interface 4. The index may instead, or in
+// addition, be carried in the sockaddr's scope ID, given here as zoneID.
+// x/net/route clears the embedded index of complete sockaddrs, but not of
+// the compact form the kernel uses for netmasks and some gateways, so
+// every address is normalized here.
+//
+// The embedded index is cleared and the scope, zoneID taking precedence,
+// becomes the zone of the result, named by zoneName. Unscoped addresses are
+// returned unchanged.
+func normalizeKAMEAddr(ip [16]byte, zoneID int, zoneName func(int) string) netip.Addr {
+	addr := netip.AddrFrom16(ip)
+	if !needsZone(addr.AsSlice()) {
+		return addr
+	}
+
+	if embedded := int(ip[2])<<8 | int(ip[3]); embedded != 0 {
+		if zoneID == 0 {
+			zoneID = embedded
+		}
+		ip[2], ip[3] = 0, 0
+		addr = netip.AddrFrom16(ip)
+	}
+	if zoneID == 0 {
+		return addr
+	}
+	return addr.WithZone(zoneName(zoneID))
+}
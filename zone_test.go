@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"net/netip"
+	"strconv"
+	"testing"
+)
+
+func TestNormalizeKAMEAddr(t *testing.T) {
+	names := map[int]string{4: "en0", 7: "bridge100"}
+	zoneName := func(index int) string {
+		if name, ok := names[index]; ok {
+			return name
+		}
+		return strconv.Itoa(index)
+	}
+
+	type testcase struct {
+		name     string
+		ip       [16]byte
+		zoneID   int
+		expected string
+	}
+
+	// Address bytes as found in the RTAX_GATEWAY sockaddrs of route dumps.
+	testcases := []testcase{
+		{"embedded index", [16]byte{0xfe, 0x80, 0x00, 0x04, 15: 0x01}, 0, "fe80::1%en0"},
+		{"embedded index and scope ID", [16]byte{0xfe, 0x80, 0x00, 0x04, 15: 0x01}, 4, "fe80::1%en0"},
+		{"scope ID wins", [16]byte{0xfe, 0x80, 0x00, 0x04, 15: 0x01}, 7, "fe80::1%bridge100"},
+		{"scope ID only", [16]byte{0xfe, 0x80, 8: 0x02, 9: 0x42, 10: 0xac, 11: 0xff, 12: 0xfe, 13: 0x11, 14: 0x00, 15: 0x03}, 7, "fe80::242:acff:fe11:3%bridge100"},
+		{"unknown interface", [16]byte{0xfe, 0x80, 0x00, 0x0c, 15: 0x01}, 0, "fe80::1%12"},
+		{"no scope", [16]byte{0xfe, 0x80, 15: 0x01}, 0, "fe80::1"},
+		{"link-local multicast", [16]byte{0xff, 0x02, 0x00, 0x04, 15: 0x01}, 0, "ff02::1%en0"},
+		{"interface-local multicast", [16]byte{0xff, 0x01, 0x00, 0x07, 15: 0x01}, 0, "ff01::1%bridge100"},
+		{"global", [16]byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x04, 15: 0x01}, 0, "2001:db8:4::1"},
+		{"global with scope ID", [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 0x01}, 4, "2001:db8::1"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr := normalizeKAMEAddr(tc.ip, tc.zoneID, zoneName)
+			if addr.String() != tc.expected {
+				t.Errorf("Unexpected address %v != %s", addr, tc.expected)
+			}
+			if _, err := netip.ParseAddr(addr.String()); err != nil {
+				t.Errorf("Result is not a valid address: %v", err)
+			}
+		})
+	}
+}